@@ -10,16 +10,40 @@ import (
 	"os"
 
 	"github.com/boschglobal/dse.clib/extra/go/command"
+	"github.com/boschglobal/dse.sdp/ast/internal/app/cache"
+	"github.com/boschglobal/dse.sdp/ast/internal/app/checksum"
 	"github.com/boschglobal/dse.sdp/ast/internal/app/convert"
+	"github.com/boschglobal/dse.sdp/ast/internal/app/decompile"
+	"github.com/boschglobal/dse.sdp/ast/internal/app/diagram"
+	"github.com/boschglobal/dse.sdp/ast/internal/app/explain"
 	"github.com/boschglobal/dse.sdp/ast/internal/app/generate"
+	"github.com/boschglobal/dse.sdp/ast/internal/app/importsim"
+	"github.com/boschglobal/dse.sdp/ast/internal/app/outdated"
+	"github.com/boschglobal/dse.sdp/ast/internal/app/overlay"
 	"github.com/boschglobal/dse.sdp/ast/internal/app/resolve"
+	"github.com/boschglobal/dse.sdp/ast/internal/app/sbom"
+	"github.com/boschglobal/dse.sdp/ast/internal/app/sweep"
+	"github.com/boschglobal/dse.sdp/ast/internal/app/validate"
+	"github.com/boschglobal/dse.sdp/ast/internal/app/vendoring"
 )
 
 var cmds = []command.CommandRunner{
 	command.NewHelpCommand("help"),
+	cache.NewCacheCommand("cache"),
+	checksum.NewChecksumCommand("checksum"),
 	convert.NewConvertCommand("convert"),
+	decompile.NewDecompileCommand("decompile"),
+	diagram.NewDiagramCommand("diagram"),
+	explain.NewExplainCommand("explain"),
 	generate.NewGenerateCommand("generate"),
+	importsim.NewImportSimCommand("import-sim"),
+	outdated.NewOutdatedCommand("outdated"),
+	overlay.NewOverlayCommand("overlay"),
 	resolve.NewResolveCommand("resolve"),
+	sbom.NewSbomCommand("sbom"),
+	sweep.NewSweepCommand("sweep"),
+	validate.NewValidateCommand("validate"),
+	vendoring.NewVendorCommand("vendor"),
 }
 
 var usage = `
@@ -31,7 +55,19 @@ Usage:
 
     ast convert -input example/ast.json -output example/ast.yaml
     ast resolve -input example/ast.yaml
+    ast cache list
+    ast outdated -input example/ast.yaml
+    ast validate -input example/ast.yaml
+    ast overlay -base example/ast.yaml -overlay example/hil.yaml -output example/ast_hil.yaml
+    ast decompile -input example/ast.yaml -output example/sim.dse
+    ast explain -input example/ast.yaml -model input
+    ast diagram -input example/ast.yaml -format dot
+    ast checksum -input example/ast.yaml -lock sdp.lock
+    ast vendor -input example/ast.yaml -output vendor
+    ast sbom -input example/ast.yaml -format spdx-json -output sbom.spdx.json
     ast generate -input example/ast.yaml -output example/sim
+    ast sweep -input example/ast.yaml -matrix example/matrix.yaml
+    ast import-sim -input example/sim -output example/ast.yaml
 
 `
 
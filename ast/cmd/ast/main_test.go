@@ -10,11 +10,14 @@ package main
 import (
 	"fmt"
 	"os"
+	"reflect"
 	"strings"
 	"testing"
 
 	"github.com/goccy/go-yaml"
 	"github.com/rogpeppe/go-internal/testscript"
+
+	"github.com/boschglobal/dse.sdp/ast/internal/pkg/source"
 )
 
 func TestMain(m *testing.M) {
@@ -92,6 +95,28 @@ func TestE2E(t *testing.T) {
 
 				}
 			},
+			"astequal": func(ts *testscript.TestScript, neg bool, args []string) {
+				// Compare two Simulation ASTs, ignoring the labels and the
+				// source annotations (which locate the DSE script lines).
+				if len(args) != 2 {
+					ts.Fatalf("astequal <file> <file>")
+				}
+				var docs [2]any
+				for i, file := range args {
+					if err := yaml.Unmarshal([]byte(ts.ReadFile(file)), &docs[i]); err != nil {
+						ts.Fatalf("astequal %q; %v", file, err)
+					}
+					docs[i] = stripAst(docs[i])
+				}
+				if reflect.DeepEqual(docs[0], docs[1]) == neg {
+					a, _ := yaml.Marshal(docs[0])
+					b, _ := yaml.Marshal(docs[1])
+					if neg {
+						ts.Fatalf("astequal %q %q; ASTs are equal (unexpected)", args[0], args[1])
+					}
+					ts.Fatalf("astequal %q %q; ASTs differ:\n%s\n---\n%s", args[0], args[1], a, b)
+				}
+			},
 		},
 		Setup: func(e *testscript.Env) error {
 			var vars = []string{
@@ -103,3 +128,25 @@ func TestE2E(t *testing.T) {
 		},
 	})
 }
+
+// stripAst removes the labels and source annotations from a (decoded) AST.
+func stripAst(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, item := range v {
+			if k == "labels" || strings.HasPrefix(k, source.Annotation) {
+				delete(v, k)
+				continue
+			}
+			v[k] = stripAst(item)
+		}
+		if annotations, ok := v["annotations"].(map[string]any); ok && len(annotations) == 0 {
+			delete(v, "annotations")
+		}
+	case []any:
+		for i := range v {
+			v[i] = stripAst(v[i])
+		}
+	}
+	return v
+}
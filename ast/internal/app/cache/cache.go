@@ -0,0 +1,156 @@
+// Copyright 2025 Robert Bosch GmbH
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cache
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/boschglobal/dse.clib/extra/go/command"
+	"github.com/boschglobal/dse.clib/extra/go/command/log"
+
+	metadatacache "github.com/boschglobal/dse.sdp/ast/internal/pkg/cache"
+)
+
+var actions = []string{"list", "show", "prune", "verify"}
+
+type CacheCommand struct {
+	command.Command
+
+	cacheDir string
+	ttl      time.Duration
+	all      bool
+	logLevel int
+
+	action string
+	args   []string
+}
+
+func NewCacheCommand(name string) *CacheCommand {
+	c := &CacheCommand{
+		Command: command.Command{
+			Name:    name,
+			FlagSet: flag.NewFlagSet(name, flag.ExitOnError),
+		},
+	}
+	c.FlagSet().StringVar(&c.cacheDir, "cache", "out/cache", "cache directory")
+	c.FlagSet().DurationVar(&c.ttl, "ttl", 0, "prune: remove entries older than this duration (e.g. 168h)")
+	c.FlagSet().BoolVar(&c.all, "all", false, "prune: remove all entries")
+	c.FlagSet().IntVar(&c.logLevel, "log", 4, "Loglevel")
+	return c
+}
+
+func (c CacheCommand) Name() string {
+	return c.Command.Name
+}
+
+func (c CacheCommand) FlagSet() *flag.FlagSet {
+	return c.Command.FlagSet
+}
+
+// Parse the command line: ast cache <list|show|prune|verify> [options] [ref].
+func (c *CacheCommand) Parse(args []string) error {
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		c.action = args[0]
+		args = args[1:]
+	}
+	if err := c.FlagSet().Parse(args); err != nil {
+		return err
+	}
+	c.args = c.FlagSet().Args()
+	if c.action == "" && len(c.args) > 0 {
+		c.action, c.args = c.args[0], c.args[1:]
+	}
+	return nil
+}
+
+func (c *CacheCommand) Run() error {
+	slog.SetDefault(log.NewLogger(c.logLevel))
+
+	cache, err := metadatacache.Open(c.cacheDir)
+	if err != nil {
+		return err
+	}
+	switch c.action {
+	case "list":
+		return c.list(cache)
+	case "show":
+		return c.show(cache)
+	case "prune":
+		return c.prune(cache)
+	case "verify":
+		return c.verify(cache)
+	default:
+		return fmt.Errorf("unknown cache action '%s' (expected one of: %s)", c.action, strings.Join(actions, ", "))
+	}
+}
+
+func (c *CacheCommand) list(cache *metadatacache.Cache) error {
+	w := tabwriter.NewWriter(flag.CommandLine.Output(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "FILE\tSIZE\tFETCHED\tETAG\tURL")
+	for _, e := range cache.Entries() {
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\n", e.File[:min(12, len(e.File))], e.Size, e.Fetched.Format(time.RFC3339), e.ETag, e.Url)
+	}
+	for _, f := range cache.Orphans() {
+		fmt.Fprintf(w, "%s\t-\t-\t-\t(not indexed)\n", f[:min(12, len(f))])
+	}
+	return w.Flush()
+}
+
+func (c *CacheCommand) show(cache *metadatacache.Cache) error {
+	if len(c.args) != 1 {
+		return fmt.Errorf("show requires one reference (url or file)")
+	}
+	entries := cache.Find(c.args[0])
+	if len(entries) == 0 {
+		return fmt.Errorf("cache entry not found: %s", c.args[0])
+	}
+	if len(entries) > 1 {
+		return fmt.Errorf("cache reference is ambiguous: %s (%d entries)", c.args[0], len(entries))
+	}
+	e := entries[0]
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "File:    %s\n", e.File)
+	fmt.Fprintf(out, "Url:     %s\n", e.Url)
+	fmt.Fprintf(out, "Fetched: %s\n", e.Fetched.Format(time.RFC3339))
+	fmt.Fprintf(out, "ETag:    %s\n", e.ETag)
+	fmt.Fprintf(out, "Size:    %d\n", e.Size)
+	fmt.Fprintf(out, "Sha256:  %s\n", e.Sha256)
+	data, err := os.ReadFile(cache.Path(e.Url))
+	if err != nil {
+		return fmt.Errorf("Error reading cache file: %v", err)
+	}
+	fmt.Fprintf(out, "---\n%s", data)
+	return nil
+}
+
+func (c *CacheCommand) prune(cache *metadatacache.Cache) error {
+	removed, err := cache.Prune(c.ttl, c.all)
+	if err != nil {
+		return err
+	}
+	for _, f := range removed {
+		fmt.Fprintf(flag.CommandLine.Output(), "Removed: %s\n", f)
+	}
+	fmt.Fprintf(flag.CommandLine.Output(), "Pruned %d cache file(s)\n", len(removed))
+	return nil
+}
+
+func (c *CacheCommand) verify(cache *metadatacache.Cache) error {
+	issues := cache.Verify()
+	for _, issue := range issues {
+		fmt.Fprintf(flag.CommandLine.Output(), "%s\n", issue)
+	}
+	if len(issues) > 0 {
+		return fmt.Errorf("cache verify failed: %d issue(s) found", len(issues))
+	}
+	fmt.Fprintf(flag.CommandLine.Output(), "Cache verified: %s\n", c.cacheDir)
+	return nil
+}
@@ -0,0 +1,37 @@
+// Copyright 2025 Robert Bosch GmbH
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	metadatacache "github.com/boschglobal/dse.sdp/ast/internal/pkg/cache"
+)
+
+func TestCacheCommand(t *testing.T) {
+	dir := t.TempDir()
+	c, err := metadatacache.Open(dir)
+	require.NoError(t, err)
+	require.NoError(t, c.Put("https://example.com/a/Taskfile.yml", []byte("version: '3'\n"), ""))
+
+	cmd := NewCacheCommand("test_cache")
+	require.NoError(t, cmd.Parse([]string{"show", "-cache", dir, "https://example.com/a/Taskfile.yml"}))
+	assert.Equal(t, "show", cmd.action)
+	assert.Equal(t, []string{"https://example.com/a/Taskfile.yml"}, cmd.args)
+	assert.NoError(t, cmd.Run())
+
+	for _, action := range []string{"list", "verify", "prune"} {
+		cmd := NewCacheCommand("test_cache")
+		require.NoError(t, cmd.Parse([]string{"-cache", dir, action}))
+		assert.NoError(t, cmd.Run(), action)
+	}
+
+	cmd = NewCacheCommand("test_cache")
+	require.NoError(t, cmd.Parse([]string{"clean", "-cache", dir}))
+	assert.ErrorContains(t, cmd.Run(), "unknown cache action 'clean'")
+}
@@ -0,0 +1,421 @@
+// Copyright 2025 Robert Bosch GmbH
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package checksum
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"text/template"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/boschglobal/dse.clib/extra/go/command"
+	"github.com/boschglobal/dse.clib/extra/go/command/log"
+
+	"github.com/boschglobal/dse.sdp/ast/internal/app/resolve"
+	"github.com/boschglobal/dse.sdp/ast/internal/pkg/artifact"
+	"github.com/boschglobal/dse.sdp/ast/internal/pkg/provider"
+)
+
+// tagPattern matches the characters which are removed from a version for the
+// TAG of the generated Taskfile (e.g. v2.1.15 -> 2.1.15).
+var tagPattern = regexp.MustCompile(`[^0-9\.]+`)
+
+type ChecksumCommand struct {
+	command.Command
+
+	inputFile    string
+	providerFile string
+	lockFile     string
+	jobs         int
+	timeout      time.Duration
+	logLevel     int
+
+	client    *http.Client
+	providers *provider.Registry
+	arch      string
+}
+
+func NewChecksumCommand(name string) *ChecksumCommand {
+	c := &ChecksumCommand{
+		Command: command.Command{
+			Name:    name,
+			FlagSet: flag.NewFlagSet(name, flag.ExitOnError),
+		},
+	}
+	c.FlagSet().StringVar(&c.inputFile, "input", "", "path to Simulation AST file")
+	c.FlagSet().StringVar(&c.providerFile, "providers", "", "path to metadata provider config file")
+	c.FlagSet().StringVar(&c.lockFile, "lock", "", "path to lockfile (e.g. sdp.lock), lock entries of repos are updated")
+	c.FlagSet().IntVar(&c.jobs, "jobs", 4, "number of parallel downloads")
+	c.FlagSet().DurationVar(&c.timeout, "timeout", 10*time.Minute, "timeout for each download")
+	c.FlagSet().IntVar(&c.logLevel, "log", 4, "Loglevel")
+	return c
+}
+
+func (c ChecksumCommand) Name() string {
+	return c.Command.Name
+}
+
+func (c ChecksumCommand) FlagSet() *flag.FlagSet {
+	return c.Command.FlagSet
+}
+
+func (c *ChecksumCommand) Parse(args []string) error {
+	return c.FlagSet().Parse(args)
+}
+
+func (c *ChecksumCommand) Run() error {
+	slog.SetDefault(log.NewLogger(c.logLevel))
+
+	c.inputFile = filepath.Join("out", c.inputFile)
+	c.client = &http.Client{Timeout: c.timeout}
+	var err error
+	if c.providers, err = provider.LoadRegistry(c.providerFile); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(flag.CommandLine.Output(), "Reading file: %s\n", c.inputFile)
+	data, err := os.ReadFile(c.inputFile)
+	if err != nil {
+		return fmt.Errorf("Error reading AST file: %v", err)
+	}
+	doc := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("Error parsing AST file: %v", err)
+	}
+	jobs := []*checksumJob{}
+	spec, _ := doc["spec"].(map[string]interface{})
+	if c.arch, _ = spec["arch"].(string); c.arch == "" {
+		c.arch = "linux-amd64"
+	}
+	uses, _ := spec["uses"].([]interface{})
+	for _, _use := range uses {
+		use, ok := _use.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		useUrl, _ := use["url"].(string)
+		if !strings.HasPrefix(useUrl, "https://") && !strings.HasPrefix(useUrl, "http://") {
+			continue
+		}
+		jobs = append(jobs, &checksumJob{use: use})
+	}
+	c.checksumUses(jobs)
+
+	issues := []string{}
+	w := tabwriter.NewWriter(flag.CommandLine.Output(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSHA256\tURL")
+	for _, job := range jobs {
+		name, _ := job.use["name"].(string)
+		if job.err != nil {
+			issues = append(issues, fmt.Sprintf("uses '%s': %v", name, job.err))
+			continue
+		}
+		if job.sha256 == "" {
+			// A repo without a package is not pinned (its metadata Taskfile is locked).
+			fmt.Fprintf(w, "%s\t%s\t%s\n", name, "-", artifact.RedactUrl(job.taskfile))
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", name, job.sha256, artifact.RedactUrl(job.resolved))
+		metadata, ok := job.use["metadata"].(map[string]interface{})
+		if !ok {
+			metadata = map[string]interface{}{}
+			job.use["metadata"] = metadata
+		}
+		metadata[artifact.MetadataSha256] = job.sha256
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(flag.CommandLine.Output(), "Writing file: %s\n", c.inputFile)
+	if err := writeAst(doc, c.inputFile); err != nil {
+		return err
+	}
+	if c.lockFile != "" {
+		fmt.Fprintf(flag.CommandLine.Output(), "Writing lockfile: %s\n", c.lockFile)
+		if err := c.updateLock(jobs); err != nil {
+			return err
+		}
+	}
+	for _, issue := range issues {
+		fmt.Fprintf(flag.CommandLine.Output(), "%s\n", issue)
+	}
+	if len(issues) > 0 {
+		return fmt.Errorf("checksum failed: %d issue(s) found", len(issues))
+	}
+	return nil
+}
+
+type checksumJob struct {
+	use map[string]interface{}
+
+	resolved       string // URL of the content (package of a repo, or the file).
+	sha256         string
+	taskfile       string // Metadata Taskfile of a repo.
+	taskfileSha256 string
+	err            error
+}
+
+// checksumUses calculates the SHA-256 of the uses entries of the jobs with a
+// bounded pool of workers (see -jobs).
+func (c *ChecksumCommand) checksumUses(jobs []*checksumJob) {
+	queue := make(chan *checksumJob)
+	var wg sync.WaitGroup
+	for range max(1, c.jobs) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range queue {
+				c.checksum(job)
+			}
+		}()
+	}
+	for _, job := range jobs {
+		queue <- job
+	}
+	close(queue)
+	wg.Wait()
+}
+
+// checksum calculates the SHA-256 of a uses entry (see artifact.MetadataSha256),
+// for a repo the SHA-256 of its package (for the arch of the simulation), and
+// of the first Taskfile variant which is found (for the lock), otherwise the
+// SHA-256 of the file.
+func (c *ChecksumCommand) checksum(job *checksumJob) {
+	useUrl, _ := job.use["url"].(string)
+	version, _ := job.use["version"].(string)
+	if version == "" {
+		req, err := c.fileRequest(job.use)
+		if err != nil {
+			job.err = err
+			return
+		}
+		job.resolved = useUrl
+		job.sha256, job.err = c.hashFile(req)
+		return
+	}
+
+	taskfileUrls := c.providers.TaskfileURLs(useUrl, version)
+	if len(taskfileUrls) == 0 {
+		job.err = fmt.Errorf("no metadata provider for %s", useUrl)
+		return
+	}
+	var data []byte
+	for _, taskfileUrl := range taskfileUrls {
+		req, err := http.NewRequest(http.MethodGet, artifact.ExpandEnv(taskfileUrl), nil)
+		if err != nil {
+			job.err = err
+			return
+		}
+		content, found, err := c.fetch(req)
+		if err != nil {
+			job.err = err
+			return
+		}
+		if found {
+			job.taskfile, data = taskfileUrl, content
+			break
+		}
+	}
+	if data == nil {
+		job.err = fmt.Errorf("metadata Taskfile not found (url=%s, version=%s)", useUrl, version)
+		return
+	}
+	hash := sha256.Sum256(data)
+	job.taskfileSha256 = hex.EncodeToString(hash[:])
+
+	packageUrl, err := c.packageUrl(data, useUrl, version)
+	if err != nil || packageUrl == "" {
+		job.err = err
+		return
+	}
+	user, _ := job.use["user"].(string)
+	token, _ := job.use["token"].(string)
+	req, err := downloadRequest(packageUrl, user, token)
+	if err != nil {
+		job.err = err
+		return
+	}
+	job.resolved = packageUrl
+	job.sha256, job.err = c.hashFile(req)
+}
+
+// packageUrl returns the URL of the package of a repo (metadata package:download
+// of its Taskfile), rendered as by the generated Taskfile. Returns "" if the
+// repo has no package.
+func (c *ChecksumCommand) packageUrl(taskfile []byte, useUrl string, version string) (string, error) {
+	var doc struct {
+		Metadata struct {
+			Package struct {
+				Download string `yaml:"download"`
+			} `yaml:"package"`
+		} `yaml:"metadata"`
+	}
+	if err := yaml.Unmarshal(taskfile, &doc); err != nil {
+		return "", fmt.Errorf("Error parsing metadata Taskfile: %v", err)
+	}
+	if doc.Metadata.Package.Download == "" {
+		return "", nil
+	}
+	tmpl, err := template.New("package").Parse(doc.Metadata.Package.Download)
+	if err != nil {
+		return "", fmt.Errorf("Error parsing package url: %v", err)
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, map[string]string{
+		"REPO":          useUrl,
+		"TAG":           tagPattern.ReplaceAllString(version, ""),
+		"PLATFORM_ARCH": c.arch,
+	}); err != nil {
+		return "", fmt.Errorf("Error rendering package url: %v", err)
+	}
+	return b.String(), nil
+}
+
+// hashFile returns the SHA-256 of the file of the request, a file which is not
+// found is an error.
+func (c *ChecksumCommand) hashFile(req *http.Request) (string, error) {
+	sha, found, err := c.hash(req)
+	if err != nil {
+		return "", err
+	}
+	if !found {
+		return "", fmt.Errorf("file not found: %s", artifact.RedactUrl(req.URL.String()))
+	}
+	return sha, nil
+}
+
+// fileRequest returns the request for the file of a uses entry, with the same
+// authentication as the download tasks of the generated Taskfile.
+func (c *ChecksumCommand) fileRequest(use map[string]interface{}) (*http.Request, error) {
+	useUrl, _ := use["url"].(string)
+	user, _ := use["user"].(string)
+	token, _ := use["token"].(string)
+	u, err := url.Parse(useUrl)
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasPrefix(u.Host, "github.boschdevcloud.") {
+		// GitHub asset (see download-file-github-asset).
+		pathParts := strings.Split(u.Path, "/")
+		if len(pathParts) < 3 {
+			return nil, fmt.Errorf("unsupported asset url: %s", useUrl)
+		}
+		apiUrl, err := u.Parse(fmt.Sprintf("/api/v3/repos/%s/%s", pathParts[1], pathParts[2]))
+		if err != nil {
+			return nil, err
+		}
+		return artifact.GitHubAssetRequest(c.client, apiUrl.String(), path.Base(path.Dir(u.Path)), path.Base(u.Path), artifact.ExpandEnv(token))
+	}
+	return downloadRequest(useUrl, user, token)
+}
+
+// downloadRequest returns the request for a file (see download-file), with
+// basic authentication when both user and token are set.
+func downloadRequest(rawUrl string, user string, token string) (*http.Request, error) {
+	req, err := http.NewRequest(http.MethodGet, rawUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+	if user, token := artifact.ExpandEnv(user), artifact.ExpandEnv(token); user != "" && token != "" {
+		req.SetBasicAuth(user, token)
+	}
+	return req, nil
+}
+
+// fetch returns the content of the response to the request, found is false if
+// the response is 404 Not Found.
+func (c *ChecksumCommand) fetch(req *http.Request) (data []byte, found bool, err error) {
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, false, fmt.Errorf("download failed: %s (%s)", artifact.RedactUrl(req.URL.String()), resp.Status)
+	}
+	data, err = io.ReadAll(resp.Body)
+	return data, err == nil, err
+}
+
+// hash returns the SHA-256 of the response to the request, found is false if
+// the response is 404 Not Found.
+func (c *ChecksumCommand) hash(req *http.Request) (sha string, found bool, err error) {
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return "", false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", false, fmt.Errorf("download failed: %s (%s)", artifact.RedactUrl(req.URL.String()), resp.Status)
+	}
+	hash := sha256.New()
+	if _, err := io.Copy(hash, resp.Body); err != nil {
+		return "", false, err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), true, nil
+}
+
+// updateLock updates the lock entries of the repos (uses with a version), the
+// lock entry records the Taskfile variant and SHA-256 (see ast resolve -lock).
+func (c *ChecksumCommand) updateLock(jobs []*checksumJob) error {
+	lock, err := resolve.LoadLockFile(c.lockFile)
+	if err != nil {
+		return err
+	}
+	for _, job := range jobs {
+		if job.taskfile == "" {
+			continue
+		}
+		name, _ := job.use["name"].(string)
+		useUrl, _ := job.use["url"].(string)
+		version, _ := job.use["version"].(string)
+		lock.Update(resolve.LockEntry{
+			Name:     name,
+			Url:      useUrl,
+			Tag:      version,
+			Resolved: job.taskfile,
+			Variant:  path.Base(job.taskfile),
+			Sha256:   job.taskfileSha256,
+		})
+	}
+	return lock.Save(c.lockFile)
+}
+
+func writeAst(doc map[string]interface{}, file string) error {
+	f, err := os.Create(file)
+	if err != nil {
+		return fmt.Errorf("error creating file: %v", err)
+	}
+	defer f.Close()
+	encoder := yaml.NewEncoder(f)
+	encoder.SetIndent(2)
+	defer encoder.Close()
+	if err := encoder.Encode(doc); err != nil {
+		return fmt.Errorf("error encoding YAML: %v", err)
+	}
+	return nil
+}
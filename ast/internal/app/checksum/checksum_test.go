@@ -0,0 +1,127 @@
+// Copyright 2025 Robert Bosch GmbH
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package checksum
+
+import (
+	"maps"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/boschglobal/dse.sdp/ast/internal/app/generate"
+	"github.com/boschglobal/dse.sdp/ast/internal/app/resolve"
+	"github.com/boschglobal/dse.sdp/ast/internal/pkg/artifact"
+	"github.com/boschglobal/dse.sdp/ast/internal/pkg/testutil"
+)
+
+const checksumTestAst = `---
+kind: Simulation
+spec:
+  arch: linux-amd64
+  stacks:
+    - name: default
+      models:
+        - name: input
+          model: dse.modelc.csv
+          uses: dse.modelc
+  uses:
+    - name: dse.modelc
+      url: SERVER/boschglobal/dse.modelc
+      version: v2.1.15
+      metadata:
+        container:
+          repository: ghcr.io/boschglobal/dse-modelc
+    - name: input
+      url: SERVER/fileshare/input.csv
+    - name: local
+      url: file:///data/local.csv
+`
+
+const checksumTestTaskfile = `---
+version: '3'
+metadata:
+  package:
+    download: '{{.REPO}}/releases/download/v{{.TAG}}/ModelC-{{.TAG}}-{{.PLATFORM_ARCH}}.zip'
+`
+
+var checksumTestFiles = map[string]string{
+	"/sdp/dse.modelc/v2.1.15/Taskfile.yml":                                            checksumTestTaskfile,
+	"/boschglobal/dse.modelc/releases/download/v2.1.15/ModelC-2.1.15-linux-amd64.zip": "package",
+	"/fileshare/input.csv": "a,b\n1,2\n",
+}
+
+// setupChecksumTest stages the AST (uses entries hosted on the server) and a
+// provider config (see testutil.FileServer.Stage).
+func setupChecksumTest(t *testing.T, files map[string]string) *testutil.FileServer {
+	server := testutil.NewFileServer(t, files, false)
+	server.Stage(t, checksumTestAst)
+	return server
+}
+
+func runChecksum(t *testing.T, args ...string) error {
+	cmd := NewChecksumCommand("checksum")
+	require.NoError(t, cmd.Parse(append([]string{"-input", "ast.yaml", "-providers", "providers.yaml", "-log", "1"}, args...)))
+	return cmd.Run()
+}
+
+func TestChecksum(t *testing.T) {
+	server := setupChecksumTest(t, checksumTestFiles)
+
+	require.NoError(t, runChecksum(t, "-lock", "sdp.lock"))
+
+	spec, _, err := generate.LoadSimulationAst("out/ast.yaml")
+	require.NoError(t, err)
+	uses := *spec.Uses
+	require.Len(t, uses, 3)
+	// The pin of a repo is the pin of its package (for the arch).
+	assert.Equal(t, testutil.Sha256("package"), artifact.UsesSha256(uses[0]))
+	assert.Equal(t, "ghcr.io/boschglobal/dse-modelc", (*uses[0].Metadata)["container"].(map[string]interface{})["repository"])
+	assert.Equal(t, testutil.Sha256("a,b\n1,2\n"), artifact.UsesSha256(uses[1]))
+	assert.Equal(t, "", artifact.UsesSha256(uses[2]))
+
+	// Only repos are locked, with the metadata Taskfile.
+	lock, err := resolve.LoadLockFile("sdp.lock")
+	require.NoError(t, err)
+	require.Len(t, lock.Uses, 1)
+	entry := lock.Lookup("dse.modelc", server.URL+"/boschglobal/dse.modelc", "v2.1.15")
+	require.NotNil(t, entry)
+	assert.Equal(t, server.URL+"/sdp/dse.modelc/v2.1.15/Taskfile.yml", entry.Resolved)
+	assert.Equal(t, "Taskfile.yml", entry.Variant)
+	assert.Equal(t, testutil.Sha256(checksumTestTaskfile), entry.Sha256)
+}
+
+func TestChecksum_noPackage(t *testing.T) {
+	files := maps.Clone(checksumTestFiles)
+	files["/sdp/dse.modelc/v2.1.15/Taskfile.yml"] = "version: '3'\n"
+	setupChecksumTest(t, files)
+
+	require.NoError(t, runChecksum(t, "-lock", "sdp.lock"))
+
+	// A repo without a package is not pinned, but locked.
+	spec, _, err := generate.LoadSimulationAst("out/ast.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, "", artifact.UsesSha256((*spec.Uses)[0]))
+	lock, err := resolve.LoadLockFile("sdp.lock")
+	require.NoError(t, err)
+	require.Len(t, lock.Uses, 1)
+	assert.Equal(t, testutil.Sha256("version: '3'\n"), lock.Uses[0].Sha256)
+}
+
+func TestChecksum_failed(t *testing.T) {
+	files := maps.Clone(checksumTestFiles)
+	delete(files, "/fileshare/input.csv")
+	setupChecksumTest(t, files)
+
+	err := runChecksum(t)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "1 issue(s) found")
+
+	// The checksums which were calculated are written.
+	spec, _, err := generate.LoadSimulationAst("out/ast.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, testutil.Sha256("package"), artifact.UsesSha256((*spec.Uses)[0]))
+	assert.Equal(t, "", artifact.UsesSha256((*spec.Uses)[1]))
+}
@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"log/slog"
+	"maps"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -21,8 +22,26 @@ import (
 	"github.com/boschglobal/dse.clib/extra/go/command/util"
 
 	"github.com/boschglobal/dse.schemas/code/go/dse/ast"
+
+	"github.com/boschglobal/dse.sdp/ast/internal/pkg/artifact"
+	"github.com/boschglobal/dse.sdp/ast/internal/pkg/source"
+	"github.com/boschglobal/dse.sdp/ast/internal/pkg/vars"
 )
 
+const originalDseScriptLabel = "original_dse_script"
+
+// inputList collects repeated -input options.
+type inputList []string
+
+func (l *inputList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *inputList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
 type NetworkInfo struct {
 	signal   string
 	mimeType string
@@ -31,15 +50,18 @@ type NetworkInfo struct {
 type ConvertCommand struct {
 	command.Command
 
-	inputFile  string
+	inputFiles inputList
 	outputFile string
+	strict     bool
 	logLevel   int
 
-	dslAst       []byte
+	dslAsts      [][]byte
 	networks     map[string]NetworkInfo
 	globalVars   *[]ast.Var
 	modelVars    map[string][]ast.Var
 	workflowVars map[string][]ast.Var
+	sourceFile   string          // DSE script of the fragment being built.
+	sources      ast.Annotations // Locations of the simulation channels.
 }
 
 func NewConvertCommand(name string) *ConvertCommand {
@@ -51,9 +73,11 @@ func NewConvertCommand(name string) *ConvertCommand {
 		networks:     map[string]NetworkInfo{},
 		modelVars:    map[string][]ast.Var{},
 		workflowVars: map[string][]ast.Var{},
+		sources:      ast.Annotations{},
 	}
-	c.FlagSet().StringVar(&c.inputFile, "input", "", "path to DSL generated AST file")
+	c.FlagSet().Var(&c.inputFiles, "input", "path to DSL generated AST file (repeatable, fragments are merged)")
 	c.FlagSet().StringVar(&c.outputFile, "output", "", "path to write generated AST file")
+	c.FlagSet().BoolVar(&c.strict, "strict", false, "fail on undefined or cyclic variable references")
 	c.FlagSet().IntVar(&c.logLevel, "log", 4, "Loglevel")
 	return c
 }
@@ -73,45 +97,94 @@ func (c *ConvertCommand) Parse(args []string) error {
 func (c *ConvertCommand) Run() error {
 	slog.SetDefault(log.NewLogger(c.logLevel))
 
+	if len(c.inputFiles) == 0 {
+		return fmt.Errorf("no input file specified")
+	}
 	outDir := "out"
-	inputPath := filepath.Join("out", c.inputFile)
-	c.inputFile = inputPath
+	for i := range c.inputFiles {
+		c.inputFiles[i] = filepath.Join("out", c.inputFiles[i])
+	}
 	outputPath := filepath.Join(outDir, c.outputFile)
 	c.outputFile = outputPath
 
-	fmt.Fprintf(flag.CommandLine.Output(), "Reading file: %s\n", c.inputFile)
+	for _, inputFile := range c.inputFiles {
+		fmt.Fprintf(flag.CommandLine.Output(), "Reading file: %s\n", inputFile)
+	}
 	fmt.Fprintf(flag.CommandLine.Output(), "Writing file: %s\n", c.outputFile)
 
-	if err := c.loadDslAST(c.inputFile); err != nil {
-		return err
+	for _, inputFile := range c.inputFiles {
+		dslAst, err := loadDslAST(inputFile)
+		if err != nil {
+			return err
+		}
+		c.dslAsts = append(c.dslAsts, dslAst)
 	}
 
-	labels := ast.Labels{"generator": "ast convert", "input_file": c.inputFile}
-
-	if originalDSE := strings.TrimSuffix(c.inputFile, ".json"); originalDSE != c.inputFile {
-		originalDSE = originalDSE + ".dse"
-		absOriginalDSE, err := filepath.Abs(originalDSE)
-		if err == nil {
-			if _, err := os.Stat(absOriginalDSE); err == nil {
-				labels["original_dse_script"] = absOriginalDSE
-				slog.Info(fmt.Sprintf("Detected original DSE script: %s", absOriginalDSE))
-			} else {
-				slog.Info(fmt.Sprintf("Original DSE script not found at: %s", absOriginalDSE))
+	// The DSE script of each fragment is recorded, the first as
+	// original_dse_script and the others as original_dse_script.<i>.
+	labels := ast.Labels{"generator": "ast convert", "input_file": c.inputFiles.String()}
+	for i, inputFile := range c.inputFiles {
+		if dseScript := originalDseScript(inputFile); dseScript != "" {
+			key := originalDseScriptLabel
+			if i > 0 {
+				key = fmt.Sprintf("%s.%d", originalDseScriptLabel, i)
 			}
+			labels[key] = dseScript
 		}
 	}
 
 	return c.generateSimulationAST(c.outputFile, labels)
 }
 
-func (c *ConvertCommand) loadDslAST(file string) error {
+// originalDseScript returns the (absolute) path of the DSE script which a DSL
+// AST file was generated from (i.e. sim.dse for sim.json), if present.
+func originalDseScript(inputFile string) string {
+	originalDSE := strings.TrimSuffix(inputFile, ".json")
+	if originalDSE == inputFile {
+		return ""
+	}
+	originalDSE = originalDSE + ".dse"
+	absOriginalDSE, err := filepath.Abs(originalDSE)
+	if err != nil {
+		return ""
+	}
+	if _, err := os.Stat(absOriginalDSE); err != nil {
+		slog.Info(fmt.Sprintf("Original DSE script not found at: %s", absOriginalDSE))
+		return ""
+	}
+	slog.Info(fmt.Sprintf("Detected original DSE script: %s", absOriginalDSE))
+	return absOriginalDSE
+}
+
+// dseScriptName returns the name of the DSE script which a DSL AST file was
+// generated from (i.e. openloop.dse for out/openloop.json).
+func dseScriptName(inputFile string) string {
+	name := filepath.Base(inputFile)
+	if script, ok := strings.CutSuffix(name, ".json"); ok {
+		return script + ".dse"
+	}
+	return name
+}
+
+// sourceLocation returns the location in the DSE script of a DSL AST object.
+// The DSL parser emits zero based token positions (the line in the script
+// and the column in the line).
+func (c *ConvertCommand) sourceLocation(value gjson.Result) (string, bool) {
+	line := value.Get("object.startLine")
+	column := value.Get("object.startColumn")
+	if !line.Exists() || !column.Exists() {
+		return "", false
+	}
+	return source.Location(c.sourceFile, int(line.Int())+1, int(column.Int())+1), true
+}
+
+func loadDslAST(file string) ([]byte, error) {
 	f, err := os.Open(file)
 	if err != nil {
-		return fmt.Errorf("Error reading DSL AST file: %v", err)
+		return nil, fmt.Errorf("Error reading DSL AST file: %v", err)
 	}
 	defer f.Close()
-	c.dslAst, err = ioutil.ReadAll(f)
-	return nil
+	return ioutil.ReadAll(f)
 }
 
 func (c *ConvertCommand) generateSimulationAST(file string, labels ast.Labels) error {
@@ -122,22 +195,61 @@ func (c *ConvertCommand) generateSimulationAST(file string, labels ast.Labels) e
 		},
 	}
 
-	arch := gjson.GetBytes(c.dslAst, "object.payload.simulation_arch.value")
-	simulation.Spec.Arch = arch.String()
+	// Build the simulation of each fragment, and merge.
+	specs := []ast.SimulationSpec{}
+	for i, dslAst := range c.dslAsts {
+		c.sourceFile = dseScriptName(c.inputFiles[i])
+		specs = append(specs, c.buildSimulationSpec(dslAst))
+	}
+	spec, conflicts := mergeSpecs(c.inputFiles, specs)
+	for _, conflict := range conflicts {
+		fmt.Fprintf(flag.CommandLine.Output(), "%s\n", conflict)
+	}
+	if len(conflicts) > 0 {
+		return fmt.Errorf("convert failed: %d conflict(s) found", len(conflicts))
+	}
+	simulation.Spec = spec
+	if len(c.sources) > 0 {
+		simulation.Metadata.Annotations = &c.sources
+	}
+	c.globalVars = simulation.Spec.Vars
+
+	c.resolveSimulationVars(&simulation)
+	if c.strict {
+		if err := vars.Report(vars.Check(simulation.Spec, os.LookupEnv)); err != nil {
+			return err
+		}
+	}
+
+	if err := util.WriteYaml(&simulation, file, false); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (c *ConvertCommand) buildSimulationSpec(dslAst []byte) ast.SimulationSpec {
+	var spec ast.SimulationSpec
 
-	stepsize := gjson.GetBytes(c.dslAst, "object.payload.stepsize.value").Float()
-	simulation.Spec.Stepsize = &stepsize
+	arch := gjson.GetBytes(dslAst, "object.payload.simulation_arch.value")
+	spec.Arch = arch.String()
 
-	endtime := gjson.GetBytes(c.dslAst, "object.payload.endtime.value").Float()
-	simulation.Spec.Endtime = &endtime
+	stepsize := gjson.GetBytes(dslAst, "object.payload.stepsize.value").Float()
+	spec.Stepsize = &stepsize
 
-	root := gjson.GetBytes(c.dslAst, "children")
+	endtime := gjson.GetBytes(dslAst, "object.payload.endtime.value").Float()
+	spec.Endtime = &endtime
+
+	root := gjson.GetBytes(dslAst, "children")
 
 	// Channels
 	channelList := buildList(root, "channels", func(value gjson.Result) ast.SimulationChannel {
 		simulationChannel := ast.SimulationChannel{
 			Name: value.Get("object.payload.channel_name.value").String(),
 		}
+		key := source.Key("channel", simulationChannel.Name)
+		if location, ok := c.sourceLocation(value); ok && c.sources[key] == nil {
+			c.sources[key] = location
+		}
 		// Networks
 		networkList := buildList(value, "children.networks", func(value gjson.Result) ast.SimulationNetwork {
 			name := value.Get("object.payload.network_name.value").String()
@@ -154,7 +266,7 @@ func (c *ConvertCommand) generateSimulationAST(file string, labels ast.Labels) e
 		simulationChannel.Networks = &networkList
 		return simulationChannel
 	})
-	simulation.Spec.Channels = channelList
+	spec.Channels = channelList
 
 	// Uses
 	usesList := buildList(root, "uses", func(value gjson.Result) ast.Uses {
@@ -166,9 +278,12 @@ func (c *ConvertCommand) generateSimulationAST(file string, labels ast.Labels) e
 			User:    util.StringPtr(value.Get("object.payload.user.value").String()),
 			Token:   util.StringPtr(value.Get("object.payload.token.value").String()),
 		}
+		if sha := value.Get("object.payload.sha256.value").String(); sha != "" {
+			uses.Metadata = &map[string]interface{}{artifact.MetadataSha256: sha}
+		}
 		return uses
 	})
-	simulation.Spec.Uses = &usesList
+	spec.Uses = &usesList
 
 	// Vars
 	varsList := buildList(root, "vars", func(value gjson.Result) ast.Var {
@@ -178,8 +293,7 @@ func (c *ConvertCommand) generateSimulationAST(file string, labels ast.Labels) e
 		}
 		return vars
 	})
-	simulation.Spec.Vars = &varsList
-	c.globalVars = &varsList
+	spec.Vars = &varsList
 
 	// Stacks
 	stackList := buildList(root, "stacks", func(value gjson.Result) ast.Stack {
@@ -212,6 +326,10 @@ func (c *ConvertCommand) generateSimulationAST(file string, labels ast.Labels) e
 				}
 			}(),
 		}
+		sources := ast.Annotations{}
+		if location, ok := c.sourceLocation(value); ok {
+			sources[source.Annotation] = location
+		}
 		//Annotations
 		annotations := ast.Annotations{}
 		value.Get("annotations").ForEach(func(_, v gjson.Result) bool {
@@ -219,7 +337,7 @@ func (c *ConvertCommand) generateSimulationAST(file string, labels ast.Labels) e
 			val := v.Get("object.payload.annotation_value.value").String()
 
 			if key != "" {
-				annotations[key] = val
+				annotations[key] = vars.ParseValue(val)
 			}
 			return true
 		})
@@ -247,6 +365,9 @@ func (c *ConvertCommand) generateSimulationAST(file string, labels ast.Labels) e
 			}
 			// Vars
 			varsList := buildList(value, "children.workflow_vars", func(value gjson.Result) ast.Var {
+				if location, ok := c.sourceLocation(value); ok {
+					sources[source.Key("workflow", workflow.Name, "var", value.Get("object.payload.var_name.value").String())] = location
+				}
 				vars := ast.Var{
 					Name:  value.Get("object.payload.var_name.value").String(),
 					Value: normalizeVarValue(value.Get("object.payload.var_value.value").String()),
@@ -277,6 +398,10 @@ func (c *ConvertCommand) generateSimulationAST(file string, labels ast.Labels) e
 
 		// Models
 		modelList := buildList(value, "children.models", func(value gjson.Result) ast.Model {
+			sources := ast.Annotations{}
+			if location, ok := c.sourceLocation(value); ok {
+				sources[source.Annotation] = location
+			}
 			model := ast.Model{
 				Name:  value.Get("object.payload.model_name.value").String(),
 				Model: value.Get("object.payload.model_repo_name.value").String(),
@@ -312,6 +437,9 @@ func (c *ConvertCommand) generateSimulationAST(file string, labels ast.Labels) e
 					Name:  value.Get("object.payload.channel_name.value").String(),
 					Alias: value.Get("object.payload.channel_alias.value").String(),
 				}
+				if location, ok := c.sourceLocation(value); ok {
+					sources[source.Key("channel", channel.Name)] = location
+				}
 				return channel
 			})
 			model.Channels = channelList
@@ -360,7 +488,7 @@ func (c *ConvertCommand) generateSimulationAST(file string, labels ast.Labels) e
 				key := v.Get("object.payload.annotation_name.value").String()
 				val := v.Get("object.payload.annotation_value.value").String()
 				if key != "" {
-					annotations[key] = val
+					annotations[key] = vars.ParseValue(val)
 				}
 				return true
 			})
@@ -388,6 +516,9 @@ func (c *ConvertCommand) generateSimulationAST(file string, labels ast.Labels) e
 						return nil
 					}(),
 				}
+				if location, ok := c.sourceLocation(value); ok {
+					sources[source.Key("file", files.Name)] = location
+				}
 				return files
 			})
 			if len(fileList) > 0 {
@@ -401,6 +532,9 @@ func (c *ConvertCommand) generateSimulationAST(file string, labels ast.Labels) e
 				}
 				// Vars
 				varsList := buildList(value, "children.workflow_vars", func(value gjson.Result) ast.Var {
+					if location, ok := c.sourceLocation(value); ok {
+						sources[source.Key("workflow", workflow.Name, "var", value.Get("object.payload.var_name.value").String())] = location
+					}
 					vars := ast.Var{
 						Name:  value.Get("object.payload.var_name.value").String(),
 						Value: normalizeVarValue(value.Get("object.payload.var_value.value").String()),
@@ -428,33 +562,43 @@ func (c *ConvertCommand) generateSimulationAST(file string, labels ast.Labels) e
 				return workflow
 			})
 			model.Workflows = &workflowList
+			addSources(&model.Annotations, sources)
 			return model
 		})
 		stack.Models = modelList
+		addSources(&stack.Annotations, sources)
 		return stack
 	})
-	simulation.Spec.Stacks = stackList
-	c.resolveSimulationVars(&simulation)
+	spec.Stacks = stackList
+	return spec
+}
 
-	if err := util.WriteYaml(&simulation, file, false); err != nil {
-		return err
+// addSources adds the source locations (of an object and its items) to the
+// annotations of the object.
+func addSources(annotations **ast.Annotations, sources ast.Annotations) {
+	if len(sources) == 0 {
+		return
 	}
-	return nil
+	if *annotations == nil {
+		*annotations = &ast.Annotations{}
+	}
+	maps.Copy(**annotations, sources)
 }
 
+// normalizeVarValue returns the value of a var: a quoted value ("..." or
+// '...') is kept as is (without the quotes), structured values (JSON or YAML
+// flow) are returned as JSON, other values are trimmed.
 func normalizeVarValue(v string) string {
+	v = strings.TrimSpace(v)
+	if unquoted, ok := vars.Unquote(v); ok {
+		return unquoted
+	}
 	// set of special characters that indicate an expression-like value.
 	const specialChars = "()[]{}|="
-	// If the value contains any of the special characters, return it as-is.
 	if strings.ContainsAny(v, specialChars) {
-		return v
-	}
-
-	// Otherwise, apply the standard normalization to trim whitespace and single quotes.
-	v = strings.TrimSpace(v)
-	if strings.HasPrefix(v, "'") && strings.HasSuffix(v, "'") {
-		v = strings.TrimPrefix(v, "'")
-		v = strings.TrimSuffix(v, "'")
+		if value, ok := vars.StructuredValue(v); ok {
+			return value
+		}
 	}
 	return v
 }
@@ -5,12 +5,16 @@
 package convert
 
 import (
+	"flag"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/boschglobal/dse.schemas/code/go/dse/ast"
 	"github.com/boschglobal/dse.sdp/ast/internal/app/generate"
+	"github.com/boschglobal/dse.sdp/ast/internal/pkg/artifact"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -121,3 +125,234 @@ Not created, outside the project folder
 	require.NoError(t, err)
 	assert.Equal(t, "Created\n", string(data))
 }
+
+func TestConvert_Strict(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	require.NoError(t, os.MkdirAll("out", 0755))
+
+	dslAst := `{"children":{"vars":[
+		{"object":{"payload":{"var_name":{"value":"A"},"var_value":{"value":"{{.B}}/{{.PATH}}"}}}},
+		{"object":{"payload":{"var_name":{"value":"B"},"var_value":{"value":"{{.A}}"}}}},
+		{"object":{"payload":{"var_name":{"value":"C"},"var_value":{"value":"{{.UNDEFINED}}"}}}}
+	]}}`
+	require.NoError(t, os.WriteFile(filepath.Join("out", "sim.json"), []byte(dslAst), 0644))
+
+	// Without strict mode, unresolved references are written as is.
+	cmd := NewConvertCommand("test_convert")
+	require.NoError(t, cmd.Parse([]string{"-input", "sim.json", "-output", "ast.yaml"}))
+	require.NoError(t, cmd.Run())
+	assert.FileExists(t, filepath.Join("out", "ast.yaml"))
+
+	cmd = NewConvertCommand("test_convert")
+	require.NoError(t, cmd.Parse([]string{"-input", "sim.json", "-output", "strict.yaml", "-strict"}))
+	err := cmd.Run()
+	assert.ErrorContains(t, err, "strict variable resolution failed: 2 issue(s) found")
+	assert.NoFileExists(t, filepath.Join("out", "strict.yaml"))
+}
+
+func TestConvert_TypedValues(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	require.NoError(t, os.MkdirAll("out", 0755))
+
+	annotation := func(name string, value string) string {
+		return fmt.Sprintf(`{"object":{"payload":{"annotation_name":{"value":%q},"annotation_value":{"value":%q}}}}`, name, value)
+	}
+	dslAst := fmt.Sprintf(`{"children":{
+		"vars":[
+			{"object":{"payload":{"var_name":{"value":"CYCLE_TIME"},"var_value":{"value":"{frame_in: 10}"}}}},
+			{"object":{"payload":{"var_name":{"value":"FRAMES"},"var_value":{"value":"'{frame_in: 10}'"}}}},
+			{"object":{"payload":{"var_name":{"value":"BUILD_ID"},"var_value":{"value":"\"0123\""}}}}
+		],
+		"stacks":[{"name":"default",
+			"annotations":[%s, %s],
+			"children":{"models":[{"object":{"payload":{"model_name":{"value":"input"},"model_repo_name":{"value":"dse.modelc.csv"}}},
+				"children":{"annotations":[%s, %s, %s, %s]}}]}
+		}]
+	}}`,
+		annotation("connection.timeout", "60"), annotation("simer-uses-selector", `"true"`),
+		annotation("cycle_time", `{"frame_in":10}`), annotation("enabled", "true"),
+		annotation("ratio", "0.5"), annotation("id", "'42'"))
+	require.NoError(t, os.WriteFile(filepath.Join("out", "sim.json"), []byte(dslAst), 0644))
+
+	cmd := NewConvertCommand("test_convert")
+	require.NoError(t, cmd.Parse([]string{"-input", "sim.json", "-output", "ast.yaml"}))
+	require.NoError(t, cmd.Run())
+
+	spec, _, err := generate.LoadSimulationAst(filepath.Join("out", "ast.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, `{"frame_in":10}`, (*spec.Vars)[0].Value)
+	assert.Equal(t, "{frame_in: 10}", (*spec.Vars)[1].Value)
+	assert.Equal(t, "0123", (*spec.Vars)[2].Value)
+	stack := spec.Stacks[0]
+	assert.Equal(t, ast.Annotations{"connection.timeout": 60, "simer-uses-selector": "true"}, *stack.Annotations)
+	annotations := *stack.Models[0].Annotations
+	assert.Equal(t, true, annotations["enabled"])
+	assert.Equal(t, 0.5, annotations["ratio"])
+	assert.Equal(t, "42", annotations["id"])
+	data, err := os.ReadFile(filepath.Join("out", "ast.yaml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "cycle_time:\n              frame_in: 10\n")
+}
+
+func TestConvert_UsesSha256(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	require.NoError(t, os.MkdirAll("out", 0755))
+
+	pin := "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+	dslAst := fmt.Sprintf(`{"children":{"uses":[
+		{"object":{"payload":{"use_item":{"value":"dse.modelc"},"link":{"value":"https://github.com/boschglobal/dse.modelc"},"version":{"value":"v2.1.23"}}}},
+		{"object":{"payload":{"use_item":{"value":"input"},"link":{"value":"https://example.com/input.csv"},"sha256":{"value":%q}}}}
+	]}}`, pin)
+	require.NoError(t, os.WriteFile(filepath.Join("out", "sim.json"), []byte(dslAst), 0644))
+
+	cmd := NewConvertCommand("test_convert")
+	require.NoError(t, cmd.Parse([]string{"-input", "sim.json", "-output", "ast.yaml"}))
+	require.NoError(t, cmd.Run())
+
+	spec, _, err := generate.LoadSimulationAst(filepath.Join("out", "ast.yaml"))
+	require.NoError(t, err)
+	require.Len(t, *spec.Uses, 2)
+	assert.Nil(t, (*spec.Uses)[0].Metadata)
+	assert.Equal(t, pin, artifact.UsesSha256((*spec.Uses)[1]))
+}
+
+func TestConvert_Fragments(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	require.NoError(t, os.MkdirAll("out", 0755))
+
+	shared := `{"object":{"payload":{"simulation_arch":{"value":"linux-amd64"},"stepsize":{"value":0.0005},"endtime":{"value":0.02}}},
+		"children":{
+			"channels":[{"object":{"payload":{"channel_name":{"value":"physical"}}}}],
+			"uses":[{"object":{"payload":{"use_item":{"value":"dse.modelc"},"link":{"value":"https://github.com/boschglobal/dse.modelc"},"version":{"value":"v2.1.23"}}}}],
+			"vars":[{"object":{"payload":{"var_name":{"value":"BUS_ID"},"var_value":{"value":"1"}}}}]
+		}}`
+	stack := func(name string, version string) string {
+		return fmt.Sprintf(`{"children":{
+			"channels":[{"object":{"payload":{"channel_name":{"value":"physical"}}}},
+				{"object":{"payload":{"channel_name":{"value":"network"}}},"children":{"networks":[{"object":{"payload":{"network_name":{"value":"CAN"},"mime_type":{"value":"application/x-automotive-bus;bus=can"}}}}]}}],
+			"uses":[{"object":{"payload":{"use_item":{"value":"dse.modelc"},"link":{"value":"https://github.com/boschglobal/dse.modelc"},"version":{"value":%q}}}}],
+			"stacks":[{"name":%q,"children":{"models":[{"object":{"payload":{"model_name":{"value":"%s_model"},"model_repo_name":{"value":"dse.modelc.csv"}}}}]}}]
+		}}`, version, name, name)
+	}
+	require.NoError(t, os.WriteFile(filepath.Join("out", "shared.json"), []byte(shared), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join("out", "shared.dse"), []byte("simulation\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join("out", "body.json"), []byte(stack("body", "v2.1.23")), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join("out", "chassis.json"), []byte(stack("chassis", "v2.1.23")), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join("out", "chassis.dse"), []byte("simulation\n"), 0644))
+
+	cmd := NewConvertCommand("test_convert")
+	require.NoError(t, cmd.Parse([]string{"-input", "shared.json", "-input", "body.json", "-input", "chassis.json", "-output", "ast.yaml"}))
+	require.NoError(t, cmd.Run())
+
+	spec, doc, err := generate.LoadSimulationAst(filepath.Join("out", "ast.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, "linux-amd64", spec.Arch)
+	assert.Equal(t, 0.0005, *spec.Stepsize)
+	require.Len(t, spec.Channels, 2)
+	assert.Equal(t, "physical", spec.Channels[0].Name)
+	assert.Equal(t, "CAN", (*spec.Channels[1].Networks)[0].Name)
+	assert.Len(t, *spec.Uses, 1)
+	assert.Len(t, *spec.Vars, 1)
+	require.Len(t, spec.Stacks, 2)
+	assert.Equal(t, "body", spec.Stacks[0].Name)
+	assert.Equal(t, "chassis_model", spec.Stacks[1].Models[0].Name)
+
+	labels := doc.Metadata.Labels
+	assert.Equal(t, "out/shared.json,out/body.json,out/chassis.json", labels["input_file"])
+	absShared, _ := filepath.Abs(filepath.Join("out", "shared.dse"))
+	absChassis, _ := filepath.Abs(filepath.Join("out", "chassis.dse"))
+	assert.Equal(t, absShared, labels["original_dse_script"])
+	assert.Equal(t, absChassis, labels["original_dse_script.2"])
+	assert.NotContains(t, labels, "original_dse_script.1")
+
+	// Conflicting fragments.
+	require.NoError(t, os.WriteFile(filepath.Join("out", "chassis.json"), []byte(stack("body", "v2.1.24")), 0644))
+	cmd = NewConvertCommand("test_convert")
+	require.NoError(t, cmd.Parse([]string{"-input", "shared.json", "-input", "body.json", "-input", "chassis.json", "-output", "conflict.yaml"}))
+	err = cmd.Run()
+	assert.ErrorContains(t, err, "convert failed: 2 conflict(s) found")
+	assert.NoFileExists(t, filepath.Join("out", "conflict.yaml"))
+}
+
+func TestMergeSpecs_conflicts(t *testing.T) {
+	f := func(v float64) *float64 { return &v }
+	_, conflicts := mergeSpecs([]string{"a.json", "b.json"}, []ast.SimulationSpec{
+		{
+			Arch:     "linux-amd64",
+			Stepsize: f(0.0005),
+			Channels: []ast.SimulationChannel{{Name: "network", Networks: &[]ast.SimulationNetwork{{Name: "CAN", MimeType: "bus=can;bus_id=1"}}}},
+			Vars:     &[]ast.Var{{Name: "BUS_ID", Value: "1"}},
+			Stacks:   []ast.Stack{{Name: "default"}},
+		},
+		{
+			Arch:     "linux-x86",
+			Stepsize: f(0.001),
+			Channels: []ast.SimulationChannel{{Name: "network", Networks: &[]ast.SimulationNetwork{{Name: "CAN", MimeType: "bus=can;bus_id=2"}}}},
+			Vars:     &[]ast.Var{{Name: "BUS_ID", Value: "2"}},
+			Stacks:   []ast.Stack{{Name: "default"}},
+		},
+	})
+	assert.Equal(t, []string{
+		"simulation arch: conflicting values 'linux-amd64' (a.json) and 'linux-x86' (b.json)",
+		"simulation stepsize: conflicting values '0.0005' (a.json) and '0.001' (b.json)",
+		"network 'CAN': conflicting definitions in a.json and b.json",
+		"var 'BUS_ID': conflicting definitions in a.json and b.json",
+		"stack 'default': defined in a.json and b.json",
+	}, conflicts)
+}
+
+func TestConvert_SourceLocations(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	require.NoError(t, os.MkdirAll("out", 0755))
+
+	// Token positions of the DSL parser are zero based.
+	position := func(line int, column int) string {
+		return fmt.Sprintf(`"startLine":%d,"startColumn":%d`, line, column)
+	}
+	dslAst := fmt.Sprintf(`{"children":{
+		"channels":[{"object":{%s,"payload":{"channel_name":{"value":"physical"}}}}],
+		"stacks":[{"name":"default","object":{},
+			"children":{"models":[{"object":{%s,"payload":{"model_name":{"value":"input"},"model_repo_name":{"value":"dse.modelc.csv"}}},
+				"children":{
+					"channels":[{"object":{%s,"payload":{"channel_name":{"value":"physical"},"channel_alias":{"value":"signal"}}}}],
+					"files":[{"object":{%s,"payload":{"file_name":{"value":"input.csv"},"file_value":{"value":"data/input.csv"}}}}],
+					"workflow":[{"object":{"payload":{"workflow_name":{"value":"generate"}}},
+						"children":{"workflow_vars":[{"object":{%s,"payload":{"var_name":{"value":"NODE"},"var_value":{"value":"{{.NODE}}"}}}}]}}]
+				}}]}
+		},{"name":"remote","object":{%s,"payload":{"stack_name":{"value":"remote"}}},
+			"children":{"models":[{"object":{"payload":{"model_name":{"value":"linear"},"model_repo_name":{"value":"dse.fmi.mcl"}}}}]}
+		}]
+	}}`, position(1, 0), position(4, 0), position(5, 2), position(6, 2), position(8, 4), position(10, 0))
+	require.NoError(t, os.WriteFile(filepath.Join("out", "openloop.json"), []byte(dslAst), 0644))
+
+	cmd := NewConvertCommand("test_convert")
+	require.NoError(t, cmd.Parse([]string{"-input", "openloop.json", "-output", "ast.yaml"}))
+	require.NoError(t, cmd.Run())
+
+	spec, doc, err := generate.LoadSimulationAst(filepath.Join("out", "ast.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, "openloop.dse:2:1", doc.Metadata.Annotations["sdp/source/channel/physical"])
+	assert.Nil(t, spec.Stacks[0].Annotations)
+	assert.Equal(t, ast.Annotations{
+		"sdp/source":                            "openloop.dse:5:1",
+		"sdp/source/channel/physical":           "openloop.dse:6:3",
+		"sdp/source/file/input.csv":             "openloop.dse:7:3",
+		"sdp/source/workflow/generate/var/NODE": "openloop.dse:9:5",
+	}, *spec.Stacks[0].Models[0].Annotations)
+	assert.Equal(t, ast.Annotations{"sdp/source": "openloop.dse:11:1"}, *spec.Stacks[1].Annotations)
+	assert.Nil(t, spec.Stacks[1].Models[0].Annotations)
+
+	// Strict variable resolution cites the location of the var.
+	cmd = NewConvertCommand("test_convert")
+	require.NoError(t, cmd.Parse([]string{"-input", "openloop.json", "-output", "strict.yaml", "-strict"}))
+	var buf strings.Builder
+	flag.CommandLine.SetOutput(&buf)
+	defer flag.CommandLine.SetOutput(nil)
+	assert.Error(t, cmd.Run())
+	assert.Contains(t, buf.String(), "openloop.dse:9:5: model 'input' (stack 'default'): workflow 'generate': var 'NODE': undefined reference {{.NODE}}")
+}
@@ -0,0 +1,139 @@
+// Copyright 2025 Robert Bosch GmbH
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package convert
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/boschglobal/dse.schemas/code/go/dse/ast"
+)
+
+// mergeSpecs merges the simulations built from several DSL AST fragments
+// (files, in order) into one simulation:
+//
+//   - arch, stepsize and endtime are taken from the fragments which set them,
+//   - channels (and their networks), uses and vars are merged by name,
+//   - stacks are appended.
+//
+// Items with the same name must have the same definition in each fragment,
+// otherwise a conflict is reported.
+func mergeSpecs(files []string, specs []ast.SimulationSpec) (ast.SimulationSpec, []string) {
+	if len(specs) == 1 {
+		return specs[0], nil
+	}
+	m := specMerger{
+		spec: ast.SimulationSpec{
+			Channels: []ast.SimulationChannel{},
+			Uses:     &[]ast.Uses{},
+			Vars:     &[]ast.Var{},
+			Stacks:   []ast.Stack{},
+		},
+		sources: map[string]string{},
+	}
+	for i, spec := range specs {
+		m.file = files[i]
+		m.mergeSettings(spec)
+		for _, channel := range spec.Channels {
+			m.mergeChannel(channel)
+		}
+		if spec.Uses != nil {
+			for _, uses := range *spec.Uses {
+				mergeNamed(&m, "uses", m.spec.Uses, uses, func(u ast.Uses) string { return u.Name })
+			}
+		}
+		if spec.Vars != nil {
+			for _, v := range *spec.Vars {
+				mergeNamed(&m, "var", m.spec.Vars, v, func(v ast.Var) string { return v.Name })
+			}
+		}
+		for _, stack := range spec.Stacks {
+			key := "stack/" + stack.Name
+			if source, ok := m.sources[key]; ok {
+				m.conflict("stack '%s': defined in %s and %s", stack.Name, source, m.file)
+				continue
+			}
+			m.sources[key] = m.file
+			m.spec.Stacks = append(m.spec.Stacks, stack)
+		}
+	}
+	return m.spec, m.conflicts
+}
+
+type specMerger struct {
+	spec      ast.SimulationSpec
+	file      string
+	sources   map[string]string // Fragment (file) which defined an item.
+	conflicts []string
+}
+
+func (m *specMerger) conflict(format string, a ...any) {
+	m.conflicts = append(m.conflicts, fmt.Sprintf(format, a...))
+}
+
+// mergeNamed adds an item to a list which is merged by name. An item with the
+// same name must have the same definition.
+func mergeNamed[T any](m *specMerger, kind string, list *[]T, item T, name func(T) string) {
+	key := kind + "/" + name(item)
+	for _, existing := range *list {
+		if name(existing) == name(item) {
+			if !reflect.DeepEqual(existing, item) {
+				m.conflict("%s '%s': conflicting definitions in %s and %s", kind, name(item), m.sources[key], m.file)
+			}
+			return
+		}
+	}
+	m.sources[key] = m.file
+	*list = append(*list, item)
+}
+
+func (m *specMerger) mergeSettings(spec ast.SimulationSpec) {
+	if spec.Arch != "" {
+		if m.spec.Arch == "" {
+			m.spec.Arch = spec.Arch
+			m.sources["arch"] = m.file
+		} else if m.spec.Arch != spec.Arch {
+			m.conflict("simulation arch: conflicting values '%s' (%s) and '%s' (%s)", m.spec.Arch, m.sources["arch"], spec.Arch, m.file)
+		}
+	}
+	mergeFloat := func(name string, target **float64, value *float64) {
+		if value == nil || *value == 0 {
+			if *target == nil {
+				*target = value
+			}
+			return
+		}
+		if *target == nil || **target == 0 {
+			*target = value
+			m.sources[name] = m.file
+		} else if **target != *value {
+			m.conflict("simulation %s: conflicting values '%v' (%s) and '%v' (%s)", name, **target, m.sources[name], *value, m.file)
+		}
+	}
+	mergeFloat("stepsize", &m.spec.Stepsize, spec.Stepsize)
+	mergeFloat("endtime", &m.spec.Endtime, spec.Endtime)
+}
+
+func (m *specMerger) mergeChannel(channel ast.SimulationChannel) {
+	var target *ast.SimulationChannel
+	for i := range m.spec.Channels {
+		if m.spec.Channels[i].Name == channel.Name {
+			target = &m.spec.Channels[i]
+		}
+	}
+	if target == nil {
+		m.spec.Channels = append(m.spec.Channels, ast.SimulationChannel{
+			Name:     channel.Name,
+			Networks: &[]ast.SimulationNetwork{},
+		})
+		target = &m.spec.Channels[len(m.spec.Channels)-1]
+	}
+	if channel.Networks == nil {
+		return
+	}
+	for _, network := range *channel.Networks {
+		mergeNamed(m, "network", target.Networks, network, func(n ast.SimulationNetwork) string { return n.Name })
+	}
+}
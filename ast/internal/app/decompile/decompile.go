@@ -0,0 +1,534 @@
+// Copyright 2025 Robert Bosch GmbH
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package decompile
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/tools/txtar"
+
+	"github.com/boschglobal/dse.clib/extra/go/command"
+	"github.com/boschglobal/dse.clib/extra/go/command/log"
+	"github.com/boschglobal/dse.clib/extra/go/file/handler/kind"
+
+	"github.com/boschglobal/dse.schemas/code/go/dse/ast"
+
+	"github.com/boschglobal/dse.sdp/ast/internal/app/generate"
+	"github.com/boschglobal/dse.sdp/ast/internal/pkg/artifact"
+	"github.com/boschglobal/dse.sdp/ast/internal/pkg/source"
+	"github.com/boschglobal/dse.sdp/ast/internal/pkg/vars"
+)
+
+type DecompileCommand struct {
+	command.Command
+
+	inputFile  string
+	outputFile string
+	logLevel   int
+
+	simulationAst ast.SimulationSpec
+	simulationDoc *kind.KindDoc
+}
+
+func NewDecompileCommand(name string) *DecompileCommand {
+	c := &DecompileCommand{
+		Command: command.Command{
+			Name:    name,
+			FlagSet: flag.NewFlagSet(name, flag.ExitOnError),
+		},
+	}
+	c.FlagSet().StringVar(&c.inputFile, "input", "", "path to Simulation AST file")
+	c.FlagSet().StringVar(&c.outputFile, "output", "", "path to write DSE script")
+	c.FlagSet().IntVar(&c.logLevel, "log", 4, "Loglevel")
+	return c
+}
+
+func (c DecompileCommand) Name() string {
+	return c.Command.Name
+}
+
+func (c DecompileCommand) FlagSet() *flag.FlagSet {
+	return c.Command.FlagSet
+}
+
+func (c *DecompileCommand) Parse(args []string) error {
+	return c.FlagSet().Parse(args)
+}
+
+func (c *DecompileCommand) Run() error {
+	slog.SetDefault(log.NewLogger(c.logLevel))
+
+	outDir := "out"
+	inputPath := filepath.Join("out", c.inputFile)
+	c.inputFile = inputPath
+	outputPath := filepath.Join(outDir, c.outputFile)
+	c.outputFile = outputPath
+
+	fmt.Fprintf(flag.CommandLine.Output(), "Reading file: %s\n", c.inputFile)
+	spec, doc, err := generate.LoadSimulationAst(c.inputFile)
+	if err != nil {
+		return err
+	}
+	c.simulationAst = *spec
+	c.simulationDoc = doc
+
+	script, warnings := Decompile(c.simulationAst)
+	for _, w := range warnings {
+		slog.Warn(w)
+	}
+	script += c.embeddedFiles()
+
+	fmt.Fprintf(flag.CommandLine.Output(), "Writing file: %s\n", c.outputFile)
+	if err := os.MkdirAll(filepath.Dir(c.outputFile), os.ModePerm); err != nil {
+		return err
+	}
+	return os.WriteFile(c.outputFile, []byte(script), 0644)
+}
+
+// embeddedFiles returns the txtar file sections of the original DSE script
+// (when available) so that they are preserved in the decompiled script.
+func (c *DecompileCommand) embeddedFiles() string {
+	if c.simulationDoc == nil || c.simulationDoc.Metadata.Labels == nil {
+		return ""
+	}
+	dseScriptPath, ok := c.simulationDoc.Metadata.Labels["original_dse_script"]
+	if !ok {
+		return ""
+	}
+	a, err := txtar.ParseFile(dseScriptPath)
+	if err != nil {
+		slog.Info(fmt.Sprintf("Original DSE script not readable: %s", dseScriptPath))
+		return ""
+	}
+	if len(a.Files) == 0 {
+		return ""
+	}
+	slog.Info(fmt.Sprintf("Copy embedded files from original DSE script: %s", dseScriptPath))
+	return "\n" + string(txtar.Format(&txtar.Archive{Files: a.Files}))
+}
+
+type scriptWriter struct {
+	b        strings.Builder
+	warnings []string
+}
+
+func (w *scriptWriter) line(format string, a ...any) {
+	w.b.WriteString(fmt.Sprintf(format, a...))
+	w.b.WriteString("\n")
+}
+
+func (w *scriptWriter) blank() {
+	w.b.WriteString("\n")
+}
+
+func (w *scriptWriter) warn(format string, a ...any) {
+	w.warnings = append(w.warnings, fmt.Sprintf(format, a...))
+}
+
+// Decompile prints the Simulation AST as a canonical DSE script. The script,
+// when parsed and converted, reproduces the AST (excluding items which are
+// added by later stages, such as metadata and model uses). Items which cannot
+// be represented in DSE script are reported as warnings.
+func Decompile(simSpec ast.SimulationSpec) (string, []string) {
+	w := &scriptWriter{}
+
+	// Simulation.
+	stepsize := 0.0005
+	if simSpec.Stepsize != nil {
+		stepsize = *simSpec.Stepsize
+	}
+	endtime := 0.005
+	if simSpec.Endtime != nil {
+		endtime = *simSpec.Endtime
+	}
+	arch := simSpec.Arch
+	if arch == "" {
+		arch = "linux-amd64"
+	}
+	w.line("simulation arch=%s stepsize=%s endtime=%s", arch, formatFloat(stepsize), formatFloat(endtime))
+
+	// Channels and networks.
+	for _, channel := range simSpec.Channels {
+		w.line("channel %s", channel.Name)
+		if channel.Networks != nil {
+			for _, network := range *channel.Networks {
+				w.line("network %s '%s'", network.Name, network.MimeType)
+			}
+		}
+	}
+
+	// Uses.
+	if simSpec.Uses != nil && len(*simSpec.Uses) > 0 {
+		w.blank()
+		w.line("uses")
+		for _, uses := range *simSpec.Uses {
+			w.line("%s", formatUses(uses))
+		}
+	}
+
+	// Locate the implicit (default) stack and the external stack. The DSL
+	// parser creates these stacks without a stack statement.
+	var defaultStack *ast.Stack
+	var externalStack *ast.Stack
+	stacks := []ast.Stack{}
+	for i, stack := range simSpec.Stacks {
+		switch {
+		case i == 0 && stack.Name == "default" && stack.Arch == nil:
+			defaultStack = &simSpec.Stacks[i]
+		case stack.Name == "external" && isExternalStack(stack):
+			externalStack = &simSpec.Stacks[i]
+		default:
+			stacks = append(stacks, stack)
+		}
+	}
+
+	// Global vars, envars and (default stack) workflows.
+	globalVars := []ast.Var{}
+	if simSpec.Vars != nil {
+		globalVars = *simSpec.Vars
+	}
+	globalEnv := []ast.Var{}
+	if defaultStack != nil && defaultStack.Env != nil {
+		globalEnv = *defaultStack.Env
+	}
+	if len(globalVars) > 0 || len(globalEnv) > 0 {
+		w.blank()
+	}
+	for _, v := range globalVars {
+		w.line("%s", formatVar(w, simSpec, v))
+	}
+	for _, e := range globalEnv {
+		w.line("%s", formatEnvar(e))
+	}
+	if defaultStack != nil && defaultStack.Workflows != nil {
+		for _, workflow := range *defaultStack.Workflows {
+			writeWorkflow(w, simSpec, workflow, nil)
+		}
+	}
+
+	// Stacks.
+	writeStack := func(stack ast.Stack, implicit bool, external []ast.Model) {
+		if implicit {
+			if len(scriptAnnotations(stack.Annotations)) > 0 {
+				w.blank()
+			}
+			writeAnnotations(w, stack.Annotations, fmt.Sprintf("stack '%s'", stack.Name))
+		} else {
+			w.blank()
+			stackLine := fmt.Sprintf("stack %s", stack.Name)
+			if stack.Stacked != nil && *stack.Stacked {
+				stackLine += " stacked=true"
+			}
+			if stack.Sequential != nil && *stack.Sequential {
+				stackLine += " sequential=true"
+			}
+			if stack.Arch != nil && *stack.Arch != arch {
+				stackLine += fmt.Sprintf(" arch=%s", *stack.Arch)
+			}
+			w.line("%s", stackLine)
+			if stack.Env != nil {
+				for _, e := range diffVars(globalEnv, *stack.Env, w, fmt.Sprintf("stack '%s' env", stack.Name)) {
+					w.line("%s", formatEnvar(e))
+				}
+			}
+			writeAnnotations(w, stack.Annotations, fmt.Sprintf("stack '%s'", stack.Name))
+			if stack.Workflows != nil {
+				for _, workflow := range *stack.Workflows {
+					writeWorkflow(w, simSpec, workflow, nil)
+				}
+			}
+		}
+		inheritedArch := arch
+		if !implicit && stack.Arch != nil {
+			inheritedArch = *stack.Arch
+		}
+		for i, model := range append(slices.Clone(stack.Models), external...) {
+			writeModel(w, simSpec, model, globalVars, inheritedArch, i > 0 || implicit)
+		}
+	}
+	externalModels := []ast.Model{}
+	if externalStack != nil {
+		externalModels = externalStack.Models
+	}
+	if defaultStack != nil {
+		if len(stacks) == 0 {
+			writeStack(*defaultStack, true, externalModels)
+		} else {
+			writeStack(*defaultStack, true, nil)
+		}
+	} else if len(stacks) == 0 {
+		for _, model := range externalModels {
+			writeModel(w, simSpec, model, globalVars, arch, true)
+		}
+	}
+	for i, stack := range stacks {
+		if i == len(stacks)-1 {
+			writeStack(stack, false, externalModels)
+		} else {
+			writeStack(stack, false, nil)
+		}
+	}
+
+	return w.b.String(), w.warnings
+}
+
+func isExternalStack(stack ast.Stack) bool {
+	for _, model := range stack.Models {
+		if model.External == nil || !*model.External || model.Model != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func writeModel(w *scriptWriter, simSpec ast.SimulationSpec, model ast.Model, globalVars []ast.Var, inheritedArch string, separate bool) {
+	modelLine := fmt.Sprintf("model %s", model.Name)
+	if model.Model != "" {
+		modelLine += fmt.Sprintf(" %s", model.Model)
+	}
+	if model.External != nil && *model.External {
+		modelLine += " external=true"
+	} else if model.Model == "" {
+		w.warn("model '%s': model repo name is required (non external model)", model.Name)
+	}
+	if model.Arch != nil && *model.Arch != inheritedArch {
+		modelLine += fmt.Sprintf(" arch=%s", *model.Arch)
+	}
+	if model.Uid != nil && *model.Uid != 0 {
+		modelLine += fmt.Sprintf(" uid=%d", *model.Uid)
+	}
+	if separate {
+		w.blank()
+	}
+	w.line("%s", modelLine)
+
+	for _, channel := range model.Channels {
+		if channel.Alias != "" {
+			w.line("channel %s %s", channel.Name, channel.Alias)
+		} else {
+			w.line("channel %s", channel.Name)
+		}
+	}
+	if model.Env != nil {
+		for _, e := range *model.Env {
+			w.line("%s", formatEnvar(e))
+		}
+	}
+	modelVars := []ast.Var{}
+	if model.Vars != nil {
+		modelVars = *model.Vars
+		for _, v := range diffVars(globalVars, modelVars, w, fmt.Sprintf("model '%s' vars", model.Name)) {
+			w.line("%s", formatVar(w, simSpec, v))
+		}
+	}
+	if model.Files != nil {
+		for _, f := range *model.Files {
+			fileLine := fmt.Sprintf("file %s", f.Name)
+			if f.Reference != nil && *f.Reference != "" {
+				fileLine += fmt.Sprintf(" %s", *f.Reference)
+			}
+			fileLine += fmt.Sprintf(" %s", f.Value)
+			if f.Path != nil && *f.Path != "" {
+				fileLine += fmt.Sprintf(" path=%s", *f.Path)
+			}
+			w.line("%s", fileLine)
+		}
+	}
+	writeAnnotations(w, model.Annotations, fmt.Sprintf("model '%s'", model.Name))
+	if model.Workflows != nil {
+		// Workflow vars inherit the (merged) model vars.
+		for _, workflow := range *model.Workflows {
+			writeWorkflow(w, simSpec, workflow, modelVars)
+		}
+	}
+}
+
+func writeWorkflow(w *scriptWriter, simSpec ast.SimulationSpec, workflow ast.Workflow, inherited []ast.Var) {
+	if workflow.Uses != nil && *workflow.Uses != "" {
+		w.line("workflow %s uses %s", workflow.Name, *workflow.Uses)
+	} else {
+		w.line("workflow %s", workflow.Name)
+	}
+	if workflow.Vars == nil {
+		return
+	}
+	for _, v := range diffVars(inherited, *workflow.Vars, w, fmt.Sprintf("workflow '%s' vars", workflow.Name)) {
+		w.line("  %s", formatVar(w, simSpec, v))
+	}
+}
+
+// writeAnnotations writes the annotations, an annotation (key and value) is a
+// single word of DSE script. Annotations which contain whitespace (or are
+// empty) cannot be represented.
+func writeAnnotations(w *scriptWriter, annotations *ast.Annotations, context string) {
+	keys := scriptAnnotations(annotations)
+	slices.Sort(keys)
+	for _, k := range keys {
+		value := vars.FormatValue((*annotations)[k])
+		if !isScriptWord(k) || !isScriptWord(value) {
+			w.warn("%s: annotation '%s' (value %s) contains whitespace, cannot be represented", context, k, value)
+			continue
+		}
+		w.line("annotation %s %s", k, value)
+	}
+}
+
+func isScriptWord(s string) bool {
+	return s != "" && !strings.ContainsFunc(s, unicode.IsSpace)
+}
+
+// scriptAnnotations returns the keys of the annotations which are written to
+// the script (source locations are recorded by convert, and not written).
+func scriptAnnotations(annotations *ast.Annotations) []string {
+	keys := []string{}
+	if annotations == nil {
+		return keys
+	}
+	for k := range *annotations {
+		if !source.IsAnnotation(k) {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// diffVars returns the vars which are not inherited (the DSL parser merges
+// inherited vars by name, in order, ahead of the declared vars).
+func diffVars(inherited []ast.Var, vars []ast.Var, w *scriptWriter, context string) []ast.Var {
+	declared := []ast.Var{}
+	for _, v := range vars {
+		idx := slices.IndexFunc(inherited, func(i ast.Var) bool { return i.Name == v.Name })
+		if idx >= 0 && equalVar(inherited[idx], v) {
+			continue
+		}
+		declared = append(declared, v)
+	}
+	for _, i := range inherited {
+		if !slices.ContainsFunc(vars, func(v ast.Var) bool { return v.Name == i.Name }) {
+			w.warn("%s: inherited var '%s' not present, cannot be represented", context, i.Name)
+		}
+	}
+	return declared
+}
+
+func equalVar(a ast.Var, b ast.Var) bool {
+	ptrEqual := func(a *string, b *string) bool {
+		if a == nil || b == nil {
+			return a == b
+		}
+		return *a == *b
+	}
+	return a.Name == b.Name && a.Value == b.Value &&
+		ptrEqual((*string)(a.Reference), (*string)(b.Reference)) &&
+		ptrEqual((*string)(a.Networktype), (*string)(b.Networktype))
+}
+
+func formatUses(uses ast.Uses) string {
+	s := fmt.Sprintf("%s %s", uses.Name, uses.Url)
+	if uses.Version != nil && *uses.Version != "" {
+		if strings.Contains(*uses.Version, " ") {
+			// Version range, e.g. ">=1.1 <2".
+			s += fmt.Sprintf(` "%s"`, *uses.Version)
+		} else {
+			s += fmt.Sprintf(" %s", *uses.Version)
+		}
+	}
+	if uses.Path != nil && *uses.Path != "" {
+		s += fmt.Sprintf(" path=%s", *uses.Path)
+	}
+	if uses.User != nil && *uses.User != "" {
+		s += fmt.Sprintf(" user=%s", *uses.User)
+	}
+	if uses.Token != nil && *uses.Token != "" {
+		s += fmt.Sprintf(" token=%s", *uses.Token)
+	}
+	if sha := artifact.UsesSha256(uses); sha != "" {
+		s += fmt.Sprintf(" sha256=%s", sha)
+	}
+	return s
+}
+
+func formatEnvar(v ast.Var) string {
+	return fmt.Sprintf("envar %s %s", v.Name, quoteValue(v.Value))
+}
+
+func formatVar(w *scriptWriter, simSpec ast.SimulationSpec, v ast.Var) string {
+	if v.Reference != nil && *v.Reference != "" {
+		value := v.Value
+		if *v.Reference == "network" {
+			value = networkForValue(simSpec, v)
+			if value == "" {
+				w.warn("var '%s': network not found for value '%s'", v.Name, v.Value)
+				value = v.Value
+			}
+		}
+		s := fmt.Sprintf("var %s %s %s", v.Name, *v.Reference, value)
+		if v.Networktype != nil && *v.Networktype != "" {
+			s += fmt.Sprintf(" %s", *v.Networktype)
+		}
+		return s
+	}
+	return fmt.Sprintf("var %s %s", v.Name, quoteValue(v.Value))
+}
+
+// networkForValue locates the network which a (resolved) network var
+// references. Mimetype values are matched against the network MIMEtype
+// where template references may have been substituted.
+func networkForValue(simSpec ast.SimulationSpec, v ast.Var) string {
+	networks := []ast.SimulationNetwork{}
+	for _, channel := range simSpec.Channels {
+		if channel.Networks != nil {
+			networks = append(networks, *channel.Networks...)
+		}
+	}
+	for _, network := range networks {
+		if network.Name == v.Value {
+			return network.Name
+		}
+	}
+	if v.Networktype == nil || *v.Networktype != "mimetype" {
+		return ""
+	}
+	for _, network := range networks {
+		parts := templateVarRegex.Split(network.MimeType, -1)
+		for i := range parts {
+			parts[i] = regexp.QuoteMeta(parts[i])
+		}
+		re, err := regexp.Compile("^" + strings.Join(parts, ".*?") + "$")
+		if err == nil && re.MatchString(v.Value) {
+			return network.Name
+		}
+	}
+	return ""
+}
+
+var templateVarRegex = regexp.MustCompile(`\{\{\.?(\w+)\}\}`)
+
+func quoteValue(v string) string {
+	if v == "" {
+		return "''"
+	}
+	if strings.ContainsAny(v, " \t") {
+		if (strings.HasPrefix(v, "'") && strings.HasSuffix(v, "'")) ||
+			(strings.HasPrefix(v, `"`) && strings.HasSuffix(v, `"`)) {
+			return v
+		}
+		return fmt.Sprintf("'%s'", v)
+	}
+	return v
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
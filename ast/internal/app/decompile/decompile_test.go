@@ -0,0 +1,185 @@
+// Copyright 2025 Robert Bosch GmbH
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package decompile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/boschglobal/dse.clib/extra/go/command/util"
+	"github.com/boschglobal/dse.schemas/code/go/dse/ast"
+
+	"github.com/boschglobal/dse.sdp/ast/internal/app/convert"
+)
+
+func decompileDslAst(t *testing.T, dslAstFile string) string {
+	data, err := os.ReadFile(dslAstFile)
+	require.NoError(t, err)
+	t.Chdir(t.TempDir())
+	require.NoError(t, os.MkdirAll("out", 0755))
+	require.NoError(t, os.WriteFile(filepath.Join("out", "sim.json"), data, 0644))
+
+	convertCmd := convert.NewConvertCommand("test_convert")
+	require.NoError(t, convertCmd.Parse([]string{"-input", "sim.json", "-output", "ast.yaml"}))
+	require.NoError(t, convertCmd.Run())
+
+	cmd := NewDecompileCommand("test_decompile")
+	require.NoError(t, cmd.Parse([]string{"-input", "ast.yaml", "-output", "sim.dse"}))
+	require.NoError(t, cmd.Run())
+
+	script, err := os.ReadFile(filepath.Join("out", "sim.dse"))
+	require.NoError(t, err)
+	t.Logf("\n%s\n", script)
+	return string(script)
+}
+
+func TestDecompile_stacks(t *testing.T) {
+	dslAstFile, _ := filepath.Abs("../../../cmd/ast/testdata/dsl/stacks.ast.json")
+	script := decompileDslAst(t, dslAstFile)
+
+	assert.Equal(t, `simulation arch=linux-x86 stepsize=0 endtime=0
+channel physical
+
+uses
+dse.fmi https://github.com/boschglobal/dse.fmi v1.1.8
+
+model one dse.fmi.mcl arch=linux-amd64
+channel physical scalar
+
+stack stack-stacked stacked=true arch=win-x86
+model two dse.fmi.mcl
+channel physical scalar
+
+stack model-arch
+model three dse.fmi.mcl arch=linux-i386
+channel physical scalar
+`, script)
+}
+
+func TestDecompile_vars(t *testing.T) {
+	dslAstFile, _ := filepath.Abs("../../../cmd/ast/testdata/dsl/vars.ast.json")
+	script := decompileDslAst(t, dslAstFile)
+
+	assert.Contains(t, script, "\nvar key_one val_one\nvar key_two val_two\n")
+	assert.Contains(t, script, "\nworkflow generate-fmimcl\n  var key_three uses val_three\n  var key_four val_four\n")
+}
+
+func TestDecompile_inherited(t *testing.T) {
+	network := ast.VarReference("network")
+	mimetype := ast.VarNetworktype("mimetype")
+	external := true
+	uid := 7
+	simSpec := ast.SimulationSpec{
+		Arch: "linux-amd64",
+		Channels: []ast.SimulationChannel{
+			{
+				Name: "network",
+				Networks: &[]ast.SimulationNetwork{
+					{Name: "CAN", MimeType: "application/x-automotive-bus;bus=can;bus_id={{.BUS_ID}}"},
+				},
+			},
+		},
+		Vars: &[]ast.Var{{Name: "BUS_ID", Value: "1"}},
+		Stacks: []ast.Stack{
+			{
+				Name: "default",
+				Env:  &[]ast.Var{{Name: "LOGLEVEL", Value: "4"}},
+				Models: []ast.Model{
+					{
+						Name:  "net",
+						Model: "dse.network",
+						Arch:  util.StringPtr("linux-amd64"),
+						Uid:   &uid,
+						Vars: &[]ast.Var{
+							{Name: "BUS_ID", Value: "1"},
+							{Name: "MIME", Value: "application/x-automotive-bus;bus=can;bus_id=1", Reference: &network, Networktype: &mimetype},
+						},
+						Workflows: &[]ast.Workflow{
+							{
+								Name: "generate-network",
+								Vars: &[]ast.Var{
+									{Name: "BUS_ID", Value: "1"},
+									{Name: "MIME", Value: "application/x-automotive-bus;bus=can;bus_id=1", Reference: &network, Networktype: &mimetype},
+									{Name: "OUT_DIR", Value: "some path"},
+								},
+							},
+						},
+					},
+				},
+			},
+			{
+				Name: "external",
+				Models: []ast.Model{
+					{Name: "gateway", External: &external},
+				},
+			},
+		},
+	}
+
+	script, warnings := Decompile(simSpec)
+	t.Logf("\n%s\n", script)
+	assert.Empty(t, warnings)
+	assert.Equal(t, `simulation arch=linux-amd64 stepsize=0.0005 endtime=0.005
+channel network
+network CAN 'application/x-automotive-bus;bus=can;bus_id={{.BUS_ID}}'
+
+var BUS_ID 1
+envar LOGLEVEL 4
+
+model net dse.network uid=7
+var MIME network CAN mimetype
+workflow generate-network
+  var OUT_DIR 'some path'
+
+model gateway external=true
+`, script)
+}
+
+func TestDecompile_usesVersionRange(t *testing.T) {
+	uses := ast.Uses{Name: "dse.fmi", Url: "https://github.com/boschglobal/dse.fmi", Version: util.StringPtr(">=1.1 <2")}
+	assert.Equal(t, `dse.fmi https://github.com/boschglobal/dse.fmi ">=1.1 <2"`, formatUses(uses))
+	uses.Version = util.StringPtr("~1.1")
+	assert.Equal(t, `dse.fmi https://github.com/boschglobal/dse.fmi ~1.1`, formatUses(uses))
+}
+
+func TestDecompile_usesSha256(t *testing.T) {
+	pin := "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+	uses := ast.Uses{Name: "input", Url: "https://example.com/input.csv", Metadata: &map[string]interface{}{"sha256": pin}}
+	assert.Equal(t, "input https://example.com/input.csv sha256="+pin, formatUses(uses))
+}
+
+func TestDecompile_annotations(t *testing.T) {
+	simSpec := ast.SimulationSpec{
+		Arch: "linux-amd64",
+		Stacks: []ast.Stack{
+			{
+				Name: "default",
+				Models: []ast.Model{
+					{
+						Name:  "input",
+						Model: "dse.modelc.csv",
+						Annotations: &ast.Annotations{
+							"cpu":         "0",
+							"description": "csv input",
+							"sdp/source":  "sim.dse:5:1",
+							"trace":       true,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	script, warnings := Decompile(simSpec)
+	assert.Contains(t, script, "annotation cpu \"0\"\nannotation trace true\n")
+	assert.NotContains(t, script, "description")
+	assert.Equal(t, []string{
+		"model 'input': annotation 'description' (value csv input) contains whitespace, cannot be represented",
+	}, warnings)
+}
@@ -0,0 +1,125 @@
+// Copyright 2025 Robert Bosch GmbH
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package diagram
+
+import (
+	"encoding/json"
+
+	"github.com/boschglobal/dse.schemas/code/go/dse/ast"
+)
+
+// DagNode and DagLink represent the input.json of the LSP ast_dag viewer.
+type DagNode struct {
+	Id          int    `json:"id"`
+	Name        string `json:"name"`
+	Alias       string `json:"alias,omitempty"`
+	ChannelName string `json:"channel_name,omitempty"`
+	MimeType    string `json:"mime_type,omitempty"`
+	Type        string `json:"type"`
+}
+
+type DagLink struct {
+	Source int    `json:"source"`
+	Target int    `json:"target"`
+	Type   string `json:"type"`
+}
+
+type Dag struct {
+	Nodes []DagNode `json:"nodes"`
+	Links []DagLink `json:"links"`
+}
+
+// Nodes targeted by this many links (or more) are drawn as a bus.
+const dagBusLinks = 5
+
+// BuildDag builds the ast_dag graph of a simulation, with the same layout as
+// the LSP client: models (rect) numbered in stack order, followed by the
+// channels referenced by models (vertical_rounded_rect, with the alias of the
+// first reference) and the networks (horizontal_rect). Models are linked to
+// their channels (link_to_channel) and to the first network of each of their
+// channels (link_to_can).
+func BuildDag(spec ast.SimulationSpec) Dag {
+	dag := Dag{Nodes: []DagNode{}, Links: []DagLink{}}
+	find := func(match func(DagNode) bool) *DagNode {
+		for i := range dag.Nodes {
+			if match(dag.Nodes[i]) {
+				return &dag.Nodes[i]
+			}
+		}
+		return nil
+	}
+	byName := func(name, nodeType string) func(DagNode) bool {
+		return func(n DagNode) bool { return n.Name == name && n.Type == nodeType }
+	}
+
+	var models []ast.Model
+	for _, stack := range spec.Stacks {
+		models = append(models, stack.Models...)
+	}
+	for i, model := range models {
+		dag.Nodes = append(dag.Nodes, DagNode{Id: i + 1, Name: model.Name, Type: "rect"})
+	}
+	nextId := len(models) + 1
+	for _, model := range models {
+		for _, channel := range model.Channels {
+			if find(byName(channel.Name, "vertical_rounded_rect")) != nil {
+				continue
+			}
+			dag.Nodes = append(dag.Nodes, DagNode{Id: nextId, Name: channel.Name, Alias: channel.Alias, Type: "vertical_rounded_rect"})
+			nextId++
+		}
+	}
+	for _, channel := range spec.Channels {
+		if channel.Networks == nil {
+			continue
+		}
+		for _, network := range *channel.Networks {
+			if find(byName(network.Name, "horizontal_rect")) != nil {
+				continue
+			}
+			dag.Nodes = append(dag.Nodes, DagNode{Id: nextId, Name: network.Name, ChannelName: channel.Name, MimeType: network.MimeType, Type: "horizontal_rect"})
+			nextId++
+		}
+	}
+
+	link := func(l DagLink) {
+		for _, existing := range dag.Links {
+			if existing.Source == l.Source && existing.Target == l.Target {
+				return
+			}
+		}
+		dag.Links = append(dag.Links, l)
+	}
+	for i, model := range models {
+		for _, channel := range model.Channels {
+			if n := find(byName(channel.Name, "vertical_rounded_rect")); n != nil {
+				dag.Links = append(dag.Links, DagLink{Source: i + 1, Target: n.Id, Type: "link_to_channel"})
+			}
+			if n := find(func(n DagNode) bool { return n.ChannelName == channel.Name }); n != nil {
+				link(DagLink{Source: i + 1, Target: n.Id, Type: "link_to_can"})
+			}
+		}
+	}
+
+	targets := map[int]int{}
+	for _, l := range dag.Links {
+		targets[l.Target]++
+	}
+	for i := range dag.Nodes {
+		if targets[dag.Nodes[i].Id] >= dagBusLinks {
+			dag.Nodes[i].Type = "horizontal_rounded_rect"
+		}
+	}
+	return dag
+}
+
+// DagJson renders the ast_dag graph (input.json) of a simulation.
+func DagJson(spec ast.SimulationSpec) (string, error) {
+	data, err := json.MarshalIndent(BuildDag(spec), "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data) + "\n", nil
+}
@@ -0,0 +1,112 @@
+// Copyright 2025 Robert Bosch GmbH
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package diagram
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/boschglobal/dse.clib/extra/go/command"
+	"github.com/boschglobal/dse.clib/extra/go/command/log"
+
+	"github.com/boschglobal/dse.schemas/code/go/dse/ast"
+
+	"github.com/boschglobal/dse.sdp/ast/internal/app/generate"
+)
+
+const (
+	FormatDot     = "dot"
+	FormatMermaid = "mermaid"
+	FormatDagJson = "dag-json"
+)
+
+var formats = []string{FormatDot, FormatMermaid, FormatDagJson}
+
+type DiagramCommand struct {
+	command.Command
+
+	inputFile  string
+	outputFile string
+	format     string
+	logLevel   int
+}
+
+func NewDiagramCommand(name string) *DiagramCommand {
+	c := &DiagramCommand{
+		Command: command.Command{
+			Name:    name,
+			FlagSet: flag.NewFlagSet(name, flag.ExitOnError),
+		},
+	}
+	c.FlagSet().StringVar(&c.inputFile, "input", "", "path to Simulation AST file")
+	c.FlagSet().StringVar(&c.outputFile, "output", "", "path to write the diagram (default stdout)")
+	c.FlagSet().StringVar(&c.format, "format", FormatDot, "diagram format (dot, mermaid or dag-json)")
+	c.FlagSet().IntVar(&c.logLevel, "log", 4, "Loglevel")
+	return c
+}
+
+func (c DiagramCommand) Name() string {
+	return c.Command.Name
+}
+
+func (c DiagramCommand) FlagSet() *flag.FlagSet {
+	return c.Command.FlagSet
+}
+
+func (c *DiagramCommand) Parse(args []string) error {
+	if err := c.FlagSet().Parse(args); err != nil {
+		return err
+	}
+	if !slices.Contains(formats, c.format) {
+		return fmt.Errorf("Unsupported diagram format: %s", c.format)
+	}
+	return nil
+}
+
+func (c *DiagramCommand) Run() error {
+	slog.SetDefault(log.NewLogger(c.logLevel))
+
+	inputPath := filepath.Join("out", c.inputFile)
+	c.inputFile = inputPath
+
+	spec, _, err := generate.LoadSimulationAst(c.inputFile)
+	if err != nil {
+		return err
+	}
+	diagram, err := Diagram(*spec, c.format)
+	if err != nil {
+		return err
+	}
+
+	if c.outputFile == "" {
+		// Diagram to stdout (messages are written to stderr).
+		_, err := fmt.Fprint(os.Stdout, diagram)
+		return err
+	}
+	c.outputFile = filepath.Join("out", c.outputFile)
+	fmt.Fprintf(flag.CommandLine.Output(), "Writing file: %s\n", c.outputFile)
+	if err := os.MkdirAll(filepath.Dir(c.outputFile), os.ModePerm); err != nil {
+		return err
+	}
+	return os.WriteFile(c.outputFile, []byte(diagram), 0644)
+}
+
+// Diagram renders the topology of a simulation in the given format.
+func Diagram(spec ast.SimulationSpec, format string) (string, error) {
+	switch format {
+	case FormatDot:
+		return Dot(BuildGraph(spec)), nil
+	case FormatMermaid:
+		return Mermaid(BuildGraph(spec)), nil
+	case FormatDagJson:
+		return DagJson(spec)
+	default:
+		return "", fmt.Errorf("Unsupported diagram format: %s", format)
+	}
+}
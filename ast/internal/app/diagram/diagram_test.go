@@ -0,0 +1,132 @@
+// Copyright 2025 Robert Bosch GmbH
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package diagram
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/boschglobal/dse.clib/extra/go/command/util"
+	"github.com/boschglobal/dse.schemas/code/go/dse/ast"
+)
+
+func testSpec() ast.SimulationSpec {
+	return ast.SimulationSpec{
+		Channels: []ast.SimulationChannel{
+			{Name: "physical"},
+			{
+				Name: "network",
+				Networks: &[]ast.SimulationNetwork{
+					{Name: "CAN", MimeType: "application/x-automotive-bus;interface=stream;type=frame;bus=can"},
+				},
+			},
+		},
+		Uses: &[]ast.Uses{
+			{Name: "dse.modelc", Url: "https://github.com/boschglobal/dse.modelc", Version: util.StringPtr("v2.1.17")},
+			{Name: "dse.fmi", Url: "https://github.com/boschglobal/dse.fmi", Version: util.StringPtr("v1.1.20")},
+		},
+		Stacks: []ast.Stack{
+			{
+				Name: "default",
+				Models: []ast.Model{
+					{
+						Name:  "input",
+						Model: "dse.modelc.csv",
+						Uses:  "dse.modelc",
+						Channels: []ast.ModelChannel{
+							{Name: "physical", Alias: "scalar_vector"},
+						},
+					},
+				},
+			},
+			{
+				Name: "fmu",
+				Models: []ast.Model{
+					{
+						Name:  "linear",
+						Model: "dse.fmi.mcl",
+						Uses:  "dse.fmi",
+						Channels: []ast.ModelChannel{
+							{Name: "physical", Alias: "signal_channel"},
+							{Name: "network", Alias: "network_channel"},
+						},
+						Workflows: &[]ast.Workflow{
+							{Name: "generate-fmimcl", Uses: util.StringPtr("dse.fmi")},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestDiagram_dot(t *testing.T) {
+	dot, err := Diagram(testSpec(), FormatDot)
+	require.NoError(t, err)
+	assert.Contains(t, dot, "digraph simulation {\n")
+	assert.Contains(t, dot, "  subgraph cluster_0 {\n    label=\"stack: default\";\n    m1 [label=\"input\\ndse.modelc.csv\", shape=box];\n  }\n")
+	assert.Contains(t, dot, "  subgraph cluster_1 {\n    label=\"stack: fmu\";\n    m2 [label=\"linear\\ndse.fmi.mcl\", shape=box];\n  }\n")
+	assert.Contains(t, dot, "  n1 [label=\"CAN\\napplication/x-automotive-bus;interface=stream;type=frame;bus=can\", shape=hexagon];\n")
+	assert.Contains(t, dot, "  c2 -> n1 [arrowhead=none];\n")
+	assert.Contains(t, dot, "  m1 -> c1 [label=\"scalar_vector\"];\n")
+	assert.Contains(t, dot, "  m2 -> c2 [label=\"network_channel\"];\n")
+	assert.Contains(t, dot, "  m1 -> u1 [style=dashed];\n")
+	assert.Contains(t, dot, "  m2 -> u2 [label=\"generate-fmimcl\", style=dashed];\n")
+}
+
+func TestDiagram_mermaid(t *testing.T) {
+	mermaid, err := Diagram(testSpec(), FormatMermaid)
+	require.NoError(t, err)
+	assert.Contains(t, mermaid, "flowchart LR\n")
+	assert.Contains(t, mermaid, "  subgraph s0[\"stack: default\"]\n    m1[\"input<br/>dse.modelc.csv\"]\n  end\n")
+	assert.Contains(t, mermaid, "  c1([\"physical\"])\n")
+	assert.Contains(t, mermaid, "  u2[/\"dse.fmi<br/>v1.1.20\"/]\n")
+	assert.Contains(t, mermaid, "  c2 --- n1\n")
+	assert.Contains(t, mermaid, "  m2 -- \"signal_channel\" --> c1\n")
+	assert.Contains(t, mermaid, "  m1 -.-> u1\n")
+	assert.Contains(t, mermaid, "  m2 -. \"generate-fmimcl\" .-> u2\n")
+}
+
+func TestDiagram_dagJson(t *testing.T) {
+	data, err := Diagram(testSpec(), FormatDagJson)
+	require.NoError(t, err)
+	var dag Dag
+	require.NoError(t, json.Unmarshal([]byte(data), &dag))
+	assert.Equal(t, []DagNode{
+		{Id: 1, Name: "input", Type: "rect"},
+		{Id: 2, Name: "linear", Type: "rect"},
+		{Id: 3, Name: "physical", Alias: "scalar_vector", Type: "vertical_rounded_rect"},
+		{Id: 4, Name: "network", Alias: "network_channel", Type: "vertical_rounded_rect"},
+		{Id: 5, Name: "CAN", ChannelName: "network", MimeType: "application/x-automotive-bus;interface=stream;type=frame;bus=can", Type: "horizontal_rect"},
+	}, dag.Nodes)
+	assert.Equal(t, []DagLink{
+		{Source: 1, Target: 3, Type: "link_to_channel"},
+		{Source: 2, Target: 3, Type: "link_to_channel"},
+		{Source: 2, Target: 4, Type: "link_to_channel"},
+		{Source: 2, Target: 5, Type: "link_to_can"},
+	}, dag.Links)
+}
+
+func TestDiagram_dagJsonBus(t *testing.T) {
+	spec := ast.SimulationSpec{Channels: []ast.SimulationChannel{{Name: "physical"}}}
+	models := []ast.Model{}
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		models = append(models, ast.Model{Name: name, Channels: []ast.ModelChannel{{Name: "physical", Alias: "scalar_vector"}}})
+	}
+	spec.Stacks = []ast.Stack{{Name: "default", Models: models}}
+
+	dag := BuildDag(spec)
+	require.Len(t, dag.Nodes, 6)
+	assert.Equal(t, DagNode{Id: 6, Name: "physical", Alias: "scalar_vector", Type: "horizontal_rounded_rect"}, dag.Nodes[5])
+	assert.Len(t, dag.Links, 5)
+}
+
+func TestDiagram_format(t *testing.T) {
+	_, err := Diagram(testSpec(), "svg")
+	assert.ErrorContains(t, err, "Unsupported diagram format: svg")
+}
@@ -0,0 +1,289 @@
+// Copyright 2025 Robert Bosch GmbH
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package diagram
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/boschglobal/dse.schemas/code/go/dse/ast"
+)
+
+type NodeKind string
+
+const (
+	NodeModel   NodeKind = "model"
+	NodeChannel NodeKind = "channel"
+	NodeNetwork NodeKind = "network"
+	NodeUses    NodeKind = "uses"
+)
+
+// Node of the topology graph, model nodes are grouped by stack.
+type Node struct {
+	Id    string
+	Kind  NodeKind
+	Name  string
+	Info  string // Secondary label (model, mime type or version).
+	Stack string
+}
+
+// Edge of the topology graph, the kind of the edge is the kind of the target
+// node (channel edges are labelled with the channel alias).
+type Edge struct {
+	From  string
+	To    string
+	Kind  NodeKind
+	Label string
+}
+
+type Graph struct {
+	Stacks []string
+	Nodes  []Node
+	Edges  []Edge
+}
+
+func (g *Graph) add(kind NodeKind, name, info, stack string) string {
+	count := 1
+	for _, n := range g.Nodes {
+		if n.Kind == kind {
+			count++
+		}
+	}
+	id := fmt.Sprintf("%s%d", kind[:1], count)
+	g.Nodes = append(g.Nodes, Node{Id: id, Kind: kind, Name: name, Info: info, Stack: stack})
+	return id
+}
+
+func (g *Graph) find(kind NodeKind, name string) string {
+	for _, n := range g.Nodes {
+		if n.Kind == kind && n.Name == name {
+			return n.Id
+		}
+	}
+	return ""
+}
+
+func (g *Graph) link(from, to string, kind NodeKind, label string) {
+	e := Edge{From: from, To: to, Kind: kind, Label: label}
+	for _, edge := range g.Edges {
+		if edge == e {
+			return
+		}
+	}
+	g.Edges = append(g.Edges, e)
+}
+
+// BuildGraph builds the topology graph of a simulation: stacks (with their
+// model instances), channels, networks and uses. Channels referenced by a
+// model, but not declared by the simulation, are also represented.
+func BuildGraph(spec ast.SimulationSpec) Graph {
+	g := Graph{}
+
+	for _, channel := range spec.Channels {
+		channelId := g.add(NodeChannel, channel.Name, "", "")
+		if channel.Networks == nil {
+			continue
+		}
+		for _, network := range *channel.Networks {
+			networkId := g.add(NodeNetwork, network.Name, network.MimeType, "")
+			g.link(channelId, networkId, NodeNetwork, "")
+		}
+	}
+	if spec.Uses != nil {
+		for _, uses := range *spec.Uses {
+			version := ""
+			if uses.Version != nil {
+				version = *uses.Version
+			}
+			g.add(NodeUses, uses.Name, version, "")
+		}
+	}
+
+	usesId := func(name string) string {
+		if id := g.find(NodeUses, name); id != "" {
+			return id
+		}
+		return g.add(NodeUses, name, "", "")
+	}
+	for _, stack := range spec.Stacks {
+		g.Stacks = append(g.Stacks, stack.Name)
+		for _, model := range stack.Models {
+			modelId := g.add(NodeModel, model.Name, model.Model, stack.Name)
+			for _, channel := range model.Channels {
+				channelId := g.find(NodeChannel, channel.Name)
+				if channelId == "" {
+					channelId = g.add(NodeChannel, channel.Name, "", "")
+				}
+				g.link(modelId, channelId, NodeChannel, channel.Alias)
+			}
+			if model.Uses != "" {
+				g.link(modelId, usesId(model.Uses), NodeUses, "")
+			}
+			if model.Files != nil {
+				for _, file := range *model.Files {
+					if file.Reference != nil && *file.Reference == "uses" {
+						g.link(modelId, usesId(file.Value), NodeUses, file.Name)
+					}
+				}
+			}
+			if model.Workflows != nil {
+				for _, workflow := range *model.Workflows {
+					if workflow.Uses != nil && *workflow.Uses != "" {
+						g.link(modelId, usesId(*workflow.Uses), NodeUses, workflow.Name)
+					}
+					if workflow.Vars == nil {
+						continue
+					}
+					for _, v := range *workflow.Vars {
+						if v.Reference != nil && *v.Reference == "uses" {
+							g.link(modelId, usesId(v.Value), NodeUses, v.Name)
+						}
+					}
+				}
+			}
+		}
+	}
+	return g
+}
+
+func (g Graph) stackNodes(stack string) []Node {
+	var nodes []Node
+	for _, n := range g.Nodes {
+		if n.Kind == NodeModel && n.Stack == stack {
+			nodes = append(nodes, n)
+		}
+	}
+	return nodes
+}
+
+func (g Graph) otherNodes() []Node {
+	var nodes []Node
+	for _, kind := range []NodeKind{NodeChannel, NodeNetwork, NodeUses} {
+		for _, n := range g.Nodes {
+			if n.Kind == kind {
+				nodes = append(nodes, n)
+			}
+		}
+	}
+	return nodes
+}
+
+func (n Node) lines() []string {
+	if n.Info == "" {
+		return []string{n.Name}
+	}
+	return []string{n.Name, n.Info}
+}
+
+var dotShapes = map[NodeKind]string{
+	NodeModel:   "box",
+	NodeChannel: "ellipse",
+	NodeNetwork: "hexagon",
+	NodeUses:    "component",
+}
+
+var dotEscape = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+func dotQuote(s string) string {
+	return `"` + dotEscape.Replace(s) + `"`
+}
+
+func dotLabel(lines []string) string {
+	escaped := make([]string, len(lines))
+	for i, l := range lines {
+		escaped[i] = dotEscape.Replace(l)
+	}
+	return `"` + strings.Join(escaped, `\n`) + `"`
+}
+
+// Dot renders the graph in the Graphviz DOT language, each stack is a cluster.
+func Dot(g Graph) string {
+	var b strings.Builder
+	b.WriteString("digraph simulation {\n")
+	b.WriteString("  rankdir=LR;\n")
+	for i, stack := range g.Stacks {
+		fmt.Fprintf(&b, "  subgraph cluster_%d {\n", i)
+		fmt.Fprintf(&b, "    label=%s;\n", dotQuote("stack: "+stack))
+		for _, n := range g.stackNodes(stack) {
+			fmt.Fprintf(&b, "    %s [label=%s, shape=%s];\n", n.Id, dotLabel(n.lines()), dotShapes[n.Kind])
+		}
+		b.WriteString("  }\n")
+	}
+	for _, n := range g.otherNodes() {
+		fmt.Fprintf(&b, "  %s [label=%s, shape=%s];\n", n.Id, dotLabel(n.lines()), dotShapes[n.Kind])
+	}
+	for _, e := range g.Edges {
+		var attrs []string
+		if e.Label != "" {
+			attrs = append(attrs, "label="+dotQuote(e.Label))
+		}
+		switch e.Kind {
+		case NodeNetwork:
+			attrs = append(attrs, "arrowhead=none")
+		case NodeUses:
+			attrs = append(attrs, "style=dashed")
+		}
+		fmt.Fprintf(&b, "  %s -> %s", e.From, e.To)
+		if len(attrs) > 0 {
+			fmt.Fprintf(&b, " [%s]", strings.Join(attrs, ", "))
+		}
+		b.WriteString(";\n")
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+var mermaidShapes = map[NodeKind][2]string{
+	NodeModel:   {"[", "]"},
+	NodeChannel: {"([", "])"},
+	NodeNetwork: {"{{", "}}"},
+	NodeUses:    {"[/", "/]"},
+}
+
+var mermaidEscape = strings.NewReplacer(`"`, "#quot;")
+
+func mermaidQuote(s string) string {
+	return `"` + mermaidEscape.Replace(s) + `"`
+}
+
+func mermaidNode(n Node) string {
+	shape := mermaidShapes[n.Kind]
+	lines := n.lines()
+	for i, l := range lines {
+		lines[i] = mermaidEscape.Replace(l)
+	}
+	return fmt.Sprintf(`%s%s"%s"%s`, n.Id, shape[0], strings.Join(lines, "<br/>"), shape[1])
+}
+
+// Mermaid renders the graph as a Mermaid flowchart, each stack is a subgraph.
+func Mermaid(g Graph) string {
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	for i, stack := range g.Stacks {
+		fmt.Fprintf(&b, "  subgraph s%d[%s]\n", i, mermaidQuote("stack: "+stack))
+		for _, n := range g.stackNodes(stack) {
+			fmt.Fprintf(&b, "    %s\n", mermaidNode(n))
+		}
+		b.WriteString("  end\n")
+	}
+	for _, n := range g.otherNodes() {
+		fmt.Fprintf(&b, "  %s\n", mermaidNode(n))
+	}
+	for _, e := range g.Edges {
+		switch {
+		case e.Kind == NodeNetwork:
+			fmt.Fprintf(&b, "  %s --- %s\n", e.From, e.To)
+		case e.Kind == NodeUses && e.Label != "":
+			fmt.Fprintf(&b, "  %s -. %s .-> %s\n", e.From, mermaidQuote(e.Label), e.To)
+		case e.Kind == NodeUses:
+			fmt.Fprintf(&b, "  %s -.-> %s\n", e.From, e.To)
+		case e.Label != "":
+			fmt.Fprintf(&b, "  %s -- %s --> %s\n", e.From, mermaidQuote(e.Label), e.To)
+		default:
+			fmt.Fprintf(&b, "  %s --> %s\n", e.From, e.To)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,426 @@
+// Copyright 2025 Robert Bosch GmbH
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package explain
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/boschglobal/dse.clib/extra/go/command"
+	"github.com/boschglobal/dse.clib/extra/go/command/log"
+
+	"github.com/boschglobal/dse.schemas/code/go/dse/ast"
+	"github.com/boschglobal/dse.schemas/code/go/dse/kind"
+
+	"github.com/boschglobal/dse.sdp/ast/internal/app/generate"
+	"github.com/boschglobal/dse.sdp/ast/internal/pkg/source"
+)
+
+type ExplainCommand struct {
+	command.Command
+
+	inputFile string
+	modelName string
+	logLevel  int
+}
+
+func NewExplainCommand(name string) *ExplainCommand {
+	c := &ExplainCommand{
+		Command: command.Command{
+			Name:    name,
+			FlagSet: flag.NewFlagSet(name, flag.ExitOnError),
+		},
+	}
+	c.FlagSet().StringVar(&c.inputFile, "input", "", "path to Simulation AST file")
+	c.FlagSet().StringVar(&c.modelName, "model", "", "name of the model instance")
+	c.FlagSet().IntVar(&c.logLevel, "log", 4, "Loglevel")
+	return c
+}
+
+func (c ExplainCommand) Name() string {
+	return c.Command.Name
+}
+
+func (c ExplainCommand) FlagSet() *flag.FlagSet {
+	return c.Command.FlagSet
+}
+
+func (c *ExplainCommand) Parse(args []string) error {
+	return c.FlagSet().Parse(args)
+}
+
+func (c *ExplainCommand) Run() error {
+	slog.SetDefault(log.NewLogger(c.logLevel))
+
+	inputPath := filepath.Join("out", c.inputFile)
+	c.inputFile = inputPath
+
+	spec, _, err := generate.LoadSimulationAst(c.inputFile)
+	if err != nil {
+		return err
+	}
+	e, err := Explain(*spec, c.modelName)
+	if err != nil {
+		return err
+	}
+	return e.Write(flag.CommandLine.Output())
+}
+
+// Entry is an effective env var, var or workflow var of a model.
+type Entry struct {
+	Name      string
+	Value     string
+	Origin    string   // global, stack, model, workflow, network or generate.
+	Detail    string   // Source of the value (e.g. the referenced uses).
+	Overrides []string // Values of other scopes, scope=value.
+}
+
+type File struct {
+	Name   string
+	Source string
+	Target string // Relative to the simulation folder.
+}
+
+type Workflow struct {
+	Name string
+	Uses string
+	Vars []Entry
+}
+
+// Explanation of the effective configuration of a model instance.
+type Explanation struct {
+	Model     ast.Model
+	Stack     string
+	Handler   string
+	Env       []Entry
+	Vars      []Entry
+	Workflows []Workflow
+	Files     []File
+	Instance  *kind.ModelInstance
+}
+
+// Same pattern as the network template substitution of convert.
+var templateVarRegex = regexp.MustCompile(`\{\{\.?(\w+)\}\}`)
+
+type scope struct {
+	name string
+	vars []ast.Var
+}
+
+func (s scope) lookup(name string) (string, bool) {
+	for _, v := range s.vars {
+		if v.Name == name {
+			return v.Value, true
+		}
+	}
+	return "", false
+}
+
+func varList(vars *[]ast.Var) []ast.Var {
+	if vars == nil {
+		return nil
+	}
+	return *vars
+}
+
+// Explain returns the effective configuration of a model instance: env
+// (stack and model), model Task vars, workflow vars (global vars are used by
+// the network template substitution), files and the generated runtime.
+// Replicated models are explained by instance (e.g. node_1).
+func Explain(spec ast.SimulationSpec, modelName string) (*Explanation, error) {
+	spec, err := generate.ExpandReplicas(spec)
+	if err != nil {
+		return nil, err
+	}
+	e := Explanation{}
+	found := false
+	for _, stack := range spec.Stacks {
+		for _, model := range stack.Models {
+			if model.Name == modelName {
+				e.Model, e.Stack, found = model, stack.Name, true
+				e.Env = explainEnv(varList(stack.Env), varList(model.Env))
+			}
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("model not found in simulation AST (name=%s)", modelName)
+	}
+	model := e.Model
+	global := scope{name: "global", vars: varList(spec.Vars)}
+
+	task, err := generate.ModelTask(model, spec)
+	if err != nil {
+		return nil, err
+	}
+	e.Vars = explainVars(spec, task, scope{name: "model", vars: varList(model.Vars)}, global)
+	if model.Workflows != nil {
+		for _, w := range *model.Workflows {
+			workflow := Workflow{Name: w.Name}
+			if w.Uses != nil {
+				workflow.Uses = *w.Uses
+			}
+			workflow.Vars = explainWorkflowVars(spec, task, w, scope{name: "model", vars: varList(model.Vars)}, global)
+			e.Workflows = append(e.Workflows, workflow)
+		}
+	}
+	if model.Files != nil {
+		for _, f := range *model.Files {
+			e.Files = append(e.Files, File{
+				Name:   f.Name,
+				Source: fileSource(spec, f),
+				Target: generate.ModelFilePath(model, f.Name),
+			})
+		}
+	}
+	e.Instance, e.Handler = generate.ModelInstance(model, spec)
+	return &e, nil
+}
+
+// explainEnv merges the stack and model env, the model env overrides the
+// stack env (later entries of a scope override earlier entries).
+func explainEnv(stackEnv []ast.Var, modelEnv []ast.Var) []Entry {
+	entries := []Entry{}
+	set := func(v ast.Var, origin string) {
+		i := slices.IndexFunc(entries, func(e Entry) bool { return e.Name == v.Name })
+		if i < 0 {
+			entries = append(entries, Entry{Name: v.Name, Value: v.Value, Origin: origin})
+			return
+		}
+		entries[i].Overrides = append(entries[i].Overrides, fmt.Sprintf("%s=%s", entries[i].Origin, entries[i].Value))
+		entries[i].Value, entries[i].Origin = v.Value, origin
+	}
+	for _, v := range stackEnv {
+		set(v, "stack")
+	}
+	for _, v := range modelEnv {
+		set(v, "model")
+	}
+	return entries
+}
+
+// explainVars explains the vars of the model Task. Model vars are set first
+// (the first of duplicate vars is used), generate then sets the vars of the
+// model type (e.g. REPO, TAG and PATH). Global vars which are not shadowed are
+// listed last.
+func explainVars(spec ast.SimulationSpec, task generate.Task, model scope, global scope) []Entry {
+	entries := []Entry{}
+	if task.Vars != nil {
+		for _, name := range task.Vars.Keys() {
+			value, _ := task.Vars.Get(name)
+			entry := Entry{Name: name, Value: value, Origin: "generate"}
+			i := slices.IndexFunc(model.vars, func(v ast.Var) bool { return v.Name == name })
+			switch {
+			case i >= 0 && model.vars[i].Value == value:
+				entry.Origin, entry.Detail = varOrigin(spec, model.vars[i], model, global)
+			case i >= 0:
+				entry.Overrides = append(entry.Overrides, fmt.Sprintf("model=%s", model.vars[i].Value))
+			}
+			if v, ok := global.lookup(name); ok {
+				entry.Overrides = append(entry.Overrides, fmt.Sprintf("global=%s", v))
+			}
+			entries = append(entries, entry)
+		}
+	}
+	for _, v := range global.vars {
+		if !slices.ContainsFunc(entries, func(e Entry) bool { return e.Name == v.Name }) {
+			entries = append(entries, Entry{Name: v.Name, Value: v.Value, Origin: "global"})
+		}
+	}
+	return entries
+}
+
+// explainWorkflowVars explains the vars of a workflow, with the values which
+// are passed to the workflow task.
+func explainWorkflowVars(spec ast.SimulationSpec, task generate.Task, w ast.Workflow, model scope, global scope) []Entry {
+	passed := map[string]string{}
+	if task.Cmds != nil {
+		for _, cmd := range *task.Cmds {
+			if cmd.Vars != nil && strings.HasSuffix(cmd.Task, ":"+w.Name) {
+				passed = *cmd.Vars
+			}
+		}
+	}
+	workflow := scope{name: "workflow", vars: varList(w.Vars)}
+	entries := []Entry{}
+	for _, v := range workflow.vars {
+		entry := Entry{Name: v.Name, Value: v.Value}
+		if value, ok := passed[v.Name]; ok {
+			entry.Value = value
+		}
+		entry.Origin, entry.Detail = varOrigin(spec, v, workflow, global)
+		for _, s := range []scope{model, global} {
+			if value, ok := s.lookup(v.Name); ok {
+				entry.Overrides = append(entry.Overrides, fmt.Sprintf("%s=%s", s.name, value))
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// varOrigin returns the origin of a var (the scope, or the network for a
+// network reference) and the source of its value.
+func varOrigin(spec ast.SimulationSpec, v ast.Var, s scope, global scope) (string, string) {
+	if v.Reference == nil {
+		return s.name, ""
+	}
+	switch *v.Reference {
+	case "uses":
+		return s.name, fmt.Sprintf("uses %s", v.Value)
+	case "var":
+		return s.name, fmt.Sprintf("var %s", v.Value)
+	case "network":
+		if network, detail, ok := networkOrigin(spec, v, s, global); ok {
+			return fmt.Sprintf("network %s", network), detail
+		}
+		return "network", ""
+	}
+	return s.name, ""
+}
+
+// networkOrigin finds the network of a network var. Mime type templates are
+// resolved with the vars of the scope, and then the global vars (as convert
+// does), the substituted vars are returned as detail.
+func networkOrigin(spec ast.SimulationSpec, v ast.Var, s scope, global scope) (string, string, bool) {
+	for _, channel := range spec.Channels {
+		if channel.Networks == nil {
+			continue
+		}
+		for _, network := range *channel.Networks {
+			if v.Networktype != nil && *v.Networktype == "signal" {
+				if network.Name == v.Value {
+					return network.Name, "signal", true
+				}
+				continue
+			}
+			subs := []string{}
+			value := templateVarRegex.ReplaceAllStringFunc(network.MimeType, func(match string) string {
+				name := templateVarRegex.FindStringSubmatch(match)[1]
+				for _, lookup := range []scope{s, global} {
+					if value, ok := lookup.lookup(name); ok {
+						subs = append(subs, fmt.Sprintf("%s from %s", name, lookup.name))
+						return value
+					}
+				}
+				return match
+			})
+			if value == v.Value {
+				return network.Name, strings.Join(subs, ", "), true
+			}
+		}
+	}
+	return "", "", false
+}
+
+func fileSource(spec ast.SimulationSpec, f ast.File) string {
+	if f.Reference == nil || *f.Reference != "uses" {
+		return f.Value
+	}
+	if spec.Uses != nil {
+		for _, uses := range *spec.Uses {
+			if uses.Name != f.Value {
+				continue
+			}
+			source := fmt.Sprintf("uses %s (%s", uses.Name, uses.Url)
+			if uses.Path != nil && *uses.Path != "" {
+				source += fmt.Sprintf(", path=%s", *uses.Path)
+			}
+			if f.Path != nil && *f.Path != "" {
+				source += fmt.Sprintf(", file=%s", *f.Path)
+			}
+			return source + ")"
+		}
+	}
+	return fmt.Sprintf("uses %s (not found)", f.Value)
+}
+
+func (e Entry) origin() string {
+	if e.Detail == "" {
+		return e.Origin
+	}
+	return fmt.Sprintf("%s (%s)", e.Origin, e.Detail)
+}
+
+func writeEntries(w io.Writer, title string, entries []Entry) {
+	fmt.Fprintf(w, "\n%s\n", title)
+	if len(entries) == 0 {
+		fmt.Fprintln(w, "  (none)")
+		return
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "  NAME\tVALUE\tORIGIN\tOVERRIDES")
+	for _, e := range entries {
+		fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\n", e.Name, e.Value, e.origin(), strings.Join(e.Overrides, ", "))
+	}
+	tw.Flush()
+}
+
+// Write prints the explanation.
+func (e Explanation) Write(w io.Writer) error {
+	fmt.Fprintf(w, "Model: %s (stack %s)\n", e.Model.Name, e.Stack)
+	fmt.Fprintf(w, "  model:   %s\n", e.Model.Model)
+	if e.Model.Uses != "" {
+		fmt.Fprintf(w, "  uses:    %s\n", e.Model.Uses)
+	}
+	fmt.Fprintf(w, "  handler: %s\n", e.Handler)
+	if location := source.Of(e.Model.Annotations); location != "" {
+		fmt.Fprintf(w, "  source:  %s\n", location)
+	}
+
+	writeEntries(w, "ENV", e.Env)
+	writeEntries(w, "VARS", e.Vars)
+	for _, workflow := range e.Workflows {
+		title := fmt.Sprintf("WORKFLOW %s", workflow.Name)
+		if workflow.Uses != "" {
+			title += fmt.Sprintf(" (uses %s)", workflow.Uses)
+		}
+		writeEntries(w, title, workflow.Vars)
+	}
+
+	fmt.Fprintf(w, "\nFILES\n")
+	if len(e.Files) == 0 {
+		fmt.Fprintln(w, "  (none)")
+	} else {
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "  NAME\tSOURCE\tTARGET")
+		for _, f := range e.Files {
+			fmt.Fprintf(tw, "  %s\t%s\t%s\n", f.Name, f.Source, f.Target)
+		}
+		tw.Flush()
+	}
+
+	fmt.Fprintf(w, "\nRUNTIME\n")
+	if e.Instance.Model != nil {
+		fmt.Fprintf(w, "  model:    %s\n", e.Instance.Model.Name)
+	}
+	if r := e.Instance.Runtime; r != nil {
+		if r.Paths != nil {
+			fmt.Fprintf(w, "  paths:    %s\n", strings.Join(*r.Paths, ", "))
+		}
+		if r.Files != nil {
+			fmt.Fprintf(w, "  files:    %s\n", strings.Join(*r.Files, ", "))
+		}
+		if r.Mcl != nil {
+			fmt.Fprintf(w, "  mcl:      %s\n", *r.Mcl)
+		}
+		if r.External != nil && *r.External {
+			fmt.Fprintln(w, "  external: true")
+		}
+		switch {
+		case r.I386 != nil && *r.I386:
+			fmt.Fprintln(w, "  arch:     i386")
+		case r.X32 != nil && *r.X32:
+			fmt.Fprintln(w, "  arch:     x32")
+		}
+	}
+	return nil
+}
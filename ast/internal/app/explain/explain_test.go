@@ -0,0 +1,152 @@
+// Copyright 2025 Robert Bosch GmbH
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package explain
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/boschglobal/dse.schemas/code/go/dse/ast"
+)
+
+const testAst = `---
+arch: linux-amd64
+channels:
+  - name: network
+    networks:
+      - name: CAN
+        mime_type: application/x-automotive-bus;bus=can;bus_id={{.BUS_ID}}
+uses:
+  - name: dse.network
+    url: https://github.com/boschglobal/dse.network
+    version: v1.0.5
+  - name: signalgroup
+    url: https://example.com/files/signalgroup.yaml
+vars:
+  - name: BUS_ID
+    value: "1"
+  - name: NODE_ID
+    value: "2"
+stacks:
+  - name: default
+    env:
+      - name: SIMBUS_LOGLEVEL
+        value: "4"
+      - name: TRACE
+        value: "off"
+    models:
+      - name: net
+        model: dse.network
+        uses: dse.network
+        channels:
+          - alias: network_channel
+            name: network
+        env:
+          - name: SIMBUS_LOGLEVEL
+            value: "2"
+        vars:
+          - name: BUS_ID
+            value: "3"
+          - name: PATH
+            value: custom
+          - name: MIMETYPE
+            value: application/x-automotive-bus;bus=can;bus_id=3
+            reference: network
+            networktype: mimetype
+        files:
+          - name: signalgroup.yaml
+            reference: uses
+            value: signalgroup
+          - name: ./net.lua
+            value: lua/net.lua
+        workflows:
+          - name: generate-network
+            uses: dse.network
+            vars:
+              - name: NETWORK
+                value: CAN
+                reference: network
+                networktype: signal
+              - name: BUS
+                value: BUS_ID
+                reference: var
+              - name: NODE_ID
+                value: "5"
+`
+
+func testSpec(t *testing.T) ast.SimulationSpec {
+	var spec ast.SimulationSpec
+	require.NoError(t, yaml.Unmarshal([]byte(testAst), &spec))
+	return spec
+}
+
+func entry(t *testing.T, entries []Entry, name string) Entry {
+	for _, e := range entries {
+		if e.Name == name {
+			return e
+		}
+	}
+	t.Fatalf("entry not found: %s", name)
+	return Entry{}
+}
+
+func TestExplain(t *testing.T) {
+	e, err := Explain(testSpec(t), "net")
+	require.NoError(t, err)
+	assert.Equal(t, "default", e.Stack)
+	assert.Equal(t, "modelc", e.Handler)
+
+	// Env.
+	assert.Equal(t, []Entry{
+		{Name: "SIMBUS_LOGLEVEL", Value: "2", Origin: "model", Overrides: []string{"stack=4"}},
+		{Name: "TRACE", Value: "off", Origin: "stack"},
+	}, e.Env)
+
+	// Vars.
+	assert.Equal(t, Entry{Name: "BUS_ID", Value: "3", Origin: "model", Overrides: []string{"global=1"}}, entry(t, e.Vars, "BUS_ID"))
+	assert.Equal(t, Entry{Name: "PATH", Value: "model/net", Origin: "generate", Overrides: []string{"model=custom"}}, entry(t, e.Vars, "PATH"))
+	assert.Equal(t, Entry{Name: "MIMETYPE", Value: "application/x-automotive-bus;bus=can;bus_id=3", Origin: "network CAN", Detail: "BUS_ID from model"}, entry(t, e.Vars, "MIMETYPE"))
+	assert.Equal(t, Entry{Name: "NODE_ID", Value: "2", Origin: "global"}, entry(t, e.Vars, "NODE_ID"))
+
+	// Workflow vars.
+	require.Len(t, e.Workflows, 1)
+	assert.Equal(t, "dse.network", e.Workflows[0].Uses)
+	assert.Equal(t, []Entry{
+		{Name: "NETWORK", Value: "CAN", Origin: "network CAN", Detail: "signal"},
+		{Name: "BUS", Value: "{{.BUS_ID}}", Origin: "workflow", Detail: "var BUS_ID"},
+		{Name: "NODE_ID", Value: "5", Origin: "workflow", Overrides: []string{"global=2"}},
+	}, e.Workflows[0].Vars)
+
+	// Files and runtime.
+	assert.Equal(t, []File{
+		{Name: "signalgroup.yaml", Source: "uses signalgroup (https://example.com/files/signalgroup.yaml)", Target: "model/net/data/signalgroup.yaml"},
+		{Name: "./net.lua", Source: "lua/net.lua", Target: "model/net/net.lua"},
+	}, e.Files)
+	assert.Equal(t, "dse.network", e.Instance.Model.Name)
+	assert.Equal(t, []string{"model/net/data"}, *e.Instance.Runtime.Paths)
+	assert.Equal(t, map[string]string{"SIMBUS_LOGLEVEL": "2"}, *e.Instance.Runtime.Env)
+}
+
+func TestExplain_write(t *testing.T) {
+	e, err := Explain(testSpec(t), "net")
+	require.NoError(t, err)
+	var b strings.Builder
+	require.NoError(t, e.Write(&b))
+	out := b.String()
+	assert.Contains(t, out, "Model: net (stack default)\n")
+	assert.Regexp(t, `SIMBUS_LOGLEVEL +2 +model +stack=4\n`, out)
+	assert.Regexp(t, `MIMETYPE +application/x-automotive-bus;bus=can;bus_id=3 +network CAN \(BUS_ID from model\)`, out)
+	assert.Contains(t, out, "WORKFLOW generate-network (uses dse.network)\n")
+	assert.Contains(t, out, "  paths:    model/net/data\n")
+}
+
+func TestExplain_modelNotFound(t *testing.T) {
+	_, err := Explain(testSpec(t), "foo")
+	assert.ErrorContains(t, err, "model not found in simulation AST (name=foo)")
+}
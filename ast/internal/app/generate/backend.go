@@ -0,0 +1,845 @@
+// Copyright 2025 Robert Bosch GmbH
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package generate
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Build backends: the task model (i.e. the Taskfile) is written as a Taskfile,
+// or converted to a GNU Makefile or a POSIX shell script.
+const (
+	BackendTaskfile = "taskfile"
+	BackendMake     = "make"
+	BackendSh       = "sh"
+)
+
+var backendFiles = map[string]string{
+	BackendTaskfile: "Taskfile.yml",
+	BackendMake:     "Makefile",
+	BackendSh:       "build.sh",
+}
+
+// rootDirVar is the shell variable holding the directory where the build
+// was started (tasks without a dir run in this directory).
+const rootDirVar = "SDP_ROOTDIR"
+
+// taskNamespace holds the tasks of a Taskfile, either the generated Taskfile
+// (root) or an included Taskfile.
+type taskNamespace struct {
+	prefix   string
+	tasks    map[string]Task
+	scope    map[string]string
+	env      [][2]string
+	dir      string
+	includes map[string]*taskNamespace
+	err      error
+}
+
+type taskStep struct {
+	cmd  string
+	call *taskInstance
+}
+
+// taskInstance is a task called with a specific set of vars, all templates
+// are rendered (runtime values are shell variable references).
+type taskInstance struct {
+	id      string
+	task    string
+	entry   bool
+	run     string
+	dir     string
+	deps    []*taskInstance
+	steps   []taskStep
+	check   string
+	require []string
+	env     [][2]string
+}
+
+// taskGraph expands the task model into task instances.
+type taskGraph struct {
+	root      *taskNamespace
+	globals   [][2]string
+	instances []*taskInstance
+	byKey     map[string]*taskInstance
+	active    map[string]bool
+	ids       map[string]int
+	entry     map[string]bool
+
+	// localTaskfile returns the local copy of a remote Taskfile, or "" (remote
+	// Taskfiles are not fetched).
+	localTaskfile func(url string) string
+	missing       []string // Remote Taskfiles without a local copy.
+}
+
+// errNotLocal is the load error of a remote Taskfile without a local copy.
+var errNotLocal = errors.New("remote Taskfile not available (run 'ast resolve' or 'ast vendor' first)")
+
+func newTaskGraph(taskfile *Taskfile, localTaskfile func(url string) string) (*taskGraph, error) {
+	g := &taskGraph{
+		byKey:         map[string]*taskInstance{},
+		active:        map[string]bool{},
+		ids:           map[string]int{},
+		localTaskfile: localTaskfile,
+	}
+	scope, globals, err := rootScope(taskfile)
+	if err != nil {
+		return nil, err
+	}
+	g.globals = globals
+	g.root = &taskNamespace{
+		tasks:    map[string]Task{},
+		scope:    scope,
+		includes: map[string]*taskNamespace{},
+	}
+	if taskfile.Tasks != nil {
+		g.root.tasks = *taskfile.Tasks
+	}
+	if taskfile.Includes != nil {
+		for _, name := range sortedKeys(*taskfile.Includes) {
+			g.root.includes[name] = g.loadNamespace(name+":", (*taskfile.Includes)[name], g.root, "")
+		}
+	}
+	return g, nil
+}
+
+// rootScope returns the scope of the (root) Taskfile and the rendered global
+// vars. Global vars are shell variables, set when the build starts.
+func rootScope(taskfile *Taskfile) (map[string]string, [][2]string, error) {
+	// Special vars of Task, the root dir is where the build was started.
+	scope := map[string]string{}
+	for _, k := range []string{"ROOT_DIR", "USER_WORKING_DIR", "TASKFILE_DIR"} {
+		scope[k] = fmt.Sprintf("${%s}", rootDirVar)
+	}
+	globals := [][2]string{}
+	if taskfile.Vars != nil {
+		for _, k := range taskfile.Vars.Keys() {
+			v, _ := taskfile.Vars.Get(k)
+			value, err := renderTemplate(v, scope)
+			if err != nil {
+				return nil, nil, fmt.Errorf("var %s: %v", k, err)
+			}
+			globals = append(globals, [2]string{k, value})
+			scope[k] = fmt.Sprintf("${%s}", k)
+		}
+	}
+	return scope, globals, nil
+}
+
+// globalAssignment returns the shell assignment of a global var, the value is
+// a default (i.e. a var which is set, e.g. with a VAR=value argument of the
+// build, is kept).
+func globalAssignment(name string, value string) string {
+	return fmt.Sprintf("[ -n \"${%s}\" ] || %s=\"%s\"", name, name, value)
+}
+
+// taskScope returns the scope of a task: the namespace scope, the call vars
+// and then the (rendered) task vars.
+func taskScope(nsScope map[string]string, fullName string, task *Task, callVars map[string]string) (map[string]string, error) {
+	scope := map[string]string{}
+	for k, v := range nsScope {
+		scope[k] = v
+	}
+	scope["TASK"] = fullName
+	for k, v := range callVars {
+		scope[k] = v
+	}
+	if task.Vars != nil {
+		for _, k := range task.Vars.Keys() {
+			v, _ := task.Vars.Get(k)
+			value, err := renderTemplate(v, scope)
+			if err != nil {
+				return nil, fmt.Errorf("task %s: var %s: %v", fullName, k, err)
+			}
+			scope[k] = value
+		}
+	}
+	return scope, nil
+}
+
+// loadNamespace loads an included Taskfile (local or remote). Load errors are
+// reported when a task of the namespace is called.
+func (g *taskGraph) loadNamespace(prefix string, include Include, parent *taskNamespace, baseDir string) *taskNamespace {
+	ns := &taskNamespace{
+		prefix:   prefix,
+		tasks:    map[string]Task{},
+		scope:    map[string]string{},
+		includes: map[string]*taskNamespace{},
+		env:      slices.Clone(parent.env),
+	}
+	for k, v := range parent.scope {
+		ns.scope[k] = v
+	}
+	fail := func(err error) *taskNamespace {
+		if !include.Optional {
+			if errors.Is(err, errNotLocal) {
+				g.missing = append(g.missing, fmt.Sprintf("include %s: %v", strings.TrimSuffix(prefix, ":"), err))
+			}
+			slog.Warn(fmt.Sprintf("include %s: %v", strings.TrimSuffix(prefix, ":"), err))
+		}
+		ns.err = err
+		return ns
+	}
+
+	location := expandEnvVars(include.Taskfile)
+	data, location, err := g.readTaskfile(location, baseDir)
+	if err != nil {
+		return fail(err)
+	}
+	var taskfile Taskfile
+	if err := yaml.Unmarshal(data, &taskfile); err != nil {
+		return fail(fmt.Errorf("invalid Taskfile (%s): %v", location, err))
+	}
+
+	// Vars of the include, then vars of the included Taskfile.
+	if include.Vars != nil {
+		for _, k := range sortedKeys(*include.Vars) {
+			v, err := renderTemplate((*include.Vars)[k], parent.scope)
+			if err != nil {
+				return fail(fmt.Errorf("var %s: %v", k, err))
+			}
+			ns.scope[k] = v
+		}
+	}
+	if taskfile.Vars != nil {
+		for _, k := range taskfile.Vars.Keys() {
+			v, _ := taskfile.Vars.Get(k)
+			value, err := renderTemplate(v, ns.scope)
+			if err != nil {
+				return fail(fmt.Errorf("var %s: %v", k, err))
+			}
+			ns.scope[k] = value
+		}
+	}
+	if taskfile.Env != nil {
+		for _, k := range taskfile.Env.Keys() {
+			v, _ := taskfile.Env.Get(k)
+			value, err := renderTemplate(v, ns.scope)
+			if err != nil {
+				return fail(fmt.Errorf("env %s: %v", k, err))
+			}
+			ns.env = append(ns.env, [2]string{k, value})
+		}
+	}
+
+	// The dir of the include, otherwise the folder of a local Taskfile.
+	taskfileDir := ""
+	if !isRemote(location) {
+		taskfileDir = filepath.Dir(location)
+	}
+	ns.dir = taskfileDir
+	if include.Dir != "" {
+		if ns.dir, err = renderTemplate(include.Dir, parent.scope); err != nil {
+			return fail(fmt.Errorf("dir: %v", err))
+		}
+	}
+	ns.scope["TASKFILE_DIR"] = ns.dir
+	if taskfile.Tasks != nil {
+		ns.tasks = *taskfile.Tasks
+	}
+	if taskfile.Includes != nil {
+		for _, name := range sortedKeys(*taskfile.Includes) {
+			ns.includes[name] = g.loadNamespace(prefix+name+":", (*taskfile.Includes)[name], ns, taskfileDir)
+		}
+	}
+	return ns
+}
+
+func isRemote(location string) bool {
+	return strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://")
+}
+
+// readTaskfile reads a Taskfile from a path (relative paths are relative to
+// the including Taskfile, a directory is searched for a Taskfile), or the
+// local copy of a URL (Taskfiles are not fetched).
+func (g *taskGraph) readTaskfile(location string, baseDir string) ([]byte, string, error) {
+	if isRemote(location) {
+		local := ""
+		if g.localTaskfile != nil {
+			local = g.localTaskfile(location)
+		}
+		if local == "" {
+			return nil, location, fmt.Errorf("%w: %s", errNotLocal, location)
+		}
+		location = local
+	}
+	location = strings.TrimPrefix(location, "file://")
+	if !filepath.IsAbs(location) && baseDir != "" {
+		location = filepath.Join(baseDir, location)
+	}
+	if info, err := os.Stat(location); err == nil && info.IsDir() {
+		for _, name := range []string{"Taskfile.sdp.yml", "Taskfile.sdp.yaml", "Taskfile.yml", "Taskfile.yaml"} {
+			if _, err := os.Stat(filepath.Join(location, name)); err == nil {
+				location = filepath.Join(location, name)
+				break
+			}
+		}
+	}
+	data, err := os.ReadFile(location)
+	return data, location, err
+}
+
+// lookup locates a task, names are relative to the namespace, a leading ':'
+// refers to the root Taskfile.
+func (g *taskGraph) lookup(ns *taskNamespace, name string) (*taskNamespace, string, *Task, error) {
+	if strings.HasPrefix(name, ":") {
+		return g.lookup(g.root, name[1:])
+	}
+	if task, ok := ns.tasks[name]; ok {
+		return ns, name, &task, nil
+	}
+	if include, rest, ok := strings.Cut(name, ":"); ok {
+		child, ok := ns.includes[include]
+		if !ok {
+			return ns, name, nil, fmt.Errorf("task not found, include %s is not available (remote Taskfile not available, run 'ast resolve' or 'ast vendor' first)", include)
+		}
+		if child.err != nil {
+			return child, rest, nil, child.err
+		}
+		return g.lookup(child, rest)
+	}
+	return ns, name, nil, fmt.Errorf("task not found")
+}
+
+// entries returns the tasks of the root Taskfile which can be called without
+// vars (i.e. tasks with vars which reference themselves need to be called).
+func (g *taskGraph) entries() []string {
+	names := []string{}
+	for _, name := range sortedKeys(g.root.tasks) {
+		task := g.root.tasks[name]
+		selfRef := false
+		if task.Vars != nil {
+			for _, k := range task.Vars.Keys() {
+				v, _ := task.Vars.Get(k)
+				for _, action := range templateActionRegex.FindAllString(v, -1) {
+					for _, m := range templateFieldRegex.FindAllStringSubmatch(action, -1) {
+						if m[1] == k {
+							selfRef = true
+						}
+					}
+				}
+			}
+		}
+		if !selfRef {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// expand creates the task instances, starting from the entry tasks.
+func (g *taskGraph) expand() error {
+	g.entry = map[string]bool{}
+	for _, name := range g.entries() {
+		g.entry[name] = true
+	}
+	for _, name := range g.entries() {
+		if _, err := g.instance(g.root, name, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var instanceIdRegex = regexp.MustCompile(`[^A-Za-z0-9_.-]`)
+
+func (g *taskGraph) instanceId(name string, entry bool) string {
+	id := instanceIdRegex.ReplaceAllString(name, "_")
+	if entry {
+		return id
+	}
+	g.ids[id] += 1
+	return fmt.Sprintf("%s.%d", id, g.ids[id])
+}
+
+// instance returns the instance of a task called with the (rendered) vars.
+func (g *taskGraph) instance(ns *taskNamespace, name string, callVars map[string]string) (*taskInstance, error) {
+	taskNs, taskName, task, err := g.lookup(ns, name)
+	fullName := taskNs.prefix + taskName
+	entry := taskNs == g.root && len(callVars) == 0 && g.entry[taskName]
+	key := fullName
+	for _, k := range sortedKeys(callVars) {
+		key += fmt.Sprintf("\x00%s=%s", k, callVars[k])
+	}
+	if ti, ok := g.byKey[key]; ok {
+		return ti, nil
+	}
+	if g.active[key] {
+		return nil, fmt.Errorf("task %s: cyclic task call", fullName)
+	}
+	if err != nil {
+		if taskNs.err == nil {
+			return nil, fmt.Errorf("task %s: %v", fullName, err)
+		}
+		// Task of an include which could not be loaded, fail when called.
+		ti := &taskInstance{id: g.instanceId(fullName, false), task: fullName}
+		ti.steps = []taskStep{{cmd: fmt.Sprintf("echo \"Task %s not available: %s\" >&2\nexit 1", fullName, strings.ReplaceAll(err.Error(), "\"", "'"))}}
+		g.byKey[key] = ti
+		g.instances = append(g.instances, ti)
+		return ti, nil
+	}
+	g.active[key] = true
+	defer delete(g.active, key)
+
+	scope, err := taskScope(taskNs.scope, fullName, task, callVars)
+	if err != nil {
+		return nil, err
+	}
+	render := func(text string) (string, error) {
+		s, err := renderTemplate(text, scope)
+		if err != nil {
+			return "", fmt.Errorf("task %s: %v", fullName, err)
+		}
+		return s, nil
+	}
+	renderList := func(list *[]string) ([]string, error) {
+		result := []string{}
+		if list == nil {
+			return result, nil
+		}
+		for _, item := range *list {
+			s, err := render(item)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, s)
+		}
+		return result, nil
+	}
+	renderVars := func(keys []string, get func(string) string) (map[string]string, error) {
+		vars := map[string]string{}
+		for _, k := range keys {
+			v, err := render(get(k))
+			if err != nil {
+				return nil, err
+			}
+			vars[k] = v
+		}
+		return vars, nil
+	}
+
+	ti := &taskInstance{
+		id:    g.instanceId(fullName, entry),
+		task:  fullName,
+		entry: entry,
+		run:   "always",
+		dir:   taskNs.dir,
+		env:   slices.Clone(taskNs.env),
+	}
+	if task.Run != nil {
+		ti.run = *task.Run
+	}
+	if task.Dir != nil {
+		dir, err := render(*task.Dir)
+		if err != nil {
+			return nil, err
+		}
+		if dir != "" && !filepath.IsAbs(dir) && !strings.HasPrefix(dir, "$") && ti.dir != "" {
+			dir = filepath.Join(ti.dir, dir)
+		}
+		ti.dir = dir
+	}
+	if task.Env != nil {
+		for _, k := range task.Env.Keys() {
+			v, _ := task.Env.Get(k)
+			value, err := render(v)
+			if err != nil {
+				return nil, err
+			}
+			ti.env = append(ti.env, [2]string{k, value})
+		}
+	}
+	if task.Requires != nil && task.Requires.Vars != nil {
+		for _, v := range *task.Requires.Vars {
+			if _, ok := scope[v]; !ok {
+				ti.require = append(ti.require, v)
+			}
+		}
+	}
+	if task.Deps != nil {
+		for _, dep := range *task.Deps {
+			var vars map[string]string
+			if dep.Vars != nil {
+				if vars, err = renderVars(dep.Vars.Keys(), func(k string) string { v, _ := dep.Vars.Get(k); return v }); err != nil {
+					return nil, err
+				}
+			}
+			depTi, err := g.instance(taskNs, dep.Task, vars)
+			if err != nil {
+				return nil, err
+			}
+			ti.deps = append(ti.deps, depTi)
+		}
+	}
+	if task.Cmds != nil {
+		for _, cmd := range *task.Cmds {
+			switch {
+			case cmd.Task != "":
+				var vars map[string]string
+				if cmd.Vars != nil {
+					if vars, err = renderVars(sortedKeys(*cmd.Vars), func(k string) string { return (*cmd.Vars)[k] }); err != nil {
+						return nil, err
+					}
+				}
+				callTi, err := g.instance(taskNs, cmd.Task, vars)
+				if err != nil {
+					return nil, err
+				}
+				ti.steps = append(ti.steps, taskStep{call: callTi})
+			case cmd.Cmd != "":
+				s, err := render(cmd.Cmd)
+				if err != nil {
+					return nil, err
+				}
+				ti.steps = append(ti.steps, taskStep{cmd: strings.Trim(s, "\n")})
+			}
+		}
+	}
+	sources, err := renderList(task.Sources)
+	if err != nil {
+		return nil, err
+	}
+	generates, err := renderList(task.Generates)
+	if err != nil {
+		return nil, err
+	}
+	status, err := renderList(task.Status)
+	if err != nil {
+		return nil, err
+	}
+	ti.check = upToDateCheck(sources, generates, status)
+
+	g.byKey[key] = ti
+	g.instances = append(g.instances, ti)
+	return ti, nil
+}
+
+// upToDateCheck returns a shell condition which is true when the task is up
+// to date: all status commands succeed and/or the generated files exist and
+// are newer than the sources. Glob patterns cannot be checked (the task will
+// always run).
+func upToDateCheck(sources []string, generates []string, status []string) string {
+	conds := []string{}
+	for _, s := range status {
+		conds = append(conds, fmt.Sprintf("{ %s; } >/dev/null 2>&1", s))
+	}
+	hasGlob := func(list []string) bool {
+		return slices.ContainsFunc(list, func(s string) bool { return strings.ContainsAny(s, "*?[") })
+	}
+	if len(sources) > 0 && len(generates) > 0 && !hasGlob(sources) && !hasGlob(generates) {
+		quoted := []string{}
+		for _, s := range sources {
+			conds = append(conds, fmt.Sprintf("[ -e \"%s\" ]", s))
+			quoted = append(quoted, fmt.Sprintf("\"%s\"", s))
+		}
+		for _, f := range generates {
+			conds = append(conds, fmt.Sprintf("[ -e \"%s\" ]", f))
+			conds = append(conds, fmt.Sprintf("[ -z \"$(find %s -newer \"%s\" 2>/dev/null)\" ]", strings.Join(quoted, " "), f))
+		}
+	} else if len(status) == 0 {
+		return ""
+	}
+	return strings.Join(conds, " && ")
+}
+
+var (
+	templateIfVarRegex   = regexp.MustCompile(`\{\{-?\s*if\s+(?:(all|any)\s+)?\.(\w+)((?:\s+\.\w+)*)\s*-?\}\}((?:[^{]|\{[^{]|\{\{\s*\.\w+\s*\}\})*?)(?:\{\{-?\s*else\s*-?\}\}((?:[^{]|\{[^{]|\{\{\s*\.\w+\s*\}\})*?))?\{\{-?\s*end\s*-?\}\}`)
+	templateRefVarRegex  = regexp.MustCompile(`\{\{-?\s*\.(\w+)\s*-?\}\}`)
+	templateDefaultRegex = regexp.MustCompile(`\{\{-?\s*(?:\.(\w+)\s*\|\s*default\s+"([^"]*)"|default\s+"([^"]*)"\s+\.(\w+))\s*-?\}\}`)
+	templateFuncVarRegex = regexp.MustCompile(`\{\{-?\s*(?:(\w+)\s+\.(\w+)|\.(\w+)\s*\|\s*(\w+))\s*-?\}\}`)
+	templateActionRegex  = regexp.MustCompile(`\{\{.*?\}\}`)
+	templateFieldRegex   = regexp.MustCompile(`(?:^|[\s(|{])\.(\w+)`)
+)
+
+// templateShellFuncs are the shell equivalents of the template functions
+// which can be applied to runtime values (i.e. {{base .URL}}).
+var templateShellFuncs = map[string]string{
+	"base":       `$(basename "%s")`,
+	"dir":        `$(dirname "%s")`,
+	"ext":        `$(expr "%s" : '.*\(\.[^./]*\)$' || true)`,
+	"trim":       `$(printf '%%s' "%s" | sed 's/^[[:space:]]*//;s/[[:space:]]*$//')`,
+	"lower":      `$(printf '%%s' "%s" | tr '[:upper:]' '[:lower:]')`,
+	"upper":      `$(printf '%%s' "%s" | tr '[:lower:]' '[:upper:]')`,
+	"toSlash":    `%s`,
+	"fromSlash":  `%s`,
+	"q":          `"%s"`,
+	"shellQuote": `"%s"`,
+}
+
+// renderTemplate renders a (Task) template with the vars in scope. Vars which
+// are not in scope are provided by the environment when the build runs, they
+// are represented by shell variable references (conditions on these vars, and
+// the functions of templateShellFuncs applied to runtime values, are also
+// evaluated by the shell). Other template actions on these vars cannot be
+// rendered and are reported.
+func renderTemplate(text string, scope map[string]string) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+	text = templateIfVarRegex.ReplaceAllStringFunc(text, func(match string) string {
+		m := templateIfVarRegex.FindStringSubmatch(match)
+		fn, name, then, otherwise := m[1], m[2], m[4], m[5]
+		names := append([]string{name}, strings.Fields(strings.ReplaceAll(m[3], ".", ""))...)
+		if !slices.ContainsFunc(names, func(n string) bool { _, ok := scope[n]; return !ok }) {
+			return match
+		}
+		if fn != "" {
+			// Condition on several vars, values in scope are tested as is.
+			conds := []string{}
+			for _, n := range names {
+				value, ok := scope[n]
+				if !ok {
+					value = fmt.Sprintf("${%s}", n)
+				}
+				conds = append(conds, fmt.Sprintf("[ -n \"%s\" ]", value))
+			}
+			op := map[string]string{"all": " && ", "any": " || "}[fn]
+			if otherwise == "" {
+				return fmt.Sprintf("$(if %s; then echo \"%s\"; fi)", strings.Join(conds, op), then)
+			}
+			return fmt.Sprintf("$(if %s; then echo \"%s\"; else echo \"%s\"; fi)", strings.Join(conds, op), then, otherwise)
+		}
+		hasElse := strings.Contains(match, "else")
+		ref := templateRefVarRegex.FindStringSubmatch(then)
+		switch {
+		case hasElse && ref != nil && ref[0] == then && ref[1] == name:
+			return fmt.Sprintf("${%s:-%s}", name, otherwise)
+		case !hasElse:
+			return fmt.Sprintf("${%s:+%s}", name, then)
+		default:
+			return fmt.Sprintf("$(if [ -n \"${%s}\" ]; then echo \"%s\"; else echo \"%s\"; fi)", name, then, otherwise)
+		}
+	})
+	text = templateDefaultRegex.ReplaceAllStringFunc(text, func(match string) string {
+		m := templateDefaultRegex.FindStringSubmatch(match)
+		name, value := m[1]+m[4], m[2]+m[3]
+		if _, ok := scope[name]; ok {
+			return match
+		}
+		return fmt.Sprintf("${%s:-%s}", name, value)
+	})
+	text = templateFuncVarRegex.ReplaceAllStringFunc(text, func(match string) string {
+		m := templateFuncVarRegex.FindStringSubmatch(match)
+		fn, name := m[1]+m[4], m[2]+m[3]
+		value, ok := scope[name]
+		if !ok {
+			value = fmt.Sprintf("${%s}", name)
+		}
+		shell, supported := templateShellFuncs[fn]
+		if !supported || !strings.Contains(value, "$") {
+			return match
+		}
+		return fmt.Sprintf(shell, value)
+	})
+	t, err := template.New("").Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	data := map[string]any{}
+	for _, action := range templateActionRegex.FindAllString(text, -1) {
+		for _, m := range templateFieldRegex.FindAllStringSubmatch(action, -1) {
+			if _, ok := scope[m[1]]; ok {
+				continue
+			}
+			if !templateRefVarRegex.MatchString(action) || templateRefVarRegex.FindString(action) != action {
+				return "", fmt.Errorf("var %s is set when the build runs, template action %s is not supported", m[1], action)
+			}
+			data[m[1]] = fmt.Sprintf("${%s}", m[1])
+		}
+	}
+	for k, v := range scope {
+		data[k] = v
+	}
+	var b bytes.Buffer
+	if err := t.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// templateFuncs are the (commonly used) template functions of Task.
+var templateFuncs = template.FuncMap{
+	"OS":         func() string { return runtime.GOOS },
+	"ARCH":       func() string { return runtime.GOARCH },
+	"exeExt":     func() string { return "" },
+	"base":       path.Base,
+	"dir":        path.Dir,
+	"ext":        path.Ext,
+	"toSlash":    filepath.ToSlash,
+	"fromSlash":  filepath.FromSlash,
+	"trim":       strings.TrimSpace,
+	"lower":      strings.ToLower,
+	"upper":      strings.ToUpper,
+	"replace":    func(old, new, s string) string { return strings.ReplaceAll(s, old, new) },
+	"contains":   func(substr, s string) bool { return strings.Contains(s, substr) },
+	"hasPrefix":  func(prefix, s string) bool { return strings.HasPrefix(s, prefix) },
+	"hasSuffix":  func(suffix, s string) bool { return strings.HasSuffix(s, suffix) },
+	"splitList":  func(sep, s string) []string { return strings.Split(s, sep) },
+	"join":       func(sep string, list []string) string { return strings.Join(list, sep) },
+	"q":          shellQuote,
+	"shellQuote": shellQuote,
+	"empty":      func(v any) bool { return templateEmpty(v) },
+	"default": func(d any, v ...any) any {
+		if len(v) == 0 || templateEmpty(v[0]) {
+			return d
+		}
+		return v[0]
+	},
+	"coalesce": func(v ...any) any {
+		for _, item := range v {
+			if !templateEmpty(item) {
+				return item
+			}
+		}
+		return ""
+	},
+	"all": func(v ...any) bool {
+		return !slices.ContainsFunc(v, templateEmpty)
+	},
+	"any": func(v ...any) bool {
+		return slices.ContainsFunc(v, func(item any) bool { return !templateEmpty(item) })
+	},
+}
+
+func templateEmpty(v any) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case bool:
+		return !v
+	case []string:
+		return len(v) == 0
+	}
+	return false
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// GenerateBuildFile writes the task model of the simulation as a build file
+// of the selected (non Taskfile) backend, i.e. a Makefile or a shell script.
+func (c GenerateCommand) GenerateBuildFile() error {
+	buildFile := backendFiles[c.backend]
+	buildPath := filepath.Join(c.outputPath, buildFile)
+	taskfile, err := c.buildTaskfile(buildFile)
+	if err != nil {
+		return err
+	}
+	g, err := newTaskGraph(taskfile, c.localTaskfile)
+	if err != nil {
+		return err
+	}
+	if len(g.missing) > 0 {
+		return fmt.Errorf("%s", strings.Join(g.missing, "\n"))
+	}
+	if err := g.expand(); err != nil {
+		return err
+	}
+
+	var b bytes.Buffer
+	var mode os.FileMode = 0644
+	switch c.backend {
+	case BackendMake:
+		fmt.Fprintf(flag.CommandLine.Output(), "Writing makefile: %s\n", buildPath)
+		g.writeMakefile(&b)
+	case BackendSh:
+		fmt.Fprintf(flag.CommandLine.Output(), "Writing script: %s\n", buildPath)
+		g.writeScript(&b)
+		mode = 0755
+	default:
+		return fmt.Errorf("unsupported backend: %s", c.backend)
+	}
+	os.MkdirAll(filepath.Dir(buildPath), os.ModePerm)
+	return os.WriteFile(buildPath, b.Bytes(), mode)
+}
+
+// instanceDir returns the (shell) path of the directory where the commands
+// of a task instance run, relative paths are relative to the root dir.
+func instanceDir(ti *taskInstance) string {
+	switch {
+	case ti.dir == "":
+		return fmt.Sprintf("${%s}", rootDirVar)
+	case filepath.IsAbs(ti.dir) || strings.HasPrefix(ti.dir, "$"):
+		return ti.dir
+	}
+	return fmt.Sprintf("${%s}/%s", rootDirVar, ti.dir)
+}
+
+// scriptLine is a line of a task script, raw lines (heredoc content) are not
+// indented by the backend.
+type scriptLine struct {
+	text string
+	raw  bool
+}
+
+// instanceBody returns the script lines of a task instance: required vars,
+// the up-to-date check and then the commands (each run in a subshell, in the
+// task dir). The escape function is applied to the rendered task content,
+// calls to other instances are provided by the backend.
+func instanceBody(ti *taskInstance, escape func(string) string, call func(*taskInstance) string, exit string) []scriptLine {
+	lines := []scriptLine{}
+	add := func(format string, a ...any) {
+		lines = append(lines, scriptLine{text: fmt.Sprintf(format, a...)})
+	}
+	dir := escape(instanceDir(ti))
+	for _, v := range ti.require {
+		add("%s", escape(fmt.Sprintf(": \"${%s:?task %s requires var %s}\"", v, ti.task, v)))
+	}
+	if ti.check != "" {
+		add("if (cd \"%s\" && %s); then", dir, escape(ti.check))
+		add("    echo \"task: [%s] up to date\"", ti.task)
+		add("    %s", exit)
+		add("fi")
+	}
+	add("echo \"task: [%s]\"", ti.task)
+	for _, step := range ti.steps {
+		if step.call != nil {
+			add("%s", call(step.call))
+			continue
+		}
+		add("(")
+		add("    cd \"%s\"", dir)
+		for _, e := range ti.env {
+			add("    export %s=\"%s\"", e[0], escape(e[1]))
+		}
+		// Heredoc content is not indented (terminators must start the line).
+		heredoc := strings.Contains(step.cmd, "<<")
+		for _, line := range strings.Split(escape(step.cmd), "\n") {
+			if heredoc {
+				lines = append(lines, scriptLine{text: line, raw: true})
+			} else {
+				add("    %s", line)
+			}
+		}
+		add(")")
+	}
+	return lines
+}
@@ -0,0 +1,61 @@
+// Copyright 2025 Robert Bosch GmbH
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package generate
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// writeMakefile writes the task instances as a GNU Makefile, each instance is
+// a (phony) target with the deps as prerequisites. Recipes run in one shell
+// (.ONESHELL) with the global vars set, calls to other tasks run a nested make
+// (dry runs, make -n, do not include these calls). Global vars are defaults,
+// VAR=value arguments of make (exported to the recipes) are kept.
+func (g *taskGraph) writeMakefile(b *bytes.Buffer) {
+	escape := func(s string) string { return strings.ReplaceAll(s, "$", "$$") }
+	call := func(ti *taskInstance) string {
+		return fmt.Sprintf("$(SDP_MAKE) %s", ti.id)
+	}
+
+	b.WriteString("# Generated by ast generate -backend=make.\n")
+	b.WriteString("#\n")
+	b.WriteString("# Usage: make [task ...] [VAR=value ...]\n")
+	b.WriteString("SHELL := /bin/sh\n")
+	b.WriteString(".SHELLFLAGS := -ec\n")
+	b.WriteString(".ONESHELL:\n")
+	b.WriteString(".DEFAULT_GOAL := default\n")
+	b.WriteString("SDP_MAKEFILE := $(abspath $(lastword $(MAKEFILE_LIST)))\n")
+	// Indirect reference to MAKE, otherwise make -n would run the recipe.
+	b.WriteString("SDP_MAKE = $(MAKE) --no-print-directory -C \"$(CURDIR)\" -f \"$(SDP_MAKEFILE)\"\n\n")
+	b.WriteString("define sdp-env\n")
+	fmt.Fprintf(b, "%s=\"$(CURDIR)\"\n", rootDirVar)
+	for _, v := range g.globals {
+		fmt.Fprintf(b, "%s\n", escape(globalAssignment(v[0], v[1])))
+	}
+	b.WriteString("endef\n")
+
+	phony := []string{}
+	for _, ti := range g.instances {
+		phony = append(phony, ti.id)
+	}
+	fmt.Fprintf(b, "\n.PHONY: %s\n", strings.Join(phony, " "))
+
+	for _, ti := range g.instances {
+		deps := []string{}
+		for _, dep := range ti.deps {
+			deps = append(deps, dep.id)
+		}
+		fmt.Fprintf(b, "\n# %s\n%s:", ti.task, ti.id)
+		if len(deps) > 0 {
+			fmt.Fprintf(b, " %s", strings.Join(deps, " "))
+		}
+		b.WriteString("\n\t@$(sdp-env)\n")
+		for _, line := range instanceBody(ti, escape, call, "exit 0") {
+			fmt.Fprintf(b, "\t%s\n", line.text)
+		}
+	}
+}
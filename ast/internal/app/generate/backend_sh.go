@@ -0,0 +1,86 @@
+// Copyright 2025 Robert Bosch GmbH
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package generate
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+)
+
+var shNameRegex = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// writeScript writes the task instances as a POSIX shell script, each
+// instance is a function and the entry tasks are selected by the arguments
+// of the script (VAR=value arguments set vars, before the global vars are
+// set, so that derived global vars use them).
+func (g *taskGraph) writeScript(b *bytes.Buffer) {
+	names := map[*taskInstance]string{}
+	used := map[string]bool{}
+	for _, ti := range g.instances {
+		name := "task_" + shNameRegex.ReplaceAllString(ti.id, "_")
+		for i := 2; used[name]; i++ {
+			name = fmt.Sprintf("task_%s_%d", shNameRegex.ReplaceAllString(ti.id, "_"), i)
+		}
+		used[name] = true
+		names[ti] = name
+	}
+	noEscape := func(s string) string { return s }
+
+	b.WriteString("#!/bin/sh\n")
+	b.WriteString("# Generated by ast generate -backend=sh.\n")
+	b.WriteString("#\n")
+	b.WriteString("# Usage: ./build.sh [VAR=value ...] [task ...]\n")
+	b.WriteString("set -e\n\n")
+	b.WriteString("for _arg in \"$@\"; do\n")
+	b.WriteString("    case \"${_arg}\" in\n")
+	b.WriteString("    *=*) export \"${_arg}\" ;;\n")
+	b.WriteString("    *) _tasks=1 ;;\n")
+	b.WriteString("    esac\n")
+	b.WriteString("done\n\n")
+	fmt.Fprintf(b, "%s=\"$(pwd)\"\n", rootDirVar)
+	for _, v := range g.globals {
+		fmt.Fprintf(b, "%s\n", globalAssignment(v[0], v[1]))
+	}
+
+	for _, ti := range g.instances {
+		name := names[ti]
+		fmt.Fprintf(b, "\n# %s\n%s() {\n", ti.task, name)
+		// Run once (per task) or when changed (per set of vars).
+		switch ti.run {
+		case "once":
+			guard := "_once_" + shNameRegex.ReplaceAllString(ti.task, "_")
+			fmt.Fprintf(b, "    [ -n \"${%s}\" ] && return 0\n    %s=1\n", guard, guard)
+		case "when_changed":
+			guard := "_done_" + name
+			fmt.Fprintf(b, "    [ -n \"${%s}\" ] && return 0\n    %s=1\n", guard, guard)
+		}
+		for _, dep := range ti.deps {
+			fmt.Fprintf(b, "    %s\n", names[dep])
+		}
+		call := func(callTi *taskInstance) string { return names[callTi] }
+		for _, line := range instanceBody(ti, noEscape, call, "return 0") {
+			if line.raw {
+				fmt.Fprintf(b, "%s\n", line.text)
+			} else {
+				fmt.Fprintf(b, "    %s\n", line.text)
+			}
+		}
+		b.WriteString("}\n")
+	}
+
+	b.WriteString("\n[ -n \"${_tasks}\" ] || set -- \"$@\" default\n")
+	b.WriteString("for _task in \"$@\"; do\n")
+	b.WriteString("    case \"${_task}\" in\n")
+	b.WriteString("    *=*) ;;\n")
+	for _, ti := range g.instances {
+		if ti.entry {
+			fmt.Fprintf(b, "    %s) %s ;;\n", ti.task, names[ti])
+		}
+	}
+	b.WriteString("    *) echo \"task: unknown task '${_task}'\" >&2; exit 1 ;;\n")
+	b.WriteString("    esac\n")
+	b.WriteString("done\n")
+}
@@ -0,0 +1,375 @@
+// Copyright 2025 Robert Bosch GmbH
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package generate
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/boschglobal/dse.sdp/ast/internal/pkg/artifact"
+	"github.com/boschglobal/dse.sdp/ast/internal/pkg/cache"
+	"github.com/boschglobal/dse.sdp/ast/internal/pkg/provider"
+	"github.com/boschglobal/dse.sdp/ast/internal/pkg/testutil"
+)
+
+func generateBuildFile(t *testing.T, input string, backend string, override string) []byte {
+	data, err := os.ReadFile(input)
+	require.NoError(t, err)
+	outFolder := filepath.Join("tmp", t.Name())
+	require.NoError(t, os.RemoveAll(filepath.Join("out", outFolder)))
+	require.NoError(t, os.MkdirAll(filepath.Join("out", outFolder), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join("out", outFolder, "ast.yaml"), data, 0644))
+
+	cmd := NewGenerateCommand("test_generate_backend")
+	require.NoError(t, cmd.Parse([]string{"-taskfile", "-backend", backend, "-input", filepath.Join(outFolder, "ast.yaml"), "-output", outFolder,
+		"-override", override}))
+	require.NoError(t, cmd.Run())
+	buildFile := filepath.Join("out", outFolder, backendFiles[backend])
+	assert.NoFileExists(t, filepath.Join("out", outFolder, "Taskfile.yml"))
+	f, err := os.ReadFile(buildFile)
+	require.NoError(t, err)
+	t.Logf("\n%s\n", f)
+	return f
+}
+
+func backendCheckout(t *testing.T) string {
+	checkout := t.TempDir()
+	taskfile := `version: '3'
+vars:
+  IMAGE: '{{.IMAGE | default "dse-modelc"}}'
+tasks:
+  generate-fmimcl:
+    run: once
+    dir: '{{.USER_WORKING_DIR}}'
+    cmds:
+      - echo "generate {{.FMU_DIR}} -> {{.OUT_DIR}} with {{.IMAGE}}"
+      - |
+        cat <<EOF > {{.OUT_DIR}}/model.yaml
+        mcl: {{.MCL_PATH}}
+        EOF
+    requires:
+      vars: [FMU_DIR, OUT_DIR, MCL_PATH, SIMBUS]
+`
+	require.NoError(t, os.WriteFile(filepath.Join(checkout, "Taskfile.yml"), []byte(taskfile), 0644))
+	return checkout
+}
+
+func TestGenerateBuildFile_sh(t *testing.T) {
+	checkout := backendCheckout(t)
+	f := generateBuildFile(t, "testdata/ast__model_modelc.yaml", BackendSh, "dse.modelc=file://"+checkout)
+	s := string(f)
+
+	// Global vars, runtime vars are shell variables.
+	assert.Contains(t, s, "#!/bin/sh\n")
+	assert.Contains(t, s, "[ -n \"${PLATFORM_ARCH}\" ] || PLATFORM_ARCH=\"linux-amd64\"\n")
+	assert.Contains(t, s, "[ -n \"${OUTDIR}\" ] || OUTDIR=\"${PWD}/out\"\n")
+	assert.Contains(t, s, "[ -n \"${PROJDIR}\" ] || PROJDIR=\"${PROJDIR:-${PWD}}\"\n")
+	// Dependency ordering.
+	assert.Contains(t, s, "task_stack_default() {\n    task_model_input\n")
+	assert.Contains(t, s, "task_build() {\n    echo \"task: [build]\"\n    task_info\n    task_build_setup_sim\n    task_stack_default\n}")
+	// Vars rendered, per instance.
+	assert.Contains(t, s, "mkdir -p ${SIMDIR}/model/input/data\n")
+	assert.Contains(t, s, "curl --retry 5 $(if [ -n \"${USER}\" ] && [ -n \"${TOKEN}\" ]; then echo \"-u ${USER}:${TOKEN}\"; fi) -fL http://some.server/fileshare/input.csv -o downloads/models/input/input.csv\n")
+	// Template functions of runtime vars are evaluated by the shell.
+	assert.Contains(t, s, " downloads/$(basename \"${PACKAGE_URL}\")\n")
+	// Up-to-date checks (status, sources/generates).
+	assert.Contains(t, s, "if (cd \"${OUTDIR}\" && { test -f downloads/models/input/input.csv && { [ -z \"${SHA256}\" ] || echo \"${SHA256}  downloads/models/input/input.csv\" | sha256sum -c --status -; }; } >/dev/null 2>&1); then\n")
+	assert.Contains(t, s, "[ -z \"$(find \"${PROJDIR}/simulation.yaml\" -newer \"${SIMDIR}/data/simulation.yaml\" 2>/dev/null)\" ]")
+	// Clean retains the build script.
+	assert.Contains(t, s, "! -name build.sh -exec rm -rf {} +")
+	// Entry tasks.
+	assert.Contains(t, s, "    build) task_build ;;\n")
+	assert.Contains(t, s, "    default) task_default ;;\n")
+	assert.NotContains(t, s, "    download-file")
+
+	if _, err := exec.LookPath("sh"); err == nil {
+		buildFile := filepath.Join("out", "tmp", t.Name(), "build.sh")
+		out, err := exec.Command("sh", "-n", buildFile).CombinedOutput()
+		assert.NoError(t, err, string(out))
+		info, err := os.Stat(buildFile)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0755), info.Mode().Perm())
+
+		// VAR=value arguments are set before the (derived) global vars.
+		cmd := exec.Command("sh", "build.sh", "info", "SIMDIR=custom")
+		cmd.Dir = filepath.Dir(buildFile)
+		out, err = cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+		assert.Contains(t, string(out), "SIMDIR             = custom\n")
+		assert.Regexp(t, `CONTAINER_SIMDIR   = .*/out/custom\n`, string(out))
+	}
+}
+
+func TestGenerateBuildFile_make(t *testing.T) {
+	checkout := backendCheckout(t)
+	f := generateBuildFile(t, "testdata/ast__model_modelc.yaml", BackendMake, "dse.modelc=file://"+checkout)
+	s := string(f)
+
+	assert.Contains(t, s, ".ONESHELL:\n")
+	assert.Contains(t, s, ".DEFAULT_GOAL := default\n")
+	assert.Contains(t, s, "[ -n \"$${OUTDIR}\" ] || OUTDIR=\"$${PWD}/out\"\n")
+	// Deps are prerequisites, task calls are nested make calls.
+	assert.Contains(t, s, "\nstack-default: model-input\n")
+	assert.Contains(t, s, "\t$(SDP_MAKE) info\n")
+	assert.Contains(t, s, "\t    mkdir -p $${SIMDIR}/model/input/data\n")
+	assert.Contains(t, s, "\t    exit 0\n")
+	assert.Contains(t, s, "! -name Makefile -exec rm -rf {} +")
+
+	if _, err := exec.LookPath("make"); err == nil {
+		dir := filepath.Join("out", "tmp", t.Name())
+		out, err := exec.Command("make", "-n", "-C", dir, "build").CombinedOutput()
+		assert.NoError(t, err, string(out))
+		assert.Contains(t, string(out), "echo \"task: [build]\"")
+		assert.Contains(t, string(out), "Makefile\" stack-default")
+
+		// VAR=value arguments are kept by the global vars.
+		out, err = exec.Command("make", "-C", dir, "info", "SIMDIR=custom").CombinedOutput()
+		require.NoError(t, err, string(out))
+		assert.Contains(t, string(out), "SIMDIR             = custom\n")
+		assert.Regexp(t, `CONTAINER_SIMDIR   = .*/out/custom\n`, string(out))
+	}
+}
+
+func TestGenerateBuildFile_include(t *testing.T) {
+	checkout := backendCheckout(t)
+	f := generateBuildFile(t, "testdata/ast__model_fmu.yaml", BackendSh, "dse.fmi=file://"+checkout)
+	s := string(f)
+
+	// Task of the included Taskfile, with include and Taskfile vars.
+	assert.Contains(t, s, "\n# dse.fmi-v1.1.20:generate-fmimcl\n")
+	assert.Contains(t, s, "    [ -n \"${_once_dse_fmi_v1_1_20_generate_fmimcl}\" ] && return 0\n")
+	assert.Contains(t, s, "    : \"${SIMBUS:?task dse.fmi-v1.1.20:generate-fmimcl requires var SIMBUS}\"\n")
+	assert.Contains(t, s, "        cd \"${SDP_ROOTDIR}\"\n")
+	assert.Contains(t, s, "with ${IMAGE:-dse-modelc}\"\n")
+	// Heredoc is not indented.
+	assert.Contains(t, s, "\nmcl: model/linear/lib/libfmimcl.so\nEOF\n")
+
+	if _, err := exec.LookPath("sh"); err == nil {
+		out, err := exec.Command("sh", "-n", filepath.Join("out", "tmp", t.Name(), "build.sh")).CombinedOutput()
+		assert.NoError(t, err, string(out))
+	}
+}
+
+const backendRemoteAst = `---
+kind: Simulation
+spec:
+  arch: linux-amd64
+  channels:
+    - name: physical
+  stacks:
+    - name: default
+      models:
+        - name: linear
+          model: dse.fmi.mcl
+          uses: dse.fmi
+          channels:
+            - alias: scalar_vector
+              name: physical
+          workflows:
+            - name: generate-fmimcl
+              vars:
+                - name: FMU_DIR
+                  value: '{{.PATH}}/fmu'
+                - name: OUT_DIR
+                  value: '{{.PATH}}/data'
+                - name: MCL_PATH
+                  value: '{{.PATH}}/lib/libfmimcl.so'
+  uses:
+    - name: dse.fmi
+      url: SERVER/boschglobal/dse.fmi
+      version: v1.1.20
+`
+
+// runBackendRemote generates the build script of an AST with a remote uses
+// entry, the remote Taskfile is vendored, cached (by ast resolve) or missing.
+// The file server has the Taskfile, but must not be requested.
+func runBackendRemote(t *testing.T, local string) error {
+	taskfile, err := os.ReadFile(filepath.Join(backendCheckout(t), "Taskfile.yml"))
+	require.NoError(t, err)
+	server := testutil.NewFileServer(t, map[string]string{"/sdp/dse.fmi/v1.1.20/Taskfile.yml": string(taskfile)}, false)
+	t.Cleanup(func() { assert.Zero(t, server.Requests.Load()) })
+	server.Stage(t, backendRemoteAst)
+	providers, err := provider.LoadRegistry("providers.yaml")
+	require.NoError(t, err)
+	url := providers.TaskfileURLs(server.URL+"/boschglobal/dse.fmi", "v1.1.20")[0]
+	switch local {
+	case "vendor":
+		file, err := artifact.Path(url)
+		require.NoError(t, err)
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join("out/vendor", file)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join("out/vendor", file), taskfile, 0644))
+		index := artifact.Index{Entries: []artifact.Entry{{Url: url, Kind: artifact.KindTaskfile, File: file}}}
+		require.NoError(t, index.Save("out/vendor"))
+	case "cache":
+		metadata, err := cache.Open("out/cache")
+		require.NoError(t, err)
+		require.NoError(t, metadata.Put(url, taskfile, ""))
+	}
+	cmd := NewGenerateCommand("test_generate_backend")
+	require.NoError(t, cmd.Parse([]string{"-taskfile", "-backend", BackendSh, "-input", "ast.yaml", "-output", ".", "-providers", "providers.yaml"}))
+	return cmd.Run()
+}
+
+func TestGenerateBuildFile_include_vendored(t *testing.T) {
+	require.NoError(t, runBackendRemote(t, "vendor"))
+	f, err := os.ReadFile("out/build.sh")
+	require.NoError(t, err)
+	assert.Contains(t, string(f), "\n# dse.fmi-v1.1.20:generate-fmimcl\n")
+}
+
+func TestGenerateBuildFile_include_cached(t *testing.T) {
+	require.NoError(t, runBackendRemote(t, "cache"))
+	f, err := os.ReadFile("out/build.sh")
+	require.NoError(t, err)
+	assert.Contains(t, string(f), "\n# dse.fmi-v1.1.20:generate-fmimcl\n")
+}
+
+func TestGenerateBuildFile_include_missing(t *testing.T) {
+	// Generate fails early, the Taskfile is not fetched.
+	err := runBackendRemote(t, "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "remote Taskfile not available for uses dse.fmi")
+	assert.Contains(t, err.Error(), "run 'ast resolve' or 'ast vendor' first")
+	assert.NoFileExists(t, "out/build.sh")
+}
+
+func TestGenerateBuildFile_include_remote(t *testing.T) {
+	// A remote include of an included Taskfile is not fetched either.
+	checkout := backendCheckout(t)
+	data, err := os.ReadFile(filepath.Join(checkout, "Taskfile.yml"))
+	require.NoError(t, err)
+	server := testutil.NewFileServer(t, map[string]string{"/common.yml": "version: '3'\n"}, false)
+	taskfile := strings.Replace(string(data), "vars:", "includes:\n  common: "+server.URL+"/common.yml\nvars:", 1)
+	require.NoError(t, os.WriteFile(filepath.Join(checkout, "Taskfile.yml"), []byte(taskfile), 0644))
+
+	astFile := filepath.Join("out", "tmp", t.Name(), "ast.yaml")
+	require.NoError(t, os.MkdirAll(filepath.Dir(astFile), 0755))
+	data, err = os.ReadFile("testdata/ast__model_fmu.yaml")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(astFile, data, 0644))
+	cmd := NewGenerateCommand("test_generate_backend")
+	require.NoError(t, cmd.Parse([]string{"-taskfile", "-backend", BackendSh, "-input", filepath.Join("tmp", t.Name(), "ast.yaml"),
+		"-output", filepath.Join("tmp", t.Name()), "-override", "dse.fmi=file://" + checkout}))
+	err = cmd.Run()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "include dse.fmi-v1.1.20:common: remote Taskfile not available")
+	assert.Zero(t, server.Requests.Load())
+}
+
+func TestRenderTemplate(t *testing.T) {
+	scope := map[string]string{"SIMDIR": "sim", "PATH": "model/input"}
+	tests := []struct {
+		text   string
+		expect string
+	}{
+		{text: "{{.SIMDIR}}/{{.PATH}}", expect: "sim/model/input"},
+		{text: "{{.OUTDIR}}/{{.SIMDIR}}", expect: "${OUTDIR}/sim"},
+		{text: "{{if .PROJDIR}}{{.PROJDIR}}{{else}}{{.PWD}}{{end}}", expect: "${PROJDIR:-${PWD}}"},
+		{text: "{{if .TOKEN}}-u {{.TOKEN}}{{end}}", expect: "${TOKEN:+-u ${TOKEN}}"},
+		{text: "{{if .A}}a{{else}}b{{end}}", expect: "$(if [ -n \"${A}\" ]; then echo \"a\"; else echo \"b\"; fi)"},
+		{text: "{{if .SIMDIR}}yes{{end}}", expect: "yes"},
+		{text: "downloads/{{base .URL}}", expect: "downloads/$(basename \"${URL}\")"},
+		{text: "{{ext \"file.zip\"}}", expect: ".zip"},
+		{text: "{{.IMAGE | default \"dse\"}}", expect: "${IMAGE:-dse}"},
+		{text: "{{default \"dse\" .IMAGE}}", expect: "${IMAGE:-dse}"},
+		{text: "{{.SIMDIR | default \"dse\"}}", expect: "sim"},
+		{text: "{{if all .USER .TOKEN}}-u {{.USER}}{{end}}", expect: "$(if [ -n \"${USER}\" ] && [ -n \"${TOKEN}\" ]; then echo \"-u ${USER}\"; fi)"},
+		{text: "{{if any .SIMDIR .A}}a{{else}}b{{end}}", expect: "$(if [ -n \"sim\" ] || [ -n \"${A}\" ]; then echo \"a\"; else echo \"b\"; fi)"},
+		{text: "{{.URL | dir}}", expect: "$(dirname \"${URL}\")"},
+		{text: "{{base .PATH}}", expect: "input"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.text, func(t *testing.T) {
+			s, err := renderTemplate(tc.text, scope)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expect, s)
+		})
+	}
+}
+
+func TestRenderTemplate_shell(t *testing.T) {
+	// Templates on runtime vars are evaluated by the shell, the result must
+	// match the template evaluated with the same values.
+	templates := []string{
+		"{{if .A}}-u {{.A}}{{end}}",
+		"{{if .A}}{{.A}}{{else}}{{.B}}{{end}}",
+		"{{if .A}}a{{else}}b{{end}}",
+		"{{if .A}}a {{.B}}{{else}}b{{end}}",
+		"{{if all .A .B}}-u {{.A}}:{{.B}}{{end}}",
+		"{{if any .A .B}}a{{else}}b{{end}}",
+		"{{if all .A .C}}a{{else}}b{{end}}",
+		"{{if any .A .C}}a{{end}}",
+		"{{.A | default \"dse\"}}",
+		"{{default \"dse\" .A}}",
+		"x/{{base .A}}",
+		"{{dir .A}}/y",
+		"{{ext .A}}",
+		"{{.A | trim}}",
+		"{{lower .A}}",
+		"{{.A | upper}}",
+		"{{.A}}-{{.C}}",
+	}
+	values := []map[string]string{
+		{},
+		{"A": ""},
+		{"A": "path/to/File.ZIP"},
+		{"A": "Path/To/file.tar.gz", "B": "b"},
+		{"A": "  Value ", "B": ""},
+		{"B": "b"},
+	}
+	scope := map[string]string{"C": "c"}
+	for _, text := range templates {
+		rendered, err := renderTemplate(text, scope)
+		require.NoError(t, err, text)
+		tmpl, err := template.New("").Funcs(templateFuncs).Parse(text)
+		require.NoError(t, err, text)
+		for _, env := range values {
+			t.Run(text+"/"+fmt.Sprint(env), func(t *testing.T) {
+				if strings.Contains(text, "base") && env["A"] == "" {
+					t.Skip("basename of an empty path is empty, path.Base returns \".\"")
+				}
+				data := map[string]string{"A": "", "B": "", "C": scope["C"]}
+				cmd := exec.Command("sh", "-c", "printf '%s' \""+rendered+"\"")
+				cmd.Env = []string{"PATH=" + os.Getenv("PATH")}
+				for k, v := range env {
+					data[k] = v
+					cmd.Env = append(cmd.Env, k+"="+v)
+				}
+				var expect bytes.Buffer
+				require.NoError(t, tmpl.Execute(&expect, data))
+				out, err := cmd.CombinedOutput()
+				require.NoError(t, err, string(out))
+				assert.Equal(t, expect.String(), string(out), rendered)
+			})
+		}
+	}
+}
+
+func TestRenderTemplate_unsupported(t *testing.T) {
+	scope := map[string]string{"SIMDIR": "sim"}
+	tests := []struct {
+		text   string
+		expect string
+	}{
+		{text: "{{replace \"a\" \"b\" .URL}}", expect: "var URL is set when the build runs"},
+		{text: "{{$.URL}}", expect: "map has no entry for key \"URL\""},
+	}
+	for _, tc := range tests {
+		t.Run(tc.text, func(t *testing.T) {
+			_, err := renderTemplate(tc.text, scope)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.expect)
+		})
+	}
+}
@@ -0,0 +1,234 @@
+// Copyright 2025 Robert Bosch GmbH
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package generate
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/boschglobal/dse.schemas/code/go/dse/ast"
+
+	"github.com/boschglobal/dse.sdp/ast/internal/pkg/source"
+)
+
+const (
+	composeSimerImage = "${SIMER_IMAGE:-ghcr.io/boschglobal/dse-simer:latest}"
+	composeRedisImage = "${REDIS_IMAGE:-redis:7-alpine}"
+	composeSimVolume  = "./sim:/sim"
+)
+
+type ComposeService struct {
+	Image       string            `yaml:"image"`
+	Platform    string            `yaml:"platform,omitempty"`
+	Profiles    []string          `yaml:"profiles,omitempty"`
+	Command     []string          `yaml:"command,omitempty"`
+	Environment map[string]string `yaml:"environment,omitempty"`
+	Labels      map[string]string `yaml:"labels,omitempty"`
+	Ports       []string          `yaml:"ports,omitempty"`
+	Volumes     []string          `yaml:"volumes,omitempty"`
+	DependsOn   []string          `yaml:"depends_on,omitempty"`
+}
+
+type Compose struct {
+	Name     string                    `yaml:"name,omitempty"`
+	Services map[string]ComposeService `yaml:"services"`
+}
+
+// ArchPlatforms are the supported archs and their container platform, 32-bit
+// architectures run on the 64-bit platform. Windows stacks have no platform.
+var ArchPlatforms = map[string]string{
+	"linux-amd64":   "linux/amd64",
+	"linux-aarch64": "linux/arm64",
+	"linux-x86":     "linux/amd64",
+	"linux-i386":    "linux/amd64",
+	"windows-x64":   "",
+	"windows-x86":   "",
+}
+
+// composePlatform maps the arch of a stack to a container platform.
+func composePlatform(arch string) string {
+	return ArchPlatforms[arch]
+}
+
+// isLocalHost returns true for connections to a SimBus (Redis) on the local
+// host, which are replaced by the Redis service of the compose file.
+func isLocalHost(u *url.URL) bool {
+	switch u.Hostname() {
+	case "localhost", "127.0.0.1", "::1":
+		return true
+	}
+	return false
+}
+
+func composeServiceName(name string) string {
+	return strings.ToLower(strings.NewReplacer("_", "-", ".", "-").Replace(name))
+}
+
+// GenerateCompose writes a docker-compose.yaml which runs the simulation with
+// a Redis service (SimBus transport) and a Simer service for each stack. The
+// simulation (out/sim) is mounted into each Simer container, the compose file
+// is written next to the Taskfile (i.e. the volume path is ./sim).
+func (c *GenerateCommand) GenerateCompose() error {
+	var composePath = filepath.Join(c.outputPath, "docker-compose.yaml")
+	fmt.Fprintf(flag.CommandLine.Output(), "Writing compose: %s\n", composePath)
+
+	connections, err := c.stackConnections()
+	if err != nil {
+		return err
+	}
+	compose, comments, err := buildCompose(c.simulationAst, connections)
+	if err != nil {
+		return err
+	}
+	var doc yaml.Node
+	if err := doc.Encode(compose); err != nil {
+		return fmt.Errorf("failed to encode compose file: %v", err)
+	}
+	// Document the placeholder services (comment on the service key).
+	for i := 0; i+1 < len(doc.Content); i += 2 {
+		if doc.Content[i].Value != "services" {
+			continue
+		}
+		services := doc.Content[i+1]
+		for j := 0; j+1 < len(services.Content); j += 2 {
+			if comment, ok := comments[services.Content[j].Value]; ok {
+				services.Content[j].HeadComment = comment
+			}
+		}
+	}
+	var b bytes.Buffer
+	b.WriteString("# Generated by ast generate -compose.\n---\n")
+	encoder := yaml.NewEncoder(&b)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return fmt.Errorf("failed to encode compose file: %v", err)
+	}
+	encoder.Close()
+	os.MkdirAll(filepath.Dir(composePath), os.ModePerm)
+	return os.WriteFile(composePath, b.Bytes(), 0644)
+}
+
+// buildCompose returns the compose file and the comments of the placeholder
+// services (by service name). Stacks connecting to a local SimBus use the Redis
+// service, other hosts are used as is.
+func buildCompose(simSpec ast.SimulationSpec, connections map[string]Connection) (*Compose, map[string]string, error) {
+	if len(simSpec.Stacks) == 0 {
+		return nil, nil, fmt.Errorf("no stacks in simulation")
+	}
+	compose := &Compose{Services: map[string]ComposeService{}}
+	comments := map[string]string{}
+
+	var redisPort string
+	for _, astStack := range simSpec.Stacks {
+		arch := simSpec.Arch
+		if astStack.Arch != nil {
+			arch = *astStack.Arch
+		}
+		conn := connections[astStack.Name]
+		if conn.Transport == TransportMq {
+			return nil, nil, fmt.Errorf("%sstack '%s': transport '%s' not supported by compose", source.Prefix(astStack.Annotations), astStack.Name, conn.Transport)
+		}
+		uri, err := url.Parse(conn.Uri)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%sstack '%s': invalid connection uri: %v", source.Prefix(astStack.Annotations), astStack.Name, err)
+		}
+		var dependsOn []string
+		if isLocalHost(uri) {
+			// Redis, matching the port of the stack connection. Within the
+			// compose network the Redis service is located by its name.
+			port := uri.Port()
+			if port == "" {
+				port = "6379"
+			}
+			if redisPort != "" && redisPort != port {
+				return nil, nil, fmt.Errorf("%sstack '%s': connection port %s conflicts with port %s of the redis service", source.Prefix(astStack.Annotations), astStack.Name, port, redisPort)
+			}
+			redisPort = port
+			uri.Host = "redis:" + port
+			dependsOn = []string{"redis"}
+		}
+		simbusUri := uri.String()
+
+		name := "simer-" + composeServiceName(astStack.Name)
+		command := []string{"-stack", astStack.Name, "-transport", conn.Transport, "-uri", simbusUri}
+		if simSpec.Stepsize != nil {
+			command = append(command, "-stepsize", fmt.Sprint(*simSpec.Stepsize))
+		}
+		if simSpec.Endtime != nil {
+			command = append(command, "-endtime", fmt.Sprint(*simSpec.Endtime))
+		}
+		service := ComposeService{
+			Image:    composeSimerImage,
+			Platform: composePlatform(arch),
+			Command:  command,
+			Labels: map[string]string{
+				"sdp.stack": astStack.Name,
+				"sdp.arch":  arch,
+			},
+			Volumes:   []string{composeSimVolume},
+			DependsOn: dependsOn,
+		}
+		if astStack.Env != nil && len(*astStack.Env) > 0 {
+			service.Environment = map[string]string{}
+			for _, v := range *astStack.Env {
+				service.Environment[v.Name] = v.Value
+			}
+		}
+		if strings.HasPrefix(arch, "windows-") {
+			service.Profiles = []string{"windows"}
+			comments[name] = fmt.Sprintf("Stack '%s' (%s) runs with Simer on a Windows host, the service is\nonly started with the 'windows' profile.", astStack.Name, arch)
+		}
+		compose.Services[name] = service
+
+		// External models, placeholder service.
+		for _, model := range astStack.Models {
+			if model.External == nil || !*model.External {
+				continue
+			}
+			name := "external-" + composeServiceName(model.Name)
+			compose.Services[name] = ComposeService{
+				Image:    "${EXTERNAL_MODEL_IMAGE:-busybox:latest}",
+				Profiles: []string{"external"},
+				Command:  []string{"sh", "-c", fmt.Sprintf("echo 'Placeholder for external model %s, replace this service.' && sleep infinity", model.Name)},
+				Environment: map[string]string{
+					"SIMBUS_TRANSPORT": conn.Transport,
+					"SIMBUS_URI":       simbusUri,
+					"MODEL_INSTANCE":   model.Name,
+					"SIM_PATH":         "/sim",
+				},
+				Labels: map[string]string{
+					"sdp.stack": astStack.Name,
+					"sdp.model": model.Name,
+				},
+				Volumes:   []string{composeSimVolume},
+				DependsOn: dependsOn,
+			}
+			comments[name] = fmt.Sprintf(
+				"Placeholder for the external model '%s' (stack '%s'). Replace the image and\n"+
+					"command with the external model (or gateway), which connects to the SimBus\n"+
+					"with SIMBUS_URI. Started with the 'external' profile:\n"+
+					"  docker compose --profile external up", model.Name, astStack.Name)
+		}
+	}
+
+	if redisPort != "" {
+		redis := ComposeService{
+			Image: composeRedisImage,
+			Ports: []string{fmt.Sprintf("%s:%s", redisPort, redisPort)},
+		}
+		if redisPort != "6379" {
+			redis.Command = []string{"redis-server", "--port", redisPort}
+		}
+		compose.Services["redis"] = redis
+	}
+	return compose, comments, nil
+}
@@ -0,0 +1,84 @@
+// Copyright 2025 Robert Bosch GmbH
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package generate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/boschglobal/dse.schemas/code/go/dse/ast"
+)
+
+func TestGenerateCompose(t *testing.T) {
+	input := "testdata/ast__compose.yaml"
+	data, err := os.ReadFile(input)
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Join("out", "testdata"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join("out", input), data, 0644))
+	outFolder := filepath.Join("tmp", t.Name())
+	require.NoError(t, os.RemoveAll(filepath.Join("out", outFolder)))
+
+	cmd := NewGenerateCommand("test_generate_compose")
+	require.NoError(t, cmd.Parse([]string{"-simulation", "-compose", "-input", input, "-output", outFolder}))
+	require.NoError(t, cmd.Run())
+	assert.FileExists(t, filepath.Join("out", outFolder, "simulation.yaml"))
+	assert.NoFileExists(t, filepath.Join("out", outFolder, "Taskfile.yml"))
+	f, err := os.ReadFile(filepath.Join("out", outFolder, "docker-compose.yaml"))
+	require.NoError(t, err)
+	t.Logf("\n%s\n", f)
+
+	// Redis, matching the stack connection (redis://localhost:6379).
+	YamlContains(t, f, "$.services.redis.image", "${REDIS_IMAGE:-redis:7-alpine}")
+	YamlContains(t, f, "$.services.redis.ports[0]", "6379:6379")
+
+	// Simer service for each stack.
+	YamlContains(t, f, "$.services.simer-default.image", "${SIMER_IMAGE:-ghcr.io/boschglobal/dse-simer:latest}")
+	YamlContains(t, f, "$.services.simer-default.platform", "linux/amd64")
+	YamlContains(t, f, "$.services.simer-default.command[1]", "default")
+	YamlContains(t, f, "$.services.simer-default.command[5]", "redis://redis:6379")
+	YamlContains(t, f, "$.services.simer-default.command[7]", "0.0005")
+	YamlContains(t, f, "$.services.simer-default.command[9]", "0.04")
+	YamlContains(t, f, "$.services.simer-default.environment.SIMBUS_LOGLEVEL", "3")
+	YamlContains(t, f, "$.services.simer-default.volumes[0]", "./sim:/sim")
+	YamlContains(t, f, "$.services.simer-default.depends_on[0]", "redis")
+	YamlContains(t, f, "$.services.simer-default.labels.'sdp.stack'", "default")
+	YamlContains(t, f, "$.services.simer-arm-stack.platform", "linux/arm64")
+	YamlContains(t, f, "$.services.simer-arm-stack.command[1]", "arm_stack")
+	YamlContains(t, f, "$.services.simer-win.profiles[0]", "windows")
+	YamlContains(t, f, "$.services.simer-win.labels.'sdp.arch'", "windows-x64")
+
+	// External model placeholder.
+	YamlContains(t, f, "$.services.external-gateway.profiles[0]", "external")
+	YamlContains(t, f, "$.services.external-gateway.environment.SIMBUS_URI", "redis://redis:6379")
+	YamlContains(t, f, "$.services.external-gateway.environment.MODEL_INSTANCE", "gateway")
+	assert.Contains(t, string(f), "# Placeholder for the external model 'gateway' (stack 'default').")
+}
+
+func TestBuildCompose_connection(t *testing.T) {
+	simSpec := ast.SimulationSpec{
+		Arch:   "linux-amd64",
+		Stacks: []ast.Stack{{Name: "remote"}, {Name: "local"}},
+	}
+	connections := map[string]Connection{
+		"remote": {Transport: "redispubsub", Uri: "redis://simbus.example.com:6380"},
+		"local":  {Transport: "redis", Uri: "redis://localhost:6380"},
+	}
+	compose, _, err := buildCompose(simSpec, connections)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"-stack", "remote", "-transport", "redispubsub", "-uri", "redis://simbus.example.com:6380"}, compose.Services["simer-remote"].Command)
+	assert.Nil(t, compose.Services["simer-remote"].DependsOn)
+	assert.Equal(t, []string{"-stack", "local", "-transport", "redis", "-uri", "redis://redis:6380"}, compose.Services["simer-local"].Command)
+	assert.Equal(t, []string{"redis"}, compose.Services["simer-local"].DependsOn)
+	assert.Equal(t, []string{"6380:6380"}, compose.Services["redis"].Ports)
+	assert.Equal(t, []string{"redis-server", "--port", "6380"}, compose.Services["redis"].Command)
+
+	connections["remote"] = Connection{Transport: "mq", Uri: "mq:///simbus"}
+	_, _, err = buildCompose(simSpec, connections)
+	assert.ErrorContains(t, err, "stack 'remote': transport 'mq' not supported by compose")
+}
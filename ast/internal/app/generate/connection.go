@@ -0,0 +1,176 @@
+// Copyright 2025 Robert Bosch GmbH
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package generate
+
+import (
+	"flag"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/boschglobal/dse.clib/extra/go/command/util"
+	"github.com/boschglobal/dse.schemas/code/go/dse/ast"
+	"github.com/boschglobal/dse.schemas/code/go/dse/kind"
+
+	"github.com/boschglobal/dse.sdp/ast/internal/pkg/source"
+)
+
+// Connection annotations, set on the Simulation (metadata.annotations) or on
+// a Stack. Annotations may also be nested (i.e. connection: {transport: mq}).
+const (
+	AnnotationConnectionTransport = "connection.transport"
+	AnnotationConnectionUri       = "connection.uri"
+	AnnotationConnectionTimeout   = "connection.timeout"
+)
+
+const (
+	TransportRedis       = "redis"
+	TransportRedisPubSub = "redispubsub"
+	TransportMq          = "mq"
+)
+
+const (
+	defaultConnectionUri     = "redis://localhost:6379"
+	defaultConnectionTimeout = 60
+)
+
+// Connection is the SimBus connection of a Stack.
+type Connection struct {
+	Transport string
+	Uri       string
+	Timeout   *int
+}
+
+// connectionAnnotation returns the value of a connection annotation, either
+// the dotted key (connection.uri) or the nested form (connection: {uri: ...}).
+func connectionAnnotation(annotations map[string]interface{}, key string) (string, bool) {
+	if annotations == nil {
+		return "", false
+	}
+	v, ok := annotations[key]
+	if !ok {
+		parent, child, _ := strings.Cut(key, ".")
+		nested, isMap := annotations[parent].(map[string]interface{})
+		if !isMap {
+			return "", false
+		}
+		if v, ok = nested[child]; !ok {
+			return "", false
+		}
+	}
+	return strings.TrimSpace(fmt.Sprint(v)), true
+}
+
+// StackConnection calculates the connection of a stack from the simulation
+// annotations and the stack annotations (which take precedence). Unsupported
+// settings are returned as issues.
+func StackConnection(simAnnotations map[string]interface{}, stack ast.Stack) (Connection, []string) {
+	var stackAnnotations map[string]interface{}
+	if stack.Annotations != nil {
+		stackAnnotations = annotationValue(*stack.Annotations).(map[string]interface{})
+	}
+	setting := func(key string) (string, bool) {
+		if v, ok := connectionAnnotation(stackAnnotations, key); ok {
+			return v, true
+		}
+		return connectionAnnotation(simAnnotations, key)
+	}
+
+	issues := []string{}
+	conn := Connection{Transport: TransportRedis}
+	if transport, ok := setting(AnnotationConnectionTransport); ok {
+		conn.Transport = transport
+	}
+	switch conn.Transport {
+	case TransportRedis, TransportRedisPubSub:
+		conn.Uri = defaultConnectionUri
+		timeout := defaultConnectionTimeout
+		conn.Timeout = &timeout
+	case TransportMq:
+	default:
+		issues = append(issues, fmt.Sprintf("unsupported transport '%s' (supported: redis, redispubsub, mq)", conn.Transport))
+		return conn, issues
+	}
+
+	if uri, ok := setting(AnnotationConnectionUri); ok {
+		conn.Uri = uri
+		if u, err := url.Parse(uri); err != nil || u.Scheme == "" {
+			issues = append(issues, fmt.Sprintf("invalid connection uri '%s'", uri))
+		} else if conn.Transport == TransportMq && u.Scheme == "redis" {
+			issues = append(issues, fmt.Sprintf("transport '%s' does not support uri '%s'", conn.Transport, uri))
+		} else if conn.Transport != TransportMq && u.Scheme != "redis" {
+			issues = append(issues, fmt.Sprintf("transport '%s' does not support uri '%s' (redis:// expected)", conn.Transport, uri))
+		}
+	}
+	if timeout, ok := setting(AnnotationConnectionTimeout); ok {
+		t, err := strconv.Atoi(timeout)
+		switch {
+		case conn.Transport == TransportMq:
+			issues = append(issues, fmt.Sprintf("transport '%s' does not support a connection timeout", conn.Transport))
+		case err != nil || t <= 0:
+			issues = append(issues, fmt.Sprintf("invalid connection timeout '%s' (seconds expected)", timeout))
+		default:
+			conn.Timeout = &t
+		}
+	}
+	return conn, issues
+}
+
+// configureConnection sets the connection (transport) of the generated stack.
+func configureConnection(stack *kind.Stack, conn Connection) {
+	var uri *string
+	if conn.Uri != "" {
+		uri = util.StringPtr(conn.Uri)
+	}
+	transport := kind.StackSpec_Connection_Transport{}
+	switch conn.Transport {
+	case TransportRedisPubSub:
+		transport.FromStackSpecConnectionTransport1(kind.StackSpecConnectionTransport1{
+			Redispubsub: kind.RedisConnection{Timeout: conn.Timeout, Uri: uri},
+		})
+	case TransportMq:
+		transport.FromStackSpecConnectionTransport2(kind.StackSpecConnectionTransport2{
+			Mq: kind.MessageQueue{Uri: uri},
+		})
+	default:
+		transport.FromStackSpecConnectionTransport0(kind.StackSpecConnectionTransport0{
+			Redis: kind.RedisConnection{Timeout: conn.Timeout, Uri: uri},
+		})
+	}
+	connection := struct {
+		Timeout   *string                              `yaml:"timeout,omitempty"`
+		Transport *kind.StackSpec_Connection_Transport `yaml:"transport,omitempty"`
+	}{
+		Transport: &transport,
+	}
+	stack.Spec.Connection = &connection
+}
+
+func (c *GenerateCommand) simulationAnnotations() map[string]interface{} {
+	if c.simulationDoc == nil {
+		return nil
+	}
+	return c.simulationDoc.Metadata.Annotations
+}
+
+// stackConnections calculates the connection of each stack (by name), any
+// unsupported settings are reported.
+func (c *GenerateCommand) stackConnections() (map[string]Connection, error) {
+	connections := map[string]Connection{}
+	var count int
+	for _, stack := range c.simulationAst.Stacks {
+		conn, issues := StackConnection(c.simulationAnnotations(), stack)
+		for _, issue := range issues {
+			fmt.Fprintf(flag.CommandLine.Output(), "%sstack '%s': %s\n", source.Prefix(stack.Annotations), stack.Name, issue)
+		}
+		count += len(issues)
+		connections[stack.Name] = conn
+	}
+	if count > 0 {
+		return nil, fmt.Errorf("connection configuration failed: %d issue(s) found", count)
+	}
+	return connections, nil
+}
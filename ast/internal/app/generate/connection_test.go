@@ -0,0 +1,138 @@
+// Copyright 2025 Robert Bosch GmbH
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package generate
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/boschglobal/dse.schemas/code/go/dse/ast"
+)
+
+func TestStackConnection(t *testing.T) {
+	tests := []struct {
+		name   string
+		sim    map[string]interface{}
+		stack  ast.Annotations
+		want   Connection
+		issues []string
+	}{
+		{
+			name: "default",
+			want: Connection{Transport: "redis", Uri: "redis://localhost:6379", Timeout: intPtr(60)},
+		},
+		{
+			name: "simulation",
+			sim: map[string]interface{}{
+				"connection": map[string]interface{}{"transport": "redispubsub", "timeout": 5},
+			},
+			want: Connection{Transport: "redispubsub", Uri: "redis://localhost:6379", Timeout: intPtr(5)},
+		},
+		{
+			name: "stack overrides simulation",
+			sim: map[string]interface{}{
+				"connection.transport": "redispubsub",
+				"connection.uri":       "redis://simbus:6379",
+			},
+			stack: ast.Annotations{"connection.transport": "redis", "connection.timeout": "10"},
+			want:  Connection{Transport: "redis", Uri: "redis://simbus:6379", Timeout: intPtr(10)},
+		},
+		{
+			name:  "mq",
+			stack: ast.Annotations{"connection.transport": "mq", "connection.uri": "mq:///simbus"},
+			want:  Connection{Transport: "mq", Uri: "mq:///simbus"},
+		},
+		{
+			name:   "unsupported transport",
+			stack:  ast.Annotations{"connection.transport": "zmq"},
+			want:   Connection{Transport: "zmq"},
+			issues: []string{"unsupported transport 'zmq' (supported: redis, redispubsub, mq)"},
+		},
+		{
+			name:  "unsupported combinations",
+			sim:   map[string]interface{}{"connection.uri": "redis://localhost:6379", "connection.timeout": 60},
+			stack: ast.Annotations{"connection.transport": "mq"},
+			want:  Connection{Transport: "mq", Uri: "redis://localhost:6379"},
+			issues: []string{
+				"transport 'mq' does not support uri 'redis://localhost:6379'",
+				"transport 'mq' does not support a connection timeout",
+			},
+		},
+		{
+			name:  "invalid settings",
+			stack: ast.Annotations{"connection.uri": "tcp://localhost:1234", "connection.timeout": "1m"},
+			want:  Connection{Transport: "redis", Uri: "tcp://localhost:1234", Timeout: intPtr(60)},
+			issues: []string{
+				"transport 'redis' does not support uri 'tcp://localhost:1234' (redis:// expected)",
+				"invalid connection timeout '1m' (seconds expected)",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stack := ast.Stack{Name: "stack"}
+			if tt.stack != nil {
+				stack.Annotations = &tt.stack
+			}
+			conn, issues := StackConnection(tt.sim, stack)
+			assert.Equal(t, tt.want, conn)
+			if tt.issues == nil {
+				assert.Empty(t, issues)
+			} else {
+				assert.Equal(t, tt.issues, issues)
+			}
+		})
+	}
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func TestGenerateSimulation_connection(t *testing.T) {
+	simulationPath := generateSimulation(t, "testdata/ast__connection.yaml")
+	f, err := os.ReadFile(simulationPath)
+	require.NoError(t, err)
+	t.Logf("\n%s\n", f)
+
+	connections := map[string]interface{}{}
+	decoder := yaml.NewDecoder(bytes.NewReader(f))
+	for {
+		var doc map[string]interface{}
+		if err := decoder.Decode(&doc); errors.Is(err, io.EOF) {
+			break
+		} else {
+			require.NoError(t, err)
+		}
+		if doc["kind"] != "Stack" {
+			continue
+		}
+		name := doc["metadata"].(map[string]interface{})["name"].(string)
+		connections[name] = doc["spec"].(map[string]interface{})["connection"]
+	}
+
+	assert.Equal(t, map[string]interface{}{
+		"transport": map[string]interface{}{
+			"redispubsub": map[string]interface{}{"uri": "redis://simbus.example.com:6380", "timeout": 60},
+		},
+	}, connections["default"])
+	assert.Equal(t, map[string]interface{}{
+		"transport": map[string]interface{}{
+			"redis": map[string]interface{}{"uri": "redis://localhost:6379", "timeout": 10},
+		},
+	}, connections["local"])
+	assert.Equal(t, map[string]interface{}{
+		"transport": map[string]interface{}{
+			"mq": map[string]interface{}{"uri": "mq:///simbus"},
+		},
+	}, connections["queue"])
+}
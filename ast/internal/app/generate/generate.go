@@ -8,7 +8,12 @@ import (
 	"flag"
 	"fmt"
 	"log/slog"
+	"maps"
+	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 
 	"github.com/boschglobal/dse.clib/extra/go/command"
 	"github.com/boschglobal/dse.clib/extra/go/command/log"
@@ -17,6 +22,11 @@ import (
 	"github.com/boschglobal/dse.clib/extra/go/file/handler/kind"
 
 	"github.com/boschglobal/dse.schemas/code/go/dse/ast"
+
+	"github.com/boschglobal/dse.sdp/ast/internal/pkg/artifact"
+	"github.com/boschglobal/dse.sdp/ast/internal/pkg/override"
+	"github.com/boschglobal/dse.sdp/ast/internal/pkg/provider"
+	"github.com/boschglobal/dse.sdp/ast/internal/pkg/vars"
 )
 
 type GenerateCommand struct {
@@ -26,12 +36,26 @@ type GenerateCommand struct {
 	outputPath     string
 	genTaskfile    bool
 	genSimulation  bool
+	genCompose     bool
+	backend        string
 	overwriteFiles bool
+	strict         bool
 	dseScriptPath  string
+	providerFile   string
+	overrideFile   string
+	overrideFlags  override.Flag
+	vendor         bool
+	vendorDir      string
+	cacheDir       string
 	logLevel       int
 
+	outDir string // Output folder of the build, relative to the project.
+
 	simulationAst ast.SimulationSpec
 	simulationDoc *kind.KindDoc
+	providers     *provider.Registry
+	overrides     override.Overrides
+	vendorIndex   *artifact.Index
 }
 
 func NewGenerateCommand(name string) *GenerateCommand {
@@ -45,8 +69,17 @@ func NewGenerateCommand(name string) *GenerateCommand {
 	c.FlagSet().StringVar(&c.outputPath, "output", "", "path to write generated files (Simer layout)")
 	c.FlagSet().BoolVar(&c.genTaskfile, "taskfile", false, "Generate a Taskfile (only)")
 	c.FlagSet().BoolVar(&c.genSimulation, "simulation", false, "Generate a Simulation (only)")
+	c.FlagSet().BoolVar(&c.genCompose, "compose", false, "Generate a docker-compose.yaml (in addition)")
+	c.FlagSet().StringVar(&c.backend, "backend", BackendTaskfile, "build backend: taskfile, make or sh")
 	c.FlagSet().BoolVar(&c.overwriteFiles, "overwrite", false, "Overwrite existing embedded files")
+	c.FlagSet().BoolVar(&c.strict, "strict", false, "fail on undefined or cyclic variable references")
 	c.FlagSet().StringVar(&c.dseScriptPath, "script", "", "Path to DSE Script file (txtar expansion)")
+	c.FlagSet().StringVar(&c.providerFile, "providers", "", "path to metadata provider config file")
+	c.FlagSet().Var(&c.overrideFlags, "override", "override a uses entry with a local directory or file: name=file:///path (repeatable)")
+	c.FlagSet().StringVar(&c.overrideFile, "override-file", "", "path to override file (default sdp.override.yaml, if present)")
+	c.FlagSet().BoolVar(&c.vendor, "vendor", false, "use vendored Taskfiles and downloads (see ast vendor), no network access")
+	c.FlagSet().StringVar(&c.vendorDir, "vendor-dir", "vendor", "path to vendor dir")
+	c.FlagSet().StringVar(&c.cacheDir, "cache-dir", "cache", "path to the metadata cache dir (see ast resolve)")
 	c.FlagSet().IntVar(&c.logLevel, "log", 4, "Loglevel")
 	return c
 }
@@ -72,12 +105,86 @@ func (c *GenerateCommand) Run() error {
 	c.inputFile = inputPath
 	outputPath := filepath.Join(outDir, c.outputPath)
 	c.outputPath = outputPath
+	c.setLocalDirs(outDir)
+
+	if _, ok := backendFiles[c.backend]; !ok {
+		return fmt.Errorf("unsupported backend: %s (supported: taskfile, make, sh)", c.backend)
+	}
 
 	fmt.Fprintf(flag.CommandLine.Output(), "Reading file: %s\n", c.inputFile)
 	err = c.loadAst(c.inputFile)
 	if err != nil {
 		return err
 	}
+	return c.generate()
+}
+
+// GenerateAst generates the simulation of an already loaded (and possibly
+// modified) AST into the output path (used as is, i.e. not prefixed with the
+// out folder). A relative output path is also the output folder of the build
+// (OUTDIR), so that the build uses the generated simulation.yaml. The options
+// of the command (backend, overrides, etc.) apply.
+func (c *GenerateCommand) GenerateAst(spec ast.SimulationSpec, doc *kind.KindDoc, outputPath string) error {
+	if _, ok := backendFiles[c.backend]; !ok {
+		return fmt.Errorf("unsupported backend: %s (supported: taskfile, make, sh)", c.backend)
+	}
+	c.simulationAst = spec
+	c.simulationDoc = doc
+	c.outputPath = outputPath
+	c.setLocalDirs("out")
+	if !filepath.IsAbs(outputPath) {
+		c.outDir = filepath.ToSlash(filepath.Clean(outputPath))
+	}
+	if err := os.MkdirAll(c.outputPath, os.ModePerm); err != nil {
+		return err
+	}
+	return c.generate()
+}
+
+// setLocalDirs sets the (relative) vendor and cache dirs in the out folder.
+func (c *GenerateCommand) setLocalDirs(outDir string) {
+	if !filepath.IsAbs(c.vendorDir) {
+		c.vendorDir = filepath.Join(outDir, c.vendorDir)
+	}
+	if !filepath.IsAbs(c.cacheDir) {
+		c.cacheDir = filepath.Join(outDir, c.cacheDir)
+	}
+}
+
+// prepare applies the overrides to the Simulation AST, expands the model
+// replicas and loads the metadata providers. Without providers the remote
+// Taskfiles of uses entries are not located (and not included).
+func (c *GenerateCommand) prepare(providers bool) error {
+	var err error
+	if err = c.applyOverrides(); err != nil {
+		return err
+	}
+	if c.simulationAst, err = ExpandReplicas(c.simulationAst); err != nil {
+		return err
+	}
+	if c.strict {
+		if err := vars.Report(vars.Check(c.simulationAst, os.LookupEnv)); err != nil {
+			return err
+		}
+	}
+	if !providers {
+		c.providers = &provider.Registry{}
+		return nil
+	}
+	c.providers, err = provider.LoadRegistry(c.providerFile)
+	return err
+}
+
+func (c *GenerateCommand) generate() error {
+	var err error
+	if err = c.prepare(true); err != nil {
+		return err
+	}
+	if c.vendor {
+		if err = c.loadVendorIndex(); err != nil {
+			return err
+		}
+	}
 
 	fmt.Fprintf(flag.CommandLine.Output(), "Writing to folder: %s\n", c.outputPath)
 
@@ -90,7 +197,12 @@ func (c *GenerateCommand) Run() error {
 		c.genSimulation = true
 	}
 	if c.genTaskfile {
-		if err = c.GenerateTaskfile(); err != nil {
+		if c.backend == BackendTaskfile {
+			err = c.GenerateTaskfile()
+		} else {
+			err = c.GenerateBuildFile()
+		}
+		if err != nil {
 			return err
 		}
 	}
@@ -99,37 +211,64 @@ func (c *GenerateCommand) Run() error {
 			return err
 		}
 	}
+	if c.genCompose {
+		if err = c.GenerateCompose(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
 func (c *GenerateCommand) loadAst(file string) error {
-	_, docs, err := handler.ParseFile(file)
+	spec, doc, err := LoadSimulationAst(file)
 	if err != nil {
 		return err
 	}
+	c.simulationAst = *spec
+	c.simulationDoc = doc
+	return nil
+}
+
+// LoadSimulationAst loads the first Simulation kind document from the file.
+func LoadSimulationAst(file string) (*ast.SimulationSpec, *kind.KindDoc, error) {
+	_, docs, err := handler.ParseFile(file)
+	if err != nil {
+		return nil, nil, err
+	}
 	docList := docs.([]kind.KindDoc)
 	for _, doc := range docList {
 		slog.Info(fmt.Sprintf("kind: %s; name=%s (%s)", doc.Kind, doc.Metadata.Name, doc.File))
 		if doc.Kind == "Simulation" {
-			ast := doc.Spec.(*ast.SimulationSpec)
-			c.simulationAst = *ast
-			c.simulationDoc = &doc
-			return nil
+			return doc.Spec.(*ast.SimulationSpec), &doc, nil
 		}
 	}
-	return fmt.Errorf("simulation AST not found in file: %s", file)
+	return nil, nil, fmt.Errorf("simulation AST not found in file: %s", file)
 }
 
 func (c *GenerateCommand) expandDseScriptFiles() error {
-	dseScriptPath := c.dseScriptPath
-	if dseScriptPath == "" {
-		if c.simulationDoc != nil && c.simulationDoc.Metadata.Labels != nil {
-			if p, ok := c.simulationDoc.Metadata.Labels["original_dse_script"]; ok {
-				dseScriptPath = p
+	dseScriptPaths := []string{}
+	if c.dseScriptPath != "" {
+		dseScriptPaths = append(dseScriptPaths, c.dseScriptPath)
+	} else if c.simulationDoc != nil && c.simulationDoc.Metadata.Labels != nil {
+		// The DSE scripts of a simulation composed from several fragments
+		// are labeled original_dse_script.<i> (after the first).
+		labels := c.simulationDoc.Metadata.Labels
+		if p, ok := labels["original_dse_script"]; ok {
+			dseScriptPaths = append(dseScriptPaths, p)
+		}
+		fragments := map[int]string{}
+		for key, p := range labels {
+			if suffix, ok := strings.CutPrefix(key, "original_dse_script."); ok {
+				if i, err := strconv.Atoi(suffix); err == nil {
+					fragments[i] = p
+				}
 			}
 		}
+		for _, i := range slices.Sorted(maps.Keys(fragments)) {
+			dseScriptPaths = append(dseScriptPaths, fragments[i])
+		}
 	}
-	if dseScriptPath == "" {
+	if len(dseScriptPaths) == 0 {
 		slog.Info("No DSE script path available, skipping txtar expansion")
 		return nil
 	}
@@ -139,6 +278,11 @@ func (c *GenerateCommand) expandDseScriptFiles() error {
 		outputDir = "."
 	}
 
-	slog.Info(fmt.Sprintf("Expanding txtar files from %s into %s", dseScriptPath, outputDir))
-	return ExpandTxtar(dseScriptPath, outputDir, c.overwriteFiles)
+	for _, dseScriptPath := range dseScriptPaths {
+		slog.Info(fmt.Sprintf("Expanding txtar files from %s into %s", dseScriptPath, outputDir))
+		if err := ExpandTxtar(dseScriptPath, outputDir, c.overwriteFiles); err != nil {
+			return err
+		}
+	}
+	return nil
 }
@@ -10,6 +10,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/boschglobal/dse.schemas/code/go/dse/ast"
 	"github.com/goccy/go-yaml"
 	"github.com/stretchr/testify/assert"
 )
@@ -67,3 +68,20 @@ func TestGenerateTaskfile(t *testing.T) {
 	assert.DirExists(t, filepath.Join("out", outFolder))
 	assert.FileExists(t, taskfileName)
 }
+
+func TestGenerateTaskfile_strict(t *testing.T) {
+	spec, doc, err := LoadSimulationAst("testdata/ast.yaml")
+	assert.NoError(t, err)
+	outFolder := filepath.Join("out", "tmp", t.Name())
+
+	cmd := NewGenerateCommand("test_generate_strict")
+	assert.NoError(t, cmd.Parse([]string{"-taskfile", "-strict"}))
+	assert.NoError(t, cmd.GenerateAst(*spec, doc, outFolder))
+
+	// Add an unresolved reference to a model.
+	spec.Stacks[0].Models[0].Vars = &[]ast.Var{
+		{Name: "SIGNALS", Value: "{{.SIGNAL_DIR}}/signals.yaml"},
+	}
+	err = cmd.GenerateAst(*spec, doc, outFolder)
+	assert.ErrorContains(t, err, "strict variable resolution failed: 1 issue(s) found")
+}
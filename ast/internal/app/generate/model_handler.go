@@ -0,0 +1,153 @@
+// Copyright 2025 Robert Bosch GmbH
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package generate
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+
+	"github.com/boschglobal/dse.schemas/code/go/dse/ast"
+	"github.com/boschglobal/dse.schemas/code/go/dse/kind"
+)
+
+// ModelContext is the context of a model, passed to the ModelHandler.
+type ModelContext struct {
+	Model    ast.Model
+	Uses     ast.Uses // Uses of the model (empty if the model has no uses).
+	UsesUrl  *url.URL // Parsed (escaped) URL of the model uses.
+	Mcl      MclInfo
+	Metadata map[string]interface{}
+	Spec     ast.SimulationSpec
+}
+
+// ModelHandler generates the model type specific parts of a model: the model
+// Task (vars, deps, cmds, sources and generates) and the ModelInstance (model
+// name and runtime). The common parts (files, workflows, env) are generated by
+// the core.
+//
+// A new model type is supported by registering a handler (RegisterModelHandler,
+// typically from an init function), embedding ModelHandlerBase provides the
+// default (empty) implementation of each method.
+type ModelHandler interface {
+	// Name of the model type (e.g. modelc).
+	Name() string
+	// Match returns true if the handler generates the model.
+	Match(mc *ModelContext) bool
+
+	// Vars sets the vars of the model Task (the core sets REPO, TAG, MODEL,
+	// PATH and PLATFORM_ARCH before, these may be modified).
+	Vars(mc *ModelContext, vars *OMap)
+	// Deps returns the deps of the model Task (i.e. package downloads).
+	Deps(mc *ModelContext) []Dep
+	// Cmds returns the cmds which install the model package (after the model
+	// data folder is created, before the model files are copied).
+	Cmds(mc *ModelContext) []Cmd
+	// Sources returns the sources of the model Task.
+	Sources(mc *ModelContext) []string
+	// Generates returns the generates of the model Task.
+	Generates(mc *ModelContext) []string
+
+	// ModelInstance sets the model name and runtime of the ModelInstance (the
+	// core sets the runtime env, external and arch settings before).
+	ModelInstance(mc *ModelContext, mi *kind.ModelInstance)
+}
+
+// ModelHandlerBase is the default implementation of a ModelHandler.
+type ModelHandlerBase struct{}
+
+func (ModelHandlerBase) Vars(mc *ModelContext, vars *OMap)                      {}
+func (ModelHandlerBase) Deps(mc *ModelContext) []Dep                            { return nil }
+func (ModelHandlerBase) Cmds(mc *ModelContext) []Cmd                            { return nil }
+func (ModelHandlerBase) Sources(mc *ModelContext) []string                      { return nil }
+func (ModelHandlerBase) Generates(mc *ModelContext) []string                    { return nil }
+func (ModelHandlerBase) ModelInstance(mc *ModelContext, mi *kind.ModelInstance) {}
+
+var modelHandlers []ModelHandler
+
+// RegisterModelHandler registers a model handler. Handlers are matched in the
+// reverse order of registration (i.e. the most recently registered handler
+// is matched first), the builtin modelc handler matches all models.
+func RegisterModelHandler(h ModelHandler) {
+	modelHandlers = slices.Insert(modelHandlers, 0, h)
+}
+
+// ModelHandlers returns the registered model handlers (in match order).
+func ModelHandlers() []ModelHandler {
+	return slices.Clone(modelHandlers)
+}
+
+func modelHandlerFor(mc *ModelContext) ModelHandler {
+	for _, h := range modelHandlers {
+		if h.Match(mc) {
+			return h
+		}
+	}
+	return modelcModelHandler{}
+}
+
+func init() {
+	// Builtin handlers, from generic to specific.
+	RegisterModelHandler(modelcModelHandler{})
+	RegisterModelHandler(fmuModelHandler{})
+	RegisterModelHandler(luaModelHandler{})
+	RegisterModelHandler(externalModelHandler{})
+}
+
+// newModelContext creates the context of a model, the uses of the model must
+// exist in the simulation.
+func newModelContext(model ast.Model, simSpec ast.SimulationSpec) (*ModelContext, error) {
+	mc := &ModelContext{
+		Model:    model,
+		Metadata: map[string]interface{}{},
+		Spec:     simSpec,
+	}
+	if len(model.Uses) > 0 && simSpec.Uses != nil {
+		for _, uses := range *simSpec.Uses {
+			if uses.Name == model.Uses {
+				mc.Uses = uses
+				break
+			}
+		}
+	}
+	if len(model.Uses) > 0 && mc.Uses.Name == "" {
+		return mc, fmt.Errorf("Model uses not found in simulation AST (name=%s)", model.Uses)
+	}
+	if model.Metadata != nil {
+		mc.Metadata = *model.Metadata
+	}
+	mc.Mcl, _ = resolveMclFromUses(mc.Uses)
+	mc.UsesUrl, _ = urlEscapedParse(mc.Uses.Url)
+	if mc.UsesUrl == nil {
+		mc.UsesUrl = &url.URL{}
+	}
+	return mc, nil
+}
+
+// metadataValue returns the value of a (nested) metadata key.
+func metadataValue(md map[string]interface{}, keys ...string) (interface{}, bool) {
+	var v interface{} = md
+	for _, k := range keys {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if v, ok = m[k]; !ok {
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+func metadataString(md map[string]interface{}, keys ...string) (string, bool) {
+	v, _ := metadataValue(md, keys...)
+	s, ok := v.(string)
+	return s, ok
+}
+
+// modelMetadata returns the metadata of the model (i.e. models.<model>.<key>).
+func (mc *ModelContext) modelMetadata(key string) (interface{}, bool) {
+	return metadataValue(mc.Metadata, "models", mc.Model.Model, key)
+}
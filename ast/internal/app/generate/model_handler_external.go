@@ -0,0 +1,33 @@
+// Copyright 2025 Robert Bosch GmbH
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package generate
+
+import (
+	"github.com/boschglobal/dse.schemas/code/go/dse/kind"
+)
+
+// externalModelHandler generates external models (external=true), i.e. a
+// gateway, which are not run by the stack (the model connects to the SimBus).
+type externalModelHandler struct {
+	modelcModelHandler
+}
+
+func (externalModelHandler) Name() string {
+	return "external"
+}
+
+func (externalModelHandler) Match(mc *ModelContext) bool {
+	return mc.Model.External != nil && *mc.Model.External
+}
+
+func (h externalModelHandler) ModelInstance(mc *ModelContext, mi *kind.ModelInstance) {
+	h.modelcModelHandler.ModelInstance(mc, mi)
+	// Use the model if specified; otherwise use the declared model name.
+	if mc.Model.Model != "" { // eg: model <model_name> <Model> external=true
+		mi.Model.Name = mc.Model.Model
+	} else { // eg: model <model_name> external=true
+		mi.Model.Name = mc.Model.Name
+	}
+}
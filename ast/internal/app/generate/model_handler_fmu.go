@@ -0,0 +1,32 @@
+// Copyright 2025 Robert Bosch GmbH
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package generate
+
+import (
+	"github.com/boschglobal/dse.schemas/code/go/dse/kind"
+)
+
+// fmuModelHandler generates FMU models, which are loaded by an MCL (e.g. the
+// FMI MCL of dse.fmi, metadata models.<model>.mcl). The MCL is installed from
+// its package (as a modelc model), FMUs are extracted by the model workflows.
+type fmuModelHandler struct {
+	modelcModelHandler
+}
+
+func (fmuModelHandler) Name() string {
+	return "fmu"
+}
+
+func (fmuModelHandler) Match(mc *ModelContext) bool {
+	v, _ := mc.modelMetadata("mcl")
+	mcl, _ := v.(bool)
+	return mcl
+}
+
+func (h fmuModelHandler) ModelInstance(mc *ModelContext, mi *kind.ModelInstance) {
+	h.modelcModelHandler.ModelInstance(mc, mi)
+	// MCL Models need to use the instance name.
+	mi.Model.Name = mc.Model.Name
+}
@@ -0,0 +1,112 @@
+// Copyright 2025 Robert Bosch GmbH
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package generate
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/boschglobal/dse.schemas/code/go/dse/kind"
+)
+
+// luaModelHandler generates Lua models, which are loaded by the Lua MCL. The
+// Lua model is a file (local or remote) or located in a zip archive (uses path).
+type luaModelHandler struct {
+	ModelHandlerBase
+}
+
+func (luaModelHandler) Name() string {
+	return "lua"
+}
+
+func (luaModelHandler) Match(mc *ModelContext) bool {
+	return mc.Mcl.Type == "lua" || getMclType(mc.Model.Model, mc.Spec.Uses).Type == "lua"
+}
+
+func (luaModelHandler) Vars(mc *ModelContext, vars *OMap) {
+	switch mc.UsesUrl.Scheme {
+	case "file":
+		u, _ := url.Parse(mc.Uses.Url)
+		vars.Set("REPO", filepath.Dir(u.Path)) // eg u.Path : /mnt/c/Users/hello.lua
+		vars.Set("PACKAGE_URL", filepath.Base(u.Path))
+		vars.Set("PACKAGE_PATH", mc.Mcl.Path)
+	case "https":
+		vars.Set("PACKAGE_URL", mc.Mcl.Url)
+		vars.Set("PACKAGE_PATH", mc.Mcl.Path)
+	}
+}
+
+func (luaModelHandler) Deps(mc *ModelContext) []Dep {
+	switch mc.UsesUrl.Scheme {
+	case "file":
+		return createModelCopyDeps(mc.Mcl)
+	case "https":
+		return createModelDownloadDeps(mc.Uses, mc.Mcl)
+	}
+	return nil
+}
+
+func (luaModelHandler) Cmds(mc *ModelContext) []Cmd {
+	if mc.UsesUrl.Scheme != "https" {
+		return nil
+	}
+	if mc.Mcl.Path == "" {
+		// Remote standalone Lua model url.
+		return []Cmd{{Cmd: "cp downloads/{{base .PACKAGE_URL}} {{.SIMDIR}}/{{.PATH}}/{{base .PACKAGE_URL}}"}}
+	}
+	// Remote archive url with Lua model path.
+	return []Cmd{{Cmd: fmt.Sprintf("unzip -ojq downloads/{{base .PACKAGE_URL}} '%s/*' -d {{.SIMDIR}}/{{.PATH}} 2>/dev/null", filepath.Dir(mc.Mcl.Path))}}
+}
+
+func (luaModelHandler) ModelInstance(mc *ModelContext, mi *kind.ModelInstance) {
+	model := mc.Model
+	mi.Model.Name = model.Model
+	script := getMclType(model.Model, mc.Spec.Uses).Path
+	if script == "" {
+		script = mc.Mcl.Path
+		if script == "" {
+			script = mc.Mcl.Url
+		}
+	}
+	mcl := "lua"
+	mi.Runtime.Mcl = &mcl
+	files := []string{fmt.Sprintf("model/%s/%s", model.Name, path.Base(script))}
+	if model.Files != nil {
+		for _, f := range *model.Files {
+			fDir, fName := path.Split(f.Name)
+			switch {
+			case len(fDir) == 0 && strings.EqualFold(path.Ext(fName), ".lua"):
+				files = append(files, fmt.Sprintf("model/%s/lua/%s", model.Name, fName))
+			case len(fDir) == 0:
+				files = append(files, fmt.Sprintf("model/%s/data/%s", model.Name, fName))
+			case strings.HasPrefix(fDir, "./"):
+				// Strip leading ./ and keep any real subdirectory that follows.
+				// e.g : file ./plant.lua plant.lua      -> fDir=""          -> model/{name}/{fName}
+				// e.g : file ./sub_dir/plant.lua plant.lua  -> fDir="sub_dir" -> model/{name}/sub_dir/{fName}
+				strippedDir := strings.TrimRight(strings.TrimPrefix(fDir, "./"), "/")
+				if len(strippedDir) == 0 {
+					files = append(files, fmt.Sprintf("model/%s/%s", model.Name, fName))
+				} else {
+					files = append(files, fmt.Sprintf("model/%s/%s/%s", model.Name, strippedDir, fName))
+				}
+			case strings.EqualFold(path.Ext(fName), ".lua"):
+				// plain subdir (no ./) + lua -> under lua/ folder
+				files = append(files, fmt.Sprintf("model/%s/lua/%s/%s", model.Name, strings.TrimRight(fDir, "/"), fName))
+			default:
+				// plain subdir + non-lua -> model root relative
+				cleanDir := strings.TrimRight(strings.TrimPrefix(fDir, "./"), "/")
+				if len(cleanDir) == 0 {
+					files = append(files, fmt.Sprintf("model/%s/%s", model.Name, fName))
+				} else {
+					files = append(files, fmt.Sprintf("model/%s/%s/%s", model.Name, cleanDir, fName))
+				}
+			}
+		}
+	}
+	mi.Runtime.Files = &files
+}
@@ -0,0 +1,101 @@
+// Copyright 2025 Robert Bosch GmbH
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package generate
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/boschglobal/dse.schemas/code/go/dse/kind"
+)
+
+// modelcModelHandler generates models which are distributed in a package (zip
+// archive) of a repo, i.e. the metadata package:download (or package:file for
+// local repos) and models.<model>.path. Matches all models.
+type modelcModelHandler struct {
+	ModelHandlerBase
+}
+
+func (modelcModelHandler) Name() string {
+	return "modelc"
+}
+
+func (modelcModelHandler) Match(mc *ModelContext) bool {
+	return true
+}
+
+func (modelcModelHandler) Vars(mc *ModelContext, vars *OMap) {
+	pkgUrlKey := "download"
+	if mc.UsesUrl.Scheme == "file" {
+		// When the URL is a "file", use the package:file key.
+		pkgUrlKey = "file"
+		if mc.Uses.Name != "" {
+			vars.Set("REPO", strings.TrimSuffix(mc.UsesUrl.Path, "/")) // eg modelUrl.Path : /mnt/c/Users/dse.sdp/
+		}
+	}
+	if packageUrl, ok := metadataString(mc.Metadata, "package", pkgUrlKey); ok {
+		vars.Set("PACKAGE_URL", packageUrl)
+		if packagePath, ok := mc.modelMetadata("path"); ok {
+			if s, ok := packagePath.(string); ok {
+				vars.Set("PACKAGE_PATH", s)
+			}
+		}
+	}
+}
+
+func (modelcModelHandler) Deps(mc *ModelContext) []Dep {
+	switch mc.UsesUrl.Scheme {
+	case "file":
+		// <repo>/<package:file> -> downloads/base(<package:file>)
+		return createModelCopyDeps(mc.Mcl)
+	case "https":
+		return createModelDownloadDeps(mc.Uses, mc.Mcl)
+	}
+	return nil
+}
+
+// isPackage returns true if the model is located in the package (i.e. has a
+// package path).
+func (modelcModelHandler) isPackage(mc *ModelContext) bool {
+	v, ok := mc.modelMetadata("path")
+	return ok && v != nil
+}
+
+func (h modelcModelHandler) Cmds(mc *ModelContext) []Cmd {
+	if !h.isPackage(mc) {
+		return nil
+	}
+	return []Cmd{
+		{
+			Task: "unzip-dir",
+			Vars: &map[string]string{
+				"ZIP":    "downloads/{{base .PACKAGE_URL}}",
+				"ZIPDIR": "{{.PACKAGE_PATH}}",
+				"DIR":    "{{.SIMDIR}}/{{.PATH}}",
+			},
+		},
+		{
+			Cmd: `find {{.SIMDIR}}/{{.PATH}}/data -type f -name model.yaml -print0 | ` +
+				`xargs -r -0 yq -i '.spec.runtime.dynlib[].path |= sub("(^.*/)?(lib|bin)/"; "{{.PATH}}/$2/")'`,
+		},
+		{Cmd: "rm -rf {{.SIMDIR}}/{{.PATH}}/examples"},
+		{Cmd: "find {{.SIMDIR}}/{{.PATH}} -type f -name simulation.yaml -print0  | xargs -r -0 rm -f"},
+		{Cmd: "find {{.SIMDIR}}/{{.PATH}} -type f -name simulation.yml -print0  | xargs -r -0 rm -f"},
+	}
+}
+
+func (h modelcModelHandler) Generates(mc *ModelContext) []string {
+	if !h.isPackage(mc) {
+		return nil
+	}
+	return []string{"{{.SIMDIR}}/{{.PATH}}/**"}
+}
+
+func (modelcModelHandler) ModelInstance(mc *ModelContext, mi *kind.ModelInstance) {
+	mi.Model.Name = mc.Model.Model
+	mi.Runtime.Paths = &[]string{
+		fmt.Sprintf("model/%s/data", mc.Model.Name),
+	}
+}
@@ -0,0 +1,173 @@
+// Copyright 2025 Robert Bosch GmbH
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package generate
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/boschglobal/dse.schemas/code/go/dse/ast"
+	"github.com/boschglobal/dse.schemas/code/go/dse/kind"
+)
+
+func TestModelHandlerFor(t *testing.T) {
+	external := true
+	spec := ast.SimulationSpec{
+		Uses: &[]ast.Uses{
+			{Name: "dse.modelc", Url: "https://github.com/boschglobal/dse.modelc"},
+			{Name: "dse.fmi", Url: "https://github.com/boschglobal/dse.fmi"},
+			{Name: "linear", Url: "https://example.com/models/linear.lua"},
+		},
+	}
+	tests := []struct {
+		name    string
+		model   ast.Model
+		handler string
+	}{
+		{
+			name:    "modelc",
+			model:   ast.Model{Name: "input", Model: "dse.modelc.csv", Uses: "dse.modelc"},
+			handler: "modelc",
+		},
+		{
+			name: "fmu",
+			model: ast.Model{Name: "linear", Model: "dse.fmi.mcl", Uses: "dse.fmi",
+				Metadata: &map[string]interface{}{
+					"models": map[string]interface{}{"dse.fmi.mcl": map[string]interface{}{"path": "fmimcl", "mcl": true}},
+				}},
+			handler: "fmu",
+		},
+		{
+			name:    "lua",
+			model:   ast.Model{Name: "linear", Model: "linear", Uses: "linear"},
+			handler: "lua",
+		},
+		{
+			name:    "external",
+			model:   ast.Model{Name: "gateway", External: &external},
+			handler: "external",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mc, err := newModelContext(tc.model, spec)
+			require.NoError(t, err)
+			assert.Equal(t, tc.handler, modelHandlerFor(mc).Name())
+		})
+	}
+
+	_, err := newModelContext(ast.Model{Name: "input", Uses: "missing"}, spec)
+	assert.ErrorContains(t, err, "Model uses not found in simulation AST (name=missing)")
+}
+
+func TestModelHandler_lua(t *testing.T) {
+	spec := ast.SimulationSpec{
+		Uses: &[]ast.Uses{
+			{Name: "linear", Url: "https://example.com/models/linear.lua"},
+		},
+	}
+	model := ast.Model{
+		Name:  "linear",
+		Model: "linear",
+		Uses:  "linear",
+		Files: &[]ast.File{
+			{Name: "signalgroup.yaml", Value: "data/linear_sg.yaml"},
+			{Name: "helper.lua", Value: "lua/helper.lua"},
+		},
+	}
+	task, err := buildModel(model, spec)
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/models/linear.lua", func() string { v, _ := task.Vars.Get("PACKAGE_URL"); return v }())
+	assert.Equal(t, "download-file", (*task.Deps)[0].Task)
+	assert.Equal(t, "cp downloads/{{base .PACKAGE_URL}} {{.SIMDIR}}/{{.PATH}}/{{base .PACKAGE_URL}}", (*task.Cmds)[2].Cmd)
+
+	mc, err := newModelContext(model, spec)
+	require.NoError(t, err)
+	mi := kind.ModelInstance{Name: model.Name, Model: &struct {
+		Name string `yaml:"name"`
+	}{}, Runtime: generateModelRuntime(model)}
+	modelHandlerFor(mc).ModelInstance(mc, &mi)
+	assert.Equal(t, "linear", mi.Model.Name)
+	assert.Equal(t, "lua", *mi.Runtime.Mcl)
+	assert.Equal(t, []string{"model/linear/linear.lua", "model/linear/data/signalgroup.yaml", "model/linear/lua/helper.lua"}, *mi.Runtime.Files)
+	assert.Nil(t, mi.Runtime.Paths)
+}
+
+// pythonModelHandler is an example of a model type which is not builtin.
+type pythonModelHandler struct {
+	ModelHandlerBase
+}
+
+func (pythonModelHandler) Name() string { return "python" }
+
+func (pythonModelHandler) Match(mc *ModelContext) bool {
+	return mc.Model.Model == "python"
+}
+
+func (pythonModelHandler) Vars(mc *ModelContext, vars *OMap) {
+	vars.Set("SCRIPT", "{{.PROJDIR}}/model.py")
+}
+
+func (pythonModelHandler) Cmds(mc *ModelContext) []Cmd {
+	return []Cmd{{Cmd: "cp {{.SCRIPT}} {{.SIMDIR}}/{{.PATH}}/model.py"}}
+}
+
+func (pythonModelHandler) Sources(mc *ModelContext) []string {
+	return []string{"{{.SCRIPT}}"}
+}
+
+func (pythonModelHandler) Generates(mc *ModelContext) []string {
+	return []string{"{{.SIMDIR}}/{{.PATH}}/model.py"}
+}
+
+func (pythonModelHandler) ModelInstance(mc *ModelContext, mi *kind.ModelInstance) {
+	mi.Model.Name = "python"
+	(*mi.Runtime.Env)["PYTHON_MODEL"] = "model/" + mc.Model.Name + "/model.py"
+}
+
+func TestRegisterModelHandler(t *testing.T) {
+	handlers := modelHandlers
+	t.Cleanup(func() { modelHandlers = handlers })
+	RegisterModelHandler(pythonModelHandler{})
+	assert.Equal(t, "python", ModelHandlers()[0].Name())
+
+	astYaml := `---
+kind: Simulation
+spec:
+  arch: linux-amd64
+  channels:
+    - name: physical
+  stacks:
+    - name: default
+      models:
+        - name: plant
+          model: python
+          channels:
+            - alias: signal_channel
+              name: physical
+`
+	require.NoError(t, os.MkdirAll("out/tmp/TestRegisterModelHandler", 0755))
+	require.NoError(t, os.WriteFile("out/tmp/TestRegisterModelHandler/ast.yaml", []byte(astYaml), 0644))
+
+	cmd := NewGenerateCommand("test_generate")
+	require.NoError(t, cmd.Parse([]string{"-input", "tmp/TestRegisterModelHandler/ast.yaml", "-output", "tmp/TestRegisterModelHandler"}))
+	require.NoError(t, cmd.Run())
+
+	f, err := os.ReadFile("out/tmp/TestRegisterModelHandler/Taskfile.yml")
+	require.NoError(t, err)
+	YamlContains(t, f, "$.tasks.model-plant.vars.SCRIPT", "{{.PROJDIR}}/model.py")
+	YamlContains(t, f, "$.tasks.model-plant.cmds[2]", "cp {{.SCRIPT}} {{.SIMDIR}}/{{.PATH}}/model.py")
+	YamlContains(t, f, "$.tasks.model-plant.sources[0]", "{{.SCRIPT}}")
+	YamlContains(t, f, "$.tasks.model-plant.generates[0]", "{{.SIMDIR}}/{{.PATH}}/model.py")
+
+	f, err = os.ReadFile("out/tmp/TestRegisterModelHandler/simulation.yaml")
+	require.NoError(t, err)
+	YamlContains(t, f, "$.spec.models[1].name", "plant")
+	YamlContains(t, f, "$.spec.models[1].model.name", "python")
+	YamlContains(t, f, "$.spec.models[1].runtime.env.PYTHON_MODEL", "model/plant/model.py")
+}
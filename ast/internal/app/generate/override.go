@@ -0,0 +1,57 @@
+// Copyright 2025 Robert Bosch GmbH
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package generate
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/boschglobal/dse.sdp/ast/internal/pkg/override"
+)
+
+// applyOverrides redirects uses entries to the local directory or file of an
+// override (see -override and sdp.override.yaml). The generated Taskfile then
+// uses the file:// handling (local Taskfile include, copy of packages). The
+// overrides, including those applied by resolve (AST labels), are listed by
+// the info task.
+func (c *GenerateCommand) applyOverrides() error {
+	overrides, err := override.Load(c.overrideFile, c.overrideFlags)
+	if err != nil {
+		return err
+	}
+	c.overrides = override.Overrides{}
+	if c.simulationDoc != nil && c.simulationDoc.Metadata.Labels != nil {
+		c.overrides = override.FromLabels(c.simulationDoc.Metadata.Labels)
+	}
+	if c.simulationAst.Uses == nil {
+		return nil
+	}
+	for i := range *c.simulationAst.Uses {
+		uses := &(*c.simulationAst.Uses)[i]
+		location, ok := overrides[uses.Name]
+		if !ok {
+			continue
+		}
+		if uses.Metadata == nil {
+			uses.Metadata = &map[string]interface{}{}
+		}
+		if _, ok := (*uses.Metadata)[override.MetadataKey]; !ok {
+			(*uses.Metadata)[override.MetadataKey] = uses.Url
+		}
+		slog.Info("Override uses", "name", uses.Name, "url", location)
+		uses.Url = location
+		c.overrides[uses.Name] = location
+	}
+	return nil
+}
+
+// overrideInfoCmds lists the overridden uses entries (info task).
+func overrideInfoCmds(overrides override.Overrides) []Cmd {
+	cmds := []Cmd{}
+	for _, name := range overrides.Names() {
+		cmds = append(cmds, Cmd{Cmd: fmt.Sprintf("echo \"OVERRIDE           = %s -> %s\"", name, overrides[name])})
+	}
+	return cmds
+}
@@ -0,0 +1,173 @@
+// Copyright 2025 Robert Bosch GmbH
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package generate
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/boschglobal/dse.schemas/code/go/dse/ast"
+
+	"github.com/boschglobal/dse.sdp/ast/internal/pkg/source"
+)
+
+// AnnotationReplicas of a model, the model is expanded into that number of
+// model instances (named <name>_<i>, with i from 0).
+const AnnotationReplicas = "replicas"
+
+var indexVarRegex = regexp.MustCompile(`\{\{\s*\.INDEX\s*\}\}`)
+
+// ModelReplicas returns the number of replicas of a model (1 when the model
+// has no replicas annotation).
+func ModelReplicas(model ast.Model) (int, error) {
+	if model.Annotations == nil {
+		return 1, nil
+	}
+	v, ok := (*model.Annotations)[AnnotationReplicas]
+	if !ok {
+		return 1, nil
+	}
+	replicas, err := strconv.Atoi(strings.TrimSpace(fmt.Sprint(v)))
+	if err != nil || replicas < 1 {
+		return 0, fmt.Errorf("%smodel '%s': invalid replicas annotation '%v' (positive integer expected)", source.Prefix(model.Annotations), model.Name, v)
+	}
+	return replicas, nil
+}
+
+// ExpandReplicas returns the simulation with each replicated model expanded
+// into its model instances. The {{.INDEX}} template is substituted in the
+// vars, env and files (name and value) of each instance, and a model UID is
+// incremented by the index. An instance which has the name or UID of another
+// model is an error. The input simulation is not modified.
+func ExpandReplicas(simSpec ast.SimulationSpec) (ast.SimulationSpec, error) {
+	stacks := make([]ast.Stack, len(simSpec.Stacks))
+	replicaOf := map[[2]int]string{}
+	for si, stack := range simSpec.Stacks {
+		models := []ast.Model{}
+		for _, model := range stack.Models {
+			replicas, err := ModelReplicas(model)
+			if err != nil {
+				return simSpec, err
+			}
+			if !isReplicated(model) {
+				models = append(models, model)
+				continue
+			}
+			for i := range replicas {
+				replica, err := modelReplica(model, i)
+				if err != nil {
+					return simSpec, err
+				}
+				replicaOf[[2]int{si, len(models)}] = model.Name
+				models = append(models, replica)
+			}
+		}
+		stack.Models = models
+		stacks[si] = stack
+	}
+	if len(replicaOf) > 0 {
+		if err := checkReplicaIdentity(stacks, replicaOf); err != nil {
+			return simSpec, err
+		}
+	}
+	simSpec.Stacks = stacks
+	return simSpec, nil
+}
+
+// checkReplicaIdentity checks that the model instances of replicated models
+// have a unique name and UID (validate checks the models before they are
+// expanded). The replicas are keyed by stack and model index.
+func checkReplicaIdentity(stacks []ast.Stack, replicaOf map[[2]int]string) error {
+	type identity struct {
+		desc    string
+		stack   string
+		replica bool
+	}
+	names := map[string]identity{}
+	uids := map[int]identity{}
+	for si, stack := range stacks {
+		for mi, model := range stack.Models {
+			origin, replica := replicaOf[[2]int{si, mi}]
+			id := identity{desc: fmt.Sprintf("model '%s'", model.Name), stack: stack.Name, replica: replica}
+			if replica {
+				id.desc = fmt.Sprintf("model '%s' (replica of '%s')", model.Name, origin)
+			}
+			if other, ok := names[model.Name]; ok && (replica || other.replica) {
+				return fmt.Errorf("%s%s: duplicate model name (also %s in stack '%s')", source.Prefix(model.Annotations), id.desc, other.desc, other.stack)
+			}
+			names[model.Name] = id
+			if model.Uid == nil || *model.Uid == 0 {
+				continue
+			}
+			if other, ok := uids[*model.Uid]; ok && (replica || other.replica) {
+				return fmt.Errorf("%s%s: duplicate uid %d (also used by %s)", source.Prefix(model.Annotations), id.desc, *model.Uid, other.desc)
+			}
+			uids[*model.Uid] = id
+		}
+	}
+	return nil
+}
+
+func isReplicated(model ast.Model) bool {
+	if model.Annotations == nil {
+		return false
+	}
+	_, ok := (*model.Annotations)[AnnotationReplicas]
+	return ok
+}
+
+func modelReplica(model ast.Model, index int) (ast.Model, error) {
+	// Deep copy of the model.
+	var replica ast.Model
+	data, err := yaml.Marshal(model)
+	if err != nil {
+		return replica, err
+	}
+	if err := yaml.Unmarshal(data, &replica); err != nil {
+		return replica, err
+	}
+
+	idx := strconv.Itoa(index)
+	substitute := func(s string) string {
+		return indexVarRegex.ReplaceAllString(s, idx)
+	}
+	substituteVars := func(vars *[]ast.Var) {
+		if vars == nil {
+			return
+		}
+		for i := range *vars {
+			(*vars)[i].Value = substitute((*vars)[i].Value)
+		}
+	}
+
+	replica.Name = fmt.Sprintf("%s_%d", model.Name, index)
+	if model.Uid != nil && *model.Uid != 0 {
+		uid := *model.Uid + index
+		replica.Uid = &uid
+	}
+	delete(*replica.Annotations, AnnotationReplicas)
+	if len(*replica.Annotations) == 0 {
+		replica.Annotations = nil
+	}
+	substituteVars(replica.Vars)
+	substituteVars(replica.Env)
+	if replica.Workflows != nil {
+		for _, workflow := range *replica.Workflows {
+			substituteVars(workflow.Vars)
+		}
+	}
+	if replica.Files != nil {
+		for i := range *replica.Files {
+			f := &(*replica.Files)[i]
+			f.Name = substitute(f.Name)
+			f.Value = substitute(f.Value)
+		}
+	}
+	return replica, nil
+}
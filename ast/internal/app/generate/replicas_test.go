@@ -0,0 +1,161 @@
+// Copyright 2025 Robert Bosch GmbH
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package generate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/boschglobal/dse.schemas/code/go/dse/ast"
+)
+
+const replicasAst = `---
+channels:
+  - name: physical
+  - name: network
+stacks:
+  - name: default
+    models:
+      - name: gateway
+        model: Gateway
+        channels:
+          - alias: signal_channel
+            name: physical
+      - name: node
+        model: dse.modelc.csv
+        uid: 100
+        annotations:
+          replicas: 3
+          trace: true
+        channels:
+          - alias: signal_channel
+            name: physical
+          - alias: network_channel
+            name: network
+        env:
+          - name: NODE_NAME
+            value: node-{{.INDEX}}
+        vars:
+          - name: NODE_ID
+            value: "{{ .INDEX }}"
+        files:
+          - name: node_{{.INDEX}}.csv
+            value: data/node_{{.INDEX}}.csv
+        workflows:
+          - name: generate
+            vars:
+              - name: NODE
+                value: "{{.INDEX}}"
+`
+
+func TestExpandReplicas(t *testing.T) {
+	var spec ast.SimulationSpec
+	require.NoError(t, yaml.Unmarshal([]byte(replicasAst), &spec))
+
+	expanded, err := ExpandReplicas(spec)
+	require.NoError(t, err)
+	models := expanded.Stacks[0].Models
+	require.Len(t, models, 4)
+	assert.Equal(t, "gateway", models[0].Name)
+	for i, model := range models[1:] {
+		index := []string{"0", "1", "2"}[i]
+		assert.Equal(t, "node_"+index, model.Name)
+		assert.Equal(t, 100+i, *model.Uid)
+		assert.Equal(t, ast.Annotations{"trace": true}, *model.Annotations)
+		assert.Equal(t, "node-"+index, (*model.Env)[0].Value)
+		assert.Equal(t, index, (*model.Vars)[0].Value)
+		assert.Equal(t, "node_"+index+".csv", (*model.Files)[0].Name)
+		assert.Equal(t, "data/node_"+index+".csv", (*model.Files)[0].Value)
+		assert.Equal(t, index, (*(*model.Workflows)[0].Vars)[0].Value)
+	}
+
+	// The input is not modified.
+	assert.Len(t, spec.Stacks[0].Models, 2)
+	assert.Equal(t, "{{ .INDEX }}", (*spec.Stacks[0].Models[1].Vars)[0].Value)
+
+	// Invalid replicas.
+	(*spec.Stacks[0].Models[1].Annotations)[AnnotationReplicas] = "many"
+	_, err = ExpandReplicas(spec)
+	assert.ErrorContains(t, err, "model 'node': invalid replicas annotation 'many' (positive integer expected)")
+	(*spec.Stacks[0].Models[1].Annotations)["sdp/source"] = "replicas.dse:12:1"
+	_, err = ExpandReplicas(spec)
+	assert.EqualError(t, err, "replicas.dse:12:1: model 'node': invalid replicas annotation 'many' (positive integer expected)")
+}
+
+func TestExpandReplicas_identity(t *testing.T) {
+	tests := []struct {
+		name   string
+		models string
+		err    string
+	}{
+		{
+			name: "uid",
+			models: `
+      - name: a
+        uid: 1
+        annotations:
+          replicas: 3
+      - name: b
+        uid: 2
+`,
+			err: "model 'b': duplicate uid 2 (also used by model 'a_1' (replica of 'a'))",
+		},
+		{
+			name: "name",
+			models: `
+      - name: node_1
+      - name: node
+        annotations:
+          replicas: 2
+          sdp/source: replicas.dse:4:1
+`,
+			err: "replicas.dse:4:1: model 'node_1' (replica of 'node'): duplicate model name (also model 'node_1' in stack 'default')",
+		},
+		{
+			name: "unique",
+			models: `
+      - name: a
+        uid: 1
+        annotations:
+          replicas: 3
+      - name: b
+        uid: 4
+`,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var spec ast.SimulationSpec
+			require.NoError(t, yaml.Unmarshal([]byte("stacks:\n  - name: default\n    models:"+tc.models), &spec))
+			_, err := ExpandReplicas(spec)
+			if tc.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.err)
+			}
+		})
+	}
+}
+
+func TestGenerateSimbusModel_replicas(t *testing.T) {
+	var spec ast.SimulationSpec
+	require.NoError(t, yaml.Unmarshal([]byte(replicasAst), &spec))
+
+	counts := func(spec ast.SimulationSpec) map[string]int {
+		counts := map[string]int{}
+		for _, ch := range *generateSimbusModel(spec).Channels {
+			counts[*ch.Name] = *ch.ExpectedModelCount
+		}
+		return counts
+	}
+	expected := map[string]int{"physical": 4, "network": 3}
+	assert.Equal(t, expected, counts(spec))
+	expanded, err := ExpandReplicas(spec)
+	require.NoError(t, err)
+	assert.Equal(t, expected, counts(expanded))
+}
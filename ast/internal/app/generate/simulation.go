@@ -7,9 +7,7 @@ package generate
 import (
 	"flag"
 	"fmt"
-	"log/slog"
 	"os"
-	"path"
 	"path/filepath"
 	"slices"
 	"strings"
@@ -17,6 +15,8 @@ import (
 	"github.com/boschglobal/dse.clib/extra/go/command/util"
 	"github.com/boschglobal/dse.schemas/code/go/dse/ast"
 	"github.com/boschglobal/dse.schemas/code/go/dse/kind"
+
+	"github.com/boschglobal/dse.sdp/ast/internal/pkg/source"
 )
 
 type MclInfo struct {
@@ -76,6 +76,11 @@ func (c *GenerateCommand) GenerateSimulation() error {
 		simChannels = append(simChannels, c.Name)
 	}
 
+	connections, err := c.stackConnections()
+	if err != nil {
+		return err
+	}
+
 	var simbusModel *kind.ModelInstance
 	for _, astStack := range simSpec.Stacks {
 		annotations := kind.Annotations{
@@ -87,7 +92,12 @@ func (c *GenerateCommand) GenerateSimulation() error {
 
 		if astStack.Annotations != nil {
 			for k, v := range *astStack.Annotations {
-				annotations[k] = v
+				// The source location of the stack is kept, the locations
+				// of items are only recorded in the AST.
+				if source.IsItemAnnotation(k) {
+					continue
+				}
+				annotations[k] = annotationValue(v)
 			}
 		}
 		stack := kind.Stack{
@@ -97,7 +107,7 @@ func (c *GenerateCommand) GenerateSimulation() error {
 				Annotations: &annotations,
 			},
 		}
-		configureConnection(&stack)
+		configureConnection(&stack, connections[astStack.Name])
 
 		if (astStack.Stacked != nil && *astStack.Stacked) ||
 			(astStack.Sequential != nil && *astStack.Sequential) ||
@@ -129,7 +139,6 @@ func (c *GenerateCommand) GenerateSimulation() error {
 			models = append(models, *simbusModel)
 		}
 		for _, astModel := range astStack.Models {
-			mcl := getMclType(astModel.Model, simSpec.Uses)
 			channels := []kind.Channel{}
 			for _, c := range astModel.Channels {
 				if slices.Contains(simChannels, c.Name) {
@@ -141,35 +150,14 @@ func (c *GenerateCommand) GenerateSimulation() error {
 				}
 			}
 
-			// MCL Models need to use astModel.Name (instance name).
-			modelName := astModel.Model
-			md := map[string]interface{}{}
-			if astModel.Metadata != nil {
-				md = *astModel.Metadata
-			}
-			if astModel.External != nil && *astModel.External == true {
-				// For external models, use `astModel.Model` if specified; otherwise use the declared model name.
-				if astModel.Model != "" { // eg: model <model_name> <Model> external=true
-					modelName = astModel.Model
-				} else { // eg: model <model_name> external=true
-					modelName = astModel.Name
-				}
-			} else {
-				func() {
-					defer func() {
-						if r := recover(); r != nil {
-						}
-					}()
-					if md["models"].(map[string]interface{})[astModel.Model].(map[string]interface{})["mcl"].(bool) == true {
-						modelName = astModel.Name
-					}
-				}()
-			}
 			var annotationMap map[string]interface{}
 			if astModel.Annotations != nil {
 				annotationMap = make(map[string]interface{})
 				for k, v := range *astModel.Annotations {
-					annotationMap[k] = v
+					if source.IsItemAnnotation(k) {
+						continue
+					}
+					annotationMap[k] = annotationValue(v)
 				}
 			}
 			model := kind.ModelInstance{
@@ -182,13 +170,15 @@ func (c *GenerateCommand) GenerateSimulation() error {
 				}(),
 				Model: &struct {
 					Name string `yaml:"name"`
-				}{
-					Name: modelName,
-				},
+				}{},
 				Channels:    &channels,
-				Runtime:     generateModelRuntime(astModel, mcl),
+				Runtime:     generateModelRuntime(astModel),
 				Annotations: (*kind.Annotations)(&annotationMap),
 			}
+			// Model name and runtime of the model type (the uses of the
+			// model are checked when generating the Taskfile).
+			mc, _ := newModelContext(astModel, simSpec)
+			modelHandlerFor(mc).ModelInstance(mc, &model)
 			models = append(models, model)
 		}
 		stack.Spec.Models = &models
@@ -212,6 +202,29 @@ func (c *GenerateCommand) GenerateSimulation() error {
 	return nil
 }
 
+// annotationValue returns an (AST) annotation value with nested maps as
+// map[string]interface{}, the YAML decoder keeps the ast.Annotations type for
+// the maps nested in annotations.
+func annotationValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case ast.Annotations:
+		return annotationValue(map[string]interface{}(v))
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			m[k] = annotationValue(e)
+		}
+		return m
+	case []interface{}:
+		l := make([]interface{}, len(v))
+		for i, e := range v {
+			l[i] = annotationValue(e)
+		}
+		return l
+	}
+	return v
+}
+
 func generateChannelSelectors(model ast.Model, channel ast.ModelChannel) *kind.Labels {
 	labels := kind.Labels{"model": model.Name}
 	if strings.HasSuffix(channel.Alias, "_channel") {
@@ -222,7 +235,9 @@ func generateChannelSelectors(model ast.Model, channel ast.ModelChannel) *kind.L
 	return &labels
 }
 
-func generateModelRuntime(model ast.Model, mcl MclInfo) *kind.ModelInstanceRuntime {
+// generateModelRuntime generates the common runtime of a model, the model
+// handler completes the runtime (see ModelHandler.ModelInstance).
+func generateModelRuntime(model ast.Model) *kind.ModelInstanceRuntime {
 	env := map[string]string{}
 	if model.Env != nil {
 		for _, e := range *model.Env {
@@ -246,74 +261,24 @@ func generateModelRuntime(model ast.Model, mcl MclInfo) *kind.ModelInstanceRunti
 			runtime.X32 = &x32
 		}
 	}
-
-	switch mcl.Type {
-	case "lua":
-		files := []string{}
-		runtime.Mcl = &mcl.Type
-		files = append(files, fmt.Sprintf("model/%s/%s", model.Name, path.Base(mcl.Path)))
-		if model.Files != nil {
-			for _, f := range *model.Files {
-				fDir, fName := path.Split(f.Name)
-				switch {
-				case len(fDir) == 0 && strings.EqualFold(path.Ext(fName), ".lua"):
-					files = append(files, fmt.Sprintf("model/%s/lua/%s", model.Name, fName))
-				case len(fDir) == 0:
-					files = append(files, fmt.Sprintf("model/%s/data/%s", model.Name, fName))
-				case strings.HasPrefix(fDir, "./"):
-					// Strip leading ./ and keep any real subdirectory that follows.
-					// e.g : file ./plant.lua plant.lua      -> fDir=""          -> model/{name}/{fName}
-					// e.g : file ./sub_dir/plant.lua plant.lua  -> fDir="sub_dir" -> model/{name}/sub_dir/{fName}
-					strippedDir := strings.TrimRight(strings.TrimPrefix(fDir, "./"), "/")
-					if len(strippedDir) == 0 {
-						files = append(files, fmt.Sprintf("model/%s/%s", model.Name, fName))
-					} else {
-						files = append(files, fmt.Sprintf("model/%s/%s/%s", model.Name, strippedDir, fName))
-					}
-				case strings.EqualFold(path.Ext(fName), ".lua"):
-					// plain subdir (no ./) + lua -> under lua/ folder
-					files = append(files, fmt.Sprintf("model/%s/lua/%s/%s", model.Name, strings.TrimRight(fDir, "/"), fName))
-				default:
-					// plain subdir + non-lua -> model root relative
-					cleanDir := strings.TrimRight(strings.TrimPrefix(fDir, "./"), "/")
-					if len(cleanDir) == 0 {
-						files = append(files, fmt.Sprintf("model/%s/%s", model.Name, fName))
-					} else {
-						files = append(files, fmt.Sprintf("model/%s/%s/%s", model.Name, cleanDir, fName))
-					}
-				}
-			}
-		}
-		runtime.Files = &files
-	case "":
-		paths := &[]string{
-			fmt.Sprintf("model/%s/data", model.Name),
-		}
-		runtime.Paths = paths
-	default:
-		slog.Warn("unknown MCL type", "mcl", mcl.Type)
-	}
-
 	return &runtime
 }
 
-func configureConnection(stack *kind.Stack) {
-	timeout := 60
-	redisTransport := kind.StackSpecConnectionTransport0{
-		Redis: kind.RedisConnection{
-			Timeout: &timeout,
-			Uri:     util.StringPtr("redis://localhost:6379"),
-		},
-	}
-	transport := kind.StackSpec_Connection_Transport{}
-	transport.FromStackSpecConnectionTransport0(redisTransport)
-	connection := struct {
-		Timeout   *string                              `yaml:"timeout,omitempty"`
-		Transport *kind.StackSpec_Connection_Transport `yaml:"transport,omitempty"`
-	}{
-		Transport: &transport,
+// ModelInstance returns the ModelInstance (model name and runtime, without
+// channels) which is generated for a model of the simulation, and the name of
+// the model handler.
+func ModelInstance(model ast.Model, simSpec ast.SimulationSpec) (*kind.ModelInstance, string) {
+	mi := kind.ModelInstance{
+		Name: model.Name,
+		Model: &struct {
+			Name string `yaml:"name"`
+		}{},
+		Runtime: generateModelRuntime(model),
 	}
-	stack.Spec.Connection = &connection
+	mc, _ := newModelContext(model, simSpec)
+	h := modelHandlerFor(mc)
+	h.ModelInstance(mc, &mi)
+	return &mi, h.Name()
 }
 
 func generateSimbusModel(simSpec ast.SimulationSpec) *kind.ModelInstance {
@@ -323,10 +288,15 @@ func generateSimbusModel(simSpec ast.SimulationSpec) *kind.ModelInstance {
 	}
 	for _, stack := range simSpec.Stacks {
 		for _, model := range stack.Models {
+			// Each replica of a (not expanded) model connects to the channel.
+			replicas, err := ModelReplicas(model)
+			if err != nil {
+				replicas = 1
+			}
 			for _, channel := range model.Channels {
 				count, ok := channelMap[channel.Name]
 				if ok {
-					channelMap[channel.Name] = count + 1
+					channelMap[channel.Name] = count + replicas
 				}
 			}
 		}
@@ -100,3 +100,101 @@ func TestGenerateSimulation(t *testing.T) {
 	YamlContains(t, f, "$.spec.models[2].channels[1].selectors.model", "linear")
 
 }
+
+func TestGenerateSimulation_typedAnnotations(t *testing.T) {
+	astFile := filepath.Join(t.TempDir(), "ast.yaml")
+	require.NoError(t, os.WriteFile(astFile, []byte(`---
+kind: Simulation
+metadata:
+  name: typed
+spec:
+  arch: linux-amd64
+  channels:
+    - name: physical
+  stacks:
+    - name: default
+      annotations:
+        connection:
+          transport: redispubsub
+          timeout: 5
+        cycle_time:
+          frame_in: 10
+        trace: true
+      models:
+        - name: input
+          model: dse.modelc.csv
+          channels:
+            - alias: signal_channel
+              name: physical
+          annotations:
+            ratio: 0.5
+            id: "42"
+            ports: [1, 2]
+`), 0644))
+	spec, doc, err := LoadSimulationAst(astFile)
+	require.NoError(t, err)
+	outFolder := filepath.Join("out", "tmp", t.Name())
+	cmd := NewGenerateCommand("test_generate_simulation")
+	require.NoError(t, cmd.Parse([]string{"-simulation"}))
+	require.NoError(t, cmd.GenerateAst(*spec, doc, outFolder))
+
+	f, err := os.ReadFile(filepath.Join(outFolder, "simulation.yaml"))
+	require.NoError(t, err)
+	var stack map[string]interface{}
+	require.NoError(t, yaml.Unmarshal(f, &stack))
+	annotations := stack["metadata"].(map[string]interface{})["annotations"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"frame_in": 10}, annotations["cycle_time"])
+	assert.Equal(t, true, annotations["trace"])
+	YamlContains(t, f, "$.spec.connection.transport.redispubsub.timeout", "5")
+
+	models := stack["spec"].(map[string]interface{})["models"].([]interface{})
+	annotations = models[1].(map[string]interface{})["annotations"].(map[string]interface{})
+	assert.Equal(t, 0.5, annotations["ratio"])
+	assert.Equal(t, "42", annotations["id"])
+	assert.Equal(t, []interface{}{1, 2}, annotations["ports"])
+}
+
+func TestGenerateSimulation_sourceAnnotations(t *testing.T) {
+	astFile := filepath.Join(t.TempDir(), "ast.yaml")
+	require.NoError(t, os.WriteFile(astFile, []byte(`---
+kind: Simulation
+metadata:
+  name: source
+spec:
+  arch: linux-amd64
+  channels:
+    - name: physical
+  stacks:
+    - name: default
+      annotations:
+        sdp/source: openloop.dse:8:1
+        sdp/source/workflow/generate/var/NODE: openloop.dse:10:5
+      models:
+        - name: input
+          model: dse.modelc.csv
+          channels:
+            - alias: signal_channel
+              name: physical
+          annotations:
+            sdp/source: openloop.dse:12:1
+            sdp/source/channel/physical: openloop.dse:13:3
+`), 0644))
+	spec, doc, err := LoadSimulationAst(astFile)
+	require.NoError(t, err)
+	outFolder := filepath.Join("out", "tmp", t.Name())
+	cmd := NewGenerateCommand("test_generate_simulation")
+	require.NoError(t, cmd.Parse([]string{"-simulation"}))
+	require.NoError(t, cmd.GenerateAst(*spec, doc, outFolder))
+
+	// Only the source location of the stack and model instance is written.
+	f, err := os.ReadFile(filepath.Join(outFolder, "simulation.yaml"))
+	require.NoError(t, err)
+	var stack map[string]interface{}
+	require.NoError(t, yaml.Unmarshal(f, &stack))
+	annotations := stack["metadata"].(map[string]interface{})["annotations"].(map[string]interface{})
+	assert.Equal(t, "openloop.dse:8:1", annotations["sdp/source"])
+	assert.NotContains(t, annotations, "sdp/source/workflow/generate/var/NODE")
+	models := stack["spec"].(map[string]interface{})["models"].([]interface{})
+	annotations = models[1].(map[string]interface{})["annotations"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"sdp/source": "openloop.dse:12:1"}, annotations)
+}
@@ -8,11 +8,15 @@ import (
 	"bytes"
 	"flag"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
+	"strings"
 
 	"github.com/elliotchance/orderedmap/v2"
+	"gopkg.in/yaml.v3"
 	//	"github.com/elliotchance/orderedmap/v2"
 
 	"github.com/boschglobal/dse.clib/extra/go/command/util"
@@ -32,12 +36,81 @@ func (vm OMap) MarshalYAML() (interface{}, error) {
 	return s, nil
 }
 
+// UnmarshalYAML decodes a vars (or env) map, preserving the order. Dynamic
+// variables (sh: <command>) are represented as a command substitution.
+func (vm *OMap) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: vars must be a map", value.Line)
+	}
+	vm.OrderedMap = orderedmap.NewOrderedMap[string, string]()
+	for i := 0; i+1 < len(value.Content); i += 2 {
+		k, v := value.Content[i].Value, value.Content[i+1]
+		switch v.Kind {
+		case yaml.ScalarNode:
+			vm.Set(k, v.Value)
+		case yaml.MappingNode:
+			var dynamic struct {
+				Sh string `yaml:"sh"`
+			}
+			if err := v.Decode(&dynamic); err != nil || dynamic.Sh == "" {
+				return fmt.Errorf("line %d: unsupported var '%s'", v.Line, k)
+			}
+			vm.Set(k, fmt.Sprintf("$(%s)", strings.TrimSpace(dynamic.Sh)))
+		default:
+			return fmt.Errorf("line %d: unsupported var '%s'", v.Line, k)
+		}
+	}
+	return nil
+}
+
 type Cmd struct {
 	Cmd  string             `yaml:"cmd,omitempty"`
 	Task string             `yaml:"task,omitempty"`
 	Vars *map[string]string `yaml:"vars,omitempty"`
 }
 
+// UnmarshalYAML decodes a command, either a string or a map (cmd or task).
+// Commands for other platforms (than linux) and deferred commands are
+// dropped, ignore_error is represented in the command.
+func (c *Cmd) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		c.Cmd = value.Value
+		return nil
+	}
+	var cmd struct {
+		Cmd         string         `yaml:"cmd"`
+		Task        string         `yaml:"task"`
+		Vars        map[string]any `yaml:"vars"`
+		IgnoreError bool           `yaml:"ignore_error"`
+		Platforms   []string       `yaml:"platforms"`
+		Defer       any            `yaml:"defer"`
+	}
+	if err := value.Decode(&cmd); err != nil {
+		return err
+	}
+	if cmd.Defer != nil {
+		slog.Warn(fmt.Sprintf("line %d: deferred command not supported (ignored)", value.Line))
+		return nil
+	}
+	if len(cmd.Platforms) > 0 && !slices.ContainsFunc(cmd.Platforms, func(p string) bool {
+		return strings.HasPrefix(p, "linux")
+	}) {
+		return nil
+	}
+	c.Cmd, c.Task = cmd.Cmd, cmd.Task
+	if c.Cmd != "" && cmd.IgnoreError {
+		c.Cmd = fmt.Sprintf("{ %s\n} || true", c.Cmd)
+	}
+	if len(cmd.Vars) > 0 {
+		vars := map[string]string{}
+		for k, v := range cmd.Vars {
+			vars[k] = fmt.Sprint(v)
+		}
+		c.Vars = &vars
+	}
+	return nil
+}
+
 func (c Cmd) MarshalYAML() (interface{}, error) {
 	if c.Cmd != "" {
 		return c.Cmd, nil
@@ -59,6 +132,23 @@ type Dep struct {
 	Vars *OMap `yaml:"vars,omitempty"`
 }
 
+// UnmarshalYAML decodes a dependency, either a task name or a map.
+func (d *Dep) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		d.Task = value.Value
+		return nil
+	}
+	var dep struct {
+		Task string `yaml:"task"`
+		Vars *OMap  `yaml:"vars"`
+	}
+	if err := value.Decode(&dep); err != nil {
+		return err
+	}
+	d.Task, d.Vars = dep.Task, dep.Vars
+	return nil
+}
+
 type Requires struct {
 	Vars *[]string `yaml:"vars,omitempty"`
 }
@@ -76,12 +166,43 @@ type Task struct {
 	Sources   *[]string `yaml:"sources,omitempty"`
 	Generates *[]string `yaml:"generates,omitempty"`
 	Status    *[]string `yaml:"status,omitempty"`
+	Env       *OMap     `yaml:"env,omitempty"`
+}
+
+// UnmarshalYAML decodes a task, including the short forms (a command or a
+// list of commands).
+func (t *Task) UnmarshalYAML(value *yaml.Node) error {
+	switch value.Kind {
+	case yaml.ScalarNode:
+		t.Cmds = &[]Cmd{{Cmd: value.Value}}
+		return nil
+	case yaml.SequenceNode:
+		var cmds []Cmd
+		if err := value.Decode(&cmds); err != nil {
+			return err
+		}
+		t.Cmds = &cmds
+		return nil
+	}
+	type task Task
+	return value.Decode((*task)(t))
 }
 
 type Include struct {
 	Taskfile string             `yaml:"taskfile,omitempty"`
 	Dir      string             `yaml:"dir,omitempty"`
 	Vars     *map[string]string `yaml:"vars,omitempty"`
+	Optional bool               `yaml:"optional,omitempty"`
+}
+
+// UnmarshalYAML decodes an include, either a Taskfile path or a map.
+func (i *Include) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		i.Taskfile = value.Value
+		return nil
+	}
+	type include Include
+	return value.Decode((*include)(i))
 }
 
 type Taskfile struct {
@@ -89,6 +210,7 @@ type Taskfile struct {
 	Includes *map[string]Include `yaml:"includes,omitempty"`
 	//Vars     *map[string]string  `yaml:"vars,omitempty"`
 	Vars  *OMap            `yaml:"vars,omitempty"`
+	Env   *OMap            `yaml:"env,omitempty"`
 	Tasks *map[string]Task `yaml:"tasks,omitempty"`
 }
 
@@ -98,10 +220,40 @@ func cleanTag(tag string) string {
 }
 
 func (c GenerateCommand) GenerateTaskfile() error {
-	var taskfilePath = filepath.Join(c.outputPath, "Taskfile.yml")
+	var taskfilePath = filepath.Join(c.outputPath, backendFiles[BackendTaskfile])
 
 	fmt.Fprintf(flag.CommandLine.Output(), "Writing taskfile: %s\n", taskfilePath)
 
+	taskfile, err := c.buildTaskfile(backendFiles[BackendTaskfile])
+	if err != nil {
+		return err
+	}
+	if err := util.WriteYaml(taskfile, taskfilePath, false); err != nil {
+		return err
+	}
+	// Correct sorted Vars in the generated YAML.
+	data, err := os.ReadFile(taskfilePath)
+	if err != nil {
+		return err
+	}
+	os.WriteFile(taskfilePath, bytes.ReplaceAll(data, []byte("vars: |2-"), []byte("vars:")), 0644)
+
+	return nil
+}
+
+// buildOutDir returns the output folder of the build (OUTDIR), relative to the
+// project folder.
+func (c GenerateCommand) buildOutDir() string {
+	if c.outDir == "" {
+		return "out"
+	}
+	return c.outDir
+}
+
+// buildTaskfile builds the task model of the simulation, the build file is
+// retained by the clean task.
+func (c GenerateCommand) buildTaskfile(buildFile string) (*Taskfile, error) {
+	outDir := c.buildOutDir()
 	// Setup the basic Taskfile structure.
 	taskfile := Taskfile{
 		Version: "3",
@@ -114,31 +266,31 @@ func (c GenerateCommand) GenerateTaskfile() error {
 					return "linux-amd64"
 				}
 			}())
-			om.Set("OUT", "out")
-			om.Set("OUTDIR", "{{.PWD}}/out")
+			om.Set("OUT", outDir)
+			om.Set("OUTDIR", "{{.PWD}}/"+outDir)
 			om.Set("SIMDIR", "sim")
 			om.Set("PROJDIR", "{{if .PROJDIR}}{{.PROJDIR}}{{else}}{{.PWD}}{{end}}")
 			om.Set("CONTAINER_WORKDIR", "{{if .ENTRYWORKDIR}}{{.ENTRYWORKDIR}}{{else}}{{.PWD}}{{end}}")
-			om.Set("CONTAINER_SIMDIR", "{{if .ENTRYWORKDIR}}{{.ENTRYWORKDIR}}{{else}}{{.PWD}}{{end}}/out/{{.SIMDIR}}")
+			om.Set("CONTAINER_SIMDIR", "{{if .ENTRYWORKDIR}}{{.ENTRYWORKDIR}}{{else}}{{.PWD}}{{end}}/"+outDir+"/{{.SIMDIR}}")
 			return &om
 		}(),
 	}
 	tasks := make(map[string]Task)
-	for k, v := range buildSimulationTasks(c.simulationAst) {
+	for k, v := range buildSimulationTasks(c.simulationAst, c.overrides, outDir) {
 		tasks[k] = v
 	}
-	for k, v := range buildBaseTasks() {
+	for k, v := range buildBaseTasks(buildFile, outDir) {
 		tasks[k] = v
 	}
 
 	// Build the Model Tasks and associated Includes.
-	includes := make(map[string]Include)
-	for k, v := range c.buildIncludes() {
-		includes[k] = v
+	includes, err := c.buildIncludes()
+	if err != nil {
+		return nil, err
 	}
 	modelTasks, err := c.buildModelTasks()
 	if err != nil {
-		return err
+		return nil, err
 	}
 	for k, v := range modelTasks {
 		tasks[k] = v
@@ -149,15 +301,10 @@ func (c GenerateCommand) GenerateTaskfile() error {
 	// Finalise the Taskfile.
 	taskfile.Tasks = &tasks
 	taskfile.Includes = &includes
-	if err := util.WriteYaml(&taskfile, taskfilePath, false); err != nil {
-		return err
-	}
-	// Correct sorted Vars in the generated YAML.
-	data, err := os.ReadFile(taskfilePath)
-	if err != nil {
-		return err
+	if c.vendorIndex != nil {
+		if err := c.vendorDownloads(&taskfile); err != nil {
+			return nil, err
+		}
 	}
-	os.WriteFile(taskfilePath, bytes.ReplaceAll(data, []byte("vars: |2-"), []byte("vars:")), 0644)
-
-	return nil
+	return &taskfile, nil
 }
@@ -12,9 +12,16 @@ import (
 
 	"github.com/boschglobal/dse.clib/extra/go/command/util"
 	"github.com/boschglobal/dse.schemas/code/go/dse/ast"
+
+	"github.com/boschglobal/dse.sdp/ast/internal/pkg/override"
 )
 
-func buildBaseTasks() map[string]Task {
+// downloadStatus is the status of the download tasks, a downloaded file is
+// up to date when it exists and matches the SHA-256 pin (if any), otherwise
+// the file is downloaded (and verified) again.
+const downloadStatus = "test -f {{.FILE}} && { [ -z \"{{.SHA256}}\" ] || echo \"{{.SHA256}}  {{.FILE}}\" | sha256sum -c --status -; }"
+
+func buildBaseTasks(buildFile string, outDir string) map[string]Task {
 	baseTasks := map[string]Task{
 		"unzip-file": {
 			Dir:   util.StringPtr("{{.OUTDIR}}"),
@@ -145,15 +152,17 @@ fi`},
 				om.Set("URL", "{{.URL}}")
 				om.Set("FILE", "{{.FILE}}")
 				om.Set("AUTH", "{{if all .USER .TOKEN}}-u {{.USER}}:{{.TOKEN}}{{else}}{{end}}")
+				om.Set("SHA256", "{{.SHA256}}")
 				return &om
 			}(),
 			Cmds: &[]Cmd{
 				{Cmd: "echo \"CURL {{.URL}} -> {{.FILE}}\""},
 				{Cmd: "mkdir -p $(dirname {{.FILE}})"},
 				{Cmd: "curl --retry 5 {{.AUTH}} -fL {{.URL}} -o {{.FILE}}"},
+				{Cmd: "if [ -n \"{{.SHA256}}\" ]; then echo \"{{.SHA256}}  {{.FILE}}\" | sha256sum -c - || { rm -f {{.FILE}}; exit 1; }; fi"},
 			},
 			Generates: &[]string{"{{.FILE}}"},
-			Status:    &[]string{"test -f {{.FILE}}"},
+			Status:    &[]string{downloadStatus},
 		},
 		"copy-file": {
 			Dir:   util.StringPtr("{{.OUTDIR}}"),
@@ -185,6 +194,7 @@ fi`},
 				om.Set("ASSET_NAME", "{{.ASSET_NAME}}")
 				om.Set("TAG", "{{.TAG}}")
 				om.Set("API_URL", "{{.API_URL}}")
+				om.Set("SHA256", "{{.SHA256}}")
 				return &om
 			}(),
 			Cmds: &[]Cmd{
@@ -200,25 +210,26 @@ fi`},
 					"  -H \"Authorization: Bearer {{.TOKEN}}\" \\\n" +
 					"  -fL $ASSET_URL \\\n" +
 					"  -o {{.FILE}}\n"},
+				{Cmd: "if [ -n \"{{.SHA256}}\" ]; then echo \"{{.SHA256}}  {{.FILE}}\" | sha256sum -c - || { rm -f {{.FILE}}; exit 1; }; fi"},
 			},
 			Generates: &[]string{"{{.FILE}}"},
-			Status:    &[]string{"test -f {{.FILE}}"},
+			Status:    &[]string{downloadStatus},
 		},
 		"clean": {
 			Cmds: &[]Cmd{
-				{Cmd: "find ./out -mindepth 1 -maxdepth 1 ! -name downloads ! -name simulation.json ! -name simulation.yaml ! -name Taskfile.yml -exec rm -rf {} +"},
+				{Cmd: fmt.Sprintf("find ./%s -mindepth 1 -maxdepth 1 ! -name downloads ! -name simulation.json ! -name simulation.yaml ! -name %s -exec rm -rf {} +", outDir, buildFile)},
 			},
 		},
 		"cleanall": {
 			Cmds: &[]Cmd{
-				{Cmd: "rm -rf ./out"},
+				{Cmd: fmt.Sprintf("rm -rf ./%s", outDir)},
 			},
 		},
 	}
 	return baseTasks
 }
 
-func buildSimulationTasks(simSpec ast.SimulationSpec) map[string]Task {
+func buildSimulationTasks(simSpec ast.SimulationSpec, overrides override.Overrides, outDir string) map[string]Task {
 	// Build the _sequential_ build commands (order is important).
 	// Sim folder setup.
 	buildCmds := []Cmd{
@@ -241,6 +252,23 @@ func buildSimulationTasks(simSpec ast.SimulationSpec) map[string]Task {
 		})
 	}
 
+	// Info (with the overridden uses entries).
+	infoCmds := []Cmd{
+		{Cmd: "echo \"=== Info ===\""},
+		{Cmd: "echo \"CONTAINER_WORKDIR  = {{.CONTAINER_WORKDIR}}\""},
+		{Cmd: "echo \"CONTAINER_SIMDIR   = {{.CONTAINER_SIMDIR}}\""},
+		{Cmd: "echo \"ENTRYWORKDIR       = {{.ENTRYWORKDIR}}\""},
+		{Cmd: "echo \"OUTDIR             = {{.OUTDIR}}\""},
+		{Cmd: "echo \"PLATFORM_ARCH      = {{.PLATFORM_ARCH}}\""},
+		{Cmd: "echo \"PROJDIR            = {{.PROJDIR}}\""},
+		{Cmd: "echo \"PWD                = {{.PWD}}\""},
+		{Cmd: "echo \"SIM                = {{.SIM}}\""},
+		{Cmd: "echo \"SIMDIR             = {{.SIMDIR}}\""},
+		{Cmd: "echo \"WORKDIR            = {{.WORKDIR}}\""},
+	}
+	infoCmds = append(infoCmds, overrideInfoCmds(overrides)...)
+	infoCmds = append(infoCmds, Cmd{Cmd: "echo \"============\""})
+
 	// Construct the simulation tasks.
 	simulationTasks := map[string]Task{
 		"default": {
@@ -252,20 +280,7 @@ func buildSimulationTasks(simSpec ast.SimulationSpec) map[string]Task {
 			Run:    util.StringPtr("always"),
 			Label:  util.StringPtr("info"),
 			Silent: func(silent bool) *bool { return &silent }(true),
-			Cmds: &[]Cmd{
-				{Cmd: "echo \"=== Info ===\""},
-				{Cmd: "echo \"CONTAINER_WORKDIR  = {{.CONTAINER_WORKDIR}}\""},
-				{Cmd: "echo \"CONTAINER_SIMDIR   = {{.CONTAINER_SIMDIR}}\""},
-				{Cmd: "echo \"ENTRYWORKDIR       = {{.ENTRYWORKDIR}}\""},
-				{Cmd: "echo \"OUTDIR             = {{.OUTDIR}}\""},
-				{Cmd: "echo \"PLATFORM_ARCH      = {{.PLATFORM_ARCH}}\""},
-				{Cmd: "echo \"PROJDIR            = {{.PROJDIR}}\""},
-				{Cmd: "echo \"PWD                = {{.PWD}}\""},
-				{Cmd: "echo \"SIM                = {{.SIM}}\""},
-				{Cmd: "echo \"SIMDIR             = {{.SIMDIR}}\""},
-				{Cmd: "echo \"WORKDIR            = {{.WORKDIR}}\""},
-				{Cmd: "echo \"============\""},
-			},
+			Cmds:   &infoCmds,
 		},
 		"build": {
 			Dir:   util.StringPtr("{{.OUTDIR}}"),
@@ -278,7 +293,7 @@ func buildSimulationTasks(simSpec ast.SimulationSpec) map[string]Task {
 			Cmds: &[]Cmd{
 				{Cmd: "mkdir -p {{.SIMDIR}}/data"},
 				{Cmd: "mkdir -p {{.SIMDIR}}/trace"},
-				{Cmd: "cp {{.PROJDIR}}/out/simulation.yaml {{.SIMDIR}}/data/simulation.yaml"},
+				{Cmd: fmt.Sprintf("cp {{.PROJDIR}}/%s/simulation.yaml {{.SIMDIR}}/data/simulation.yaml", outDir)},
 			},
 			Sources:   &[]string{"{{.PROJDIR}}/simulation.yaml"},
 			Generates: &[]string{"{{.SIMDIR}}/data/simulation.yaml"},
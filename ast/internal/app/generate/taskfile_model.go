@@ -6,6 +6,7 @@ package generate
 
 import (
 	"fmt"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"os"
@@ -17,6 +18,10 @@ import (
 
 	"github.com/boschglobal/dse.clib/extra/go/command/util"
 	"github.com/boschglobal/dse.schemas/code/go/dse/ast"
+
+	"github.com/boschglobal/dse.sdp/ast/internal/pkg/artifact"
+	"github.com/boschglobal/dse.sdp/ast/internal/pkg/provider"
+	"github.com/boschglobal/dse.sdp/ast/internal/pkg/source"
 )
 
 var templateVarRegex = regexp.MustCompile(`\{\{\s*\.(\w+)\s*\}\}`)
@@ -59,54 +64,35 @@ func urlEscapedParse(u string) (*url.URL, error) {
 	return url.Parse(u)
 }
 
-func resolveRemoteTaskfile(u *url.URL, owner, repo, version string) string {
-	for _, name := range []string{"Taskfile.sdp.yml", "Taskfile.sdp.yaml", "Taskfile.yml", "Taskfile.yaml"} {
-		resolvedURL := *u
-		var finalUrl *url.URL
-		switch resolvedURL.Host {
-		case "github.com":
-			resolvedURL.Host = "raw.githubusercontent.com"
-			finalUrl, _ = resolvedURL.Parse(fmt.Sprintf("/%s/%s/refs/tags/%s/%s", owner, repo, version, name))
-		case "github.boschdevcloud.com":
-			resolvedURL.Host = "raw.github.boschdevcloud.com"
-			finalUrl, _ = resolvedURL.Parse(fmt.Sprintf("/%s/%s/%s/%s", owner, repo, version, name))
-		default:
-			continue
-		}
-
-		probeUrl := func() string {
-			_u := finalUrl.String()
-			_u = strings.ReplaceAll(_u, `%7B`, `{`)
-			_u = strings.ReplaceAll(_u, `%7D`, `}`)
-			_u = strings.ReplaceAll(_u, "{{.GHE_TOKEN}}", os.Getenv("GHE_TOKEN"))
-			return _u
-		}()
-
+func resolveRemoteTaskfile(providers *provider.Registry, repoUrl string, version string) string {
+	for _, taskfileUrl := range providers.TaskfileURLs(repoUrl, version) {
+		probeUrl := strings.ReplaceAll(taskfileUrl, "{{.GHE_TOKEN}}", os.Getenv("GHE_TOKEN"))
 		req, _ := http.NewRequest(http.MethodHead, probeUrl, nil)
 		resp, err := http.DefaultClient.Do(req)
-		if err == nil && resp.StatusCode == http.StatusOK {
-			_u := finalUrl.String()
-			_u = strings.ReplaceAll(_u, `%7B`, `{`)
-			_u = strings.ReplaceAll(_u, `%7D`, `}`)
-			return _u
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				return taskfileUrl
+			}
 		}
 	}
 	return ""
 }
 
-func (c GenerateCommand) buildIncludes() map[string]Include {
+func (c GenerateCommand) buildIncludes() (map[string]Include, error) {
 	includes := make(map[string]Include)
 	simSpec := c.simulationAst
 
 	if simSpec.Uses == nil {
-		return includes
+		return includes, nil
 	}
+	missing := []string{}
 
 	for _, uses := range *simSpec.Uses {
 		u, _ := urlEscapedParse(uses.Url)
 
 		vars := map[string]string{
-			"SIM":          "out/{{.SIMDIR}}",
+			"SIM":          c.buildOutDir() + "/{{.SIMDIR}}",
 			"ENTRYWORKDIR": "{{if .ENTRYWORKDIR}}{{.ENTRYWORKDIR}}{{else}}{{.PWD}}{{end}}",
 		}
 
@@ -172,29 +158,49 @@ func (c GenerateCommand) buildIncludes() map[string]Include {
 			if uses.Token != nil {
 				vars["DOCKER_TOKEN"] = *uses.Token
 			}
-			if strings.HasPrefix(u.Host, "github.") == false {
+			if c.providers.Lookup(u.Host) == nil {
 				continue
 			}
-			pathParts := strings.Split(u.Path, string(os.PathSeparator))
-			taskfile := resolveRemoteTaskfile(
-				u,
-				pathParts[1],
-				pathParts[2],
-				*uses.Version,
-			)
+			var taskfile string
+			switch {
+			case c.vendorIndex != nil:
+				taskfile = c.vendoredTaskfile(uses.Url, *uses.Version)
+				if taskfile == "" {
+					slog.Warn("Remote Taskfile not vendored", "uses", uses.Name, "url", uses.Url, "version", *uses.Version)
+				}
+			case c.backend != BackendTaskfile:
+				// The build file backends read the Taskfile when generating,
+				// from a local copy (no network access).
+				for _, taskfileUrl := range c.providers.TaskfileURLs(uses.Url, *uses.Version) {
+					if taskfile = c.localTaskfile(taskfileUrl); taskfile != "" {
+						break
+					}
+				}
+				if taskfile == "" {
+					missing = append(missing, fmt.Sprintf("%s (url=%s, version=%s)", uses.Name, uses.Url, *uses.Version))
+				}
+			default:
+				taskfile = resolveRemoteTaskfile(c.providers, uses.Url, *uses.Version)
+				if taskfile == "" {
+					slog.Warn("Remote Taskfile not available", "uses", uses.Name, "url", uses.Url, "version", *uses.Version)
+				}
+			}
 
 			if taskfile == "" {
 				continue
 			}
 			includes[fmt.Sprintf("%s-%s", uses.Name, *uses.Version)] = Include{
 				Taskfile: taskfile,
-				Dir:      "{{if .ENTRYWORKDIR}}{{.WORKDIR}}{{else}}{{.PWD}}{{end}}/out/{{.SIMDIR}}",
+				Dir:      "{{if .ENTRYWORKDIR}}{{.WORKDIR}}{{else}}{{.PWD}}{{end}}/" + c.buildOutDir() + "/{{.SIMDIR}}",
 				Vars:     &vars,
 			}
 		}
 	}
 
-	return includes
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("remote Taskfile not available for uses %s (run 'ast resolve' or 'ast vendor' first)", strings.Join(missing, ", "))
+	}
+	return includes, nil
 }
 
 func createModelDownloadDeps(modelUses ast.Uses, mcl MclInfo) []Dep {
@@ -221,6 +227,9 @@ func createModelDownloadDeps(modelUses ast.Uses, mcl MclInfo) []Dep {
 						om.Set("TOKEN", tokenValue)
 					}
 				}
+				if sha := downloadSha256(&modelUses); sha != "" {
+					om.Set("SHA256", sha)
+				}
 				return &om
 			}(),
 		},
@@ -228,24 +237,6 @@ func createModelDownloadDeps(modelUses ast.Uses, mcl MclInfo) []Dep {
 	return deps
 }
 
-func createRemoteLuaDeps(modelUses ast.Uses, mcl MclInfo, cmds *[]Cmd) []Dep {
-	deps := []Dep{}
-
-	deps = createModelDownloadDeps(modelUses, mcl)
-	if mcl.Path == "" { // remote standalone lua model url
-		*cmds = append(*cmds,
-			Cmd{Cmd: "cp downloads/{{base .PACKAGE_URL}} {{.SIMDIR}}/{{.PATH}}/{{base .PACKAGE_URL}}"},
-		)
-	} else { // remote archive url with lua model path
-		pathDir := filepath.Dir(mcl.Path)
-		*cmds = append(
-			*cmds,
-			Cmd{Cmd: fmt.Sprintf("unzip -ojq downloads/{{base .PACKAGE_URL}} '%s/*' -d {{.SIMDIR}}/{{.PATH}} 2>/dev/null", pathDir)},
-		)
-	}
-	return deps
-}
-
 func createModelCopyDeps(mcl MclInfo) []Dep {
 	deps := []Dep{}
 	if mcl.Type == "lua" && mcl.Path != "" { // path within zip file
@@ -340,9 +331,10 @@ func resolveMclFromUses(u ast.Uses) (MclInfo, bool) {
 	return MclInfo{Type: ""}, false
 }
 
-func genericModelTask(model ast.Model, modelUses ast.Uses) Task {
-	mcl, _ := resolveMclFromUses(modelUses)
-	modelUrl, _ := urlEscapedParse(modelUses.Url)
+// genericModelTask builds the model Task, the model type specific vars, deps,
+// cmds, sources and generates are provided by the model handler.
+func genericModelTask(mc *ModelContext, h ModelHandler) Task {
+	model := mc.Model
 	cmds := []Cmd{
 		{
 			Cmd: fmt.Sprintf("echo \"SIM Model %s -> {{.SIMDIR}}/{{.PATH}}\"", model.Name),
@@ -351,49 +343,15 @@ func genericModelTask(model ast.Model, modelUses ast.Uses) Task {
 			Cmd: "mkdir -p {{.SIMDIR}}/{{.PATH}}/data",
 		},
 	}
-	deps := func() []Dep {
-		switch modelUrl.Scheme {
-		case "file":
-			// <repo>/<package:file> -> downloads/base(<package:file>)
-			return createModelCopyDeps(mcl)
-		case "https":
-			if mcl.Type == "lua" {
-				return createRemoteLuaDeps(modelUses, mcl, &cmds)
-			} else {
-				return createModelDownloadDeps(modelUses, mcl)
-			}
-		default:
-			return nil
-		}
-	}()
-	sources := []string{}
-	generates := func() []string {
-		return []string{
-			//"downloads/{{base .PACKAGE_URL}}", // TODO: handled by deps
-		}
-	}()
-	md := map[string]interface{}{}
-	if model.Metadata != nil {
-		md = *model.Metadata
-	}
+	cmds = append(cmds, h.Cmds(mc)...)
+	deps := h.Deps(mc)
+	sources := append([]string{}, h.Sources(mc)...)
+	generates := append([]string{}, h.Generates(mc)...)
 
 	modelTask := Task{
 		Dir:   util.StringPtr("{{.OUTDIR}}"),
 		Label: util.StringPtr(fmt.Sprintf("sim:model:%s", model.Name)),
 		Vars: func() *OMap {
-			pkgUrlKey := "download"
-			repo := modelUses.Url
-			u, _ := url.Parse(repo)
-			if modelUrl.Scheme == "file" {
-				// When the URL is a "file", use the package:file key.
-				if mcl.Type == "lua" { // eg u.Path : /mnt/c/Users/hello.lua
-					repo = filepath.Dir(u.Path)
-				} else {
-					pkgUrlKey = "file"
-					repo = strings.TrimSuffix(modelUrl.Path, "/") // eg modelUrl.Path : /mnt/c/Users/dse.sdp/
-				}
-			}
-
 			om := OMap{orderedmap.NewOrderedMap[string, string]()}
 			if model.Vars != nil {
 				for _, v := range *model.Vars {
@@ -403,10 +361,10 @@ func genericModelTask(model ast.Model, modelUses ast.Uses) Task {
 					om.Set(v.Name, v.Value)
 				}
 			}
-			if modelUses.Name != "" {
-				om.Set("REPO", repo)
-				if modelUses.Version != nil {
-					om.Set("TAG", cleanTag(*modelUses.Version))
+			if mc.Uses.Name != "" {
+				om.Set("REPO", mc.Uses.Url)
+				if mc.Uses.Version != nil {
+					om.Set("TAG", cleanTag(*mc.Uses.Version))
 				}
 			}
 			om.Set("MODEL", model.Name)
@@ -414,27 +372,7 @@ func genericModelTask(model ast.Model, modelUses ast.Uses) Task {
 			if model.Arch != nil {
 				om.Set("PLATFORM_ARCH", *model.Arch)
 			}
-
-			func() {
-				defer func() {
-					if r := recover(); r != nil {
-					}
-				}()
-				switch mcl.Type {
-				case "lua":
-					if modelUrl.Scheme == "file" {
-						om.Set("PACKAGE_URL", filepath.Base(u.Path))
-						om.Set("PACKAGE_PATH", mcl.Path)
-					} else if modelUrl.Scheme == "https" {
-						om.Set("PACKAGE_URL", mcl.Url)
-						om.Set("PACKAGE_PATH", mcl.Path)
-					}
-				case "":
-					om.Set("PACKAGE_URL", md["package"].(map[string]interface{})[pkgUrlKey].(string))
-					om.Set("PACKAGE_PATH", md["models"].(map[string]interface{})[model.Model].(map[string]interface{})["path"].(string))
-				}
-			}()
-
+			h.Vars(mc, &om)
 			return &om
 		}(),
 		Deps:      &deps,
@@ -445,6 +383,13 @@ func genericModelTask(model ast.Model, modelUses ast.Uses) Task {
 	return modelTask
 }
 
+// downloadSha256 returns the SHA-256 pin of a uses entry, which is verified by
+// the download task: the pin of the file, or for a repository (uses with
+// version) the pin of its package (or asset).
+func downloadSha256(uses *ast.Uses) string {
+	return artifact.UsesSha256(*uses)
+}
+
 func parseUrl(task *Task, uses *ast.Uses, modelName string) string {
 	u, _ := url.Parse(uses.Url)
 	downloadFile := fmt.Sprintf("downloads/models/{{.MODEL}}/%s", filepath.Base(u.Path))
@@ -461,6 +406,9 @@ func parseUrl(task *Task, uses *ast.Uses, modelName string) string {
 					if uses.Token != nil {
 						om.Set("TOKEN", *uses.Token)
 					}
+					if sha := downloadSha256(uses); sha != "" {
+						om.Set("SHA256", sha)
+					}
 					pathParts := strings.Split(u.Path, string(os.PathSeparator))
 					om.Set("ASSET_NAME", pathParts[len(pathParts)-1])
 					om.Set("TAG", pathParts[len(pathParts)-2])
@@ -494,6 +442,9 @@ func parseUrl(task *Task, uses *ast.Uses, modelName string) string {
 							om.Set("TOKEN", tokenValue)
 						}
 					}
+					if sha := downloadSha256(uses); sha != "" {
+						om.Set("SHA256", sha)
+					}
 					return &om
 				}(),
 			})
@@ -515,94 +466,61 @@ func parseUrl(task *Task, uses *ast.Uses, modelName string) string {
 	return downloadFile
 }
 
-func buildModel(model ast.Model, simSpec ast.SimulationSpec) (Task, error) {
-	var modelUses ast.Uses
-	if len(model.Uses) > 0 {
-		for _, uses := range *simSpec.Uses {
-			if uses.Name == model.Uses {
-				modelUses = uses
-				break
-			}
-		}
-		if modelUses.Name == "" {
-			return Task{}, fmt.Errorf("Model uses not found in simulation AST (name=%s)", model.Uses)
-		}
+// modelFileDir returns the folder (relative to the model folder, with a
+// trailing /) and the name of a model file.
+func modelFileDir(name string) (dir string, file string) {
+	dir, file = filepath.Split(name)
+	switch {
+	case len(dir) == 0 && strings.EqualFold(filepath.Ext(file), ".lua"):
+		dir = "lua/"
+	case len(dir) == 0:
+		dir = "data/"
+	case strings.HasPrefix(dir, "./"):
+		// ./ prefix -> relative to model root (any file type)
+		cleanDir := strings.TrimRight(strings.TrimPrefix(dir, "./"), "/")
+		if len(cleanDir) == 0 {
+			dir = ""
+		} else {
+			dir = cleanDir + "/"
+		}
+	case strings.EqualFold(filepath.Ext(file), ".lua"):
+		// plain subdir + lua -> under lua/ folder
+		cleanDir := strings.TrimRight(dir, "/")
+		dir = fmt.Sprintf("lua/%s/", cleanDir)
+	default:
+		// plain subdir + non-lua -> model root relative (dir unchanged)
 	}
-	usesDownloadFilePaths := map[string]string{}
+	return dir, file
+}
 
-	md := map[string]interface{}{}
-	if model.Metadata != nil {
-		md = *model.Metadata
-	}
-	modelTask := genericModelTask(model, modelUses)
+// ModelFilePath returns the path of a model file, relative to the simulation
+// folder (e.g. model/input/data/input.csv).
+func ModelFilePath(model ast.Model, name string) string {
+	dir, file := modelFileDir(name)
+	return fmt.Sprintf("model/%s/%s%s", model.Name, dir, file)
+}
 
-	// Parse: modelc package/model files
-	func(task *Task, model ast.Model) {
-		isModel := func() bool {
-			defer func() {
-				if r := recover(); r != nil {
-				}
-			}()
-			if v := md["models"].(map[string]interface{})[model.Model].(map[string]interface{})["path"]; v != nil {
-				return true
-			} else {
-				return false
-			}
-		}
-		if isModel() == false {
-			return
-		}
-		*task.Cmds = append(*task.Cmds, Cmd{
-			Task: "unzip-dir",
-			Vars: &map[string]string{
-				"ZIP":    "downloads/{{base .PACKAGE_URL}}",
-				"ZIPDIR": fmt.Sprintf("{{.PACKAGE_PATH}}"),
-				"DIR":    fmt.Sprintf("{{.SIMDIR}}/{{.PATH}}"),
-			},
-		})
-		*task.Cmds = append(*task.Cmds, Cmd{
-			Cmd: fmt.Sprintf(`find {{.SIMDIR}}/{{.PATH}}/data -type f -name model.yaml -print0 | ` +
-				`xargs -r -0 yq -i '.spec.runtime.dynlib[].path |= sub("(^.*/)?(lib|bin)/"; "{{.PATH}}/$2/")'`,
-			),
-		})
-		*task.Cmds = append(*task.Cmds, Cmd{
-			Cmd: fmt.Sprintf("rm -rf {{.SIMDIR}}/{{.PATH}}/examples"),
-		})
-		*task.Cmds = append(*task.Cmds, Cmd{
-			Cmd: fmt.Sprintf("find {{.SIMDIR}}/{{.PATH}} -type f -name simulation.yaml -print0  | xargs -r -0 rm -f"),
-		})
-		*task.Cmds = append(*task.Cmds, Cmd{
-			Cmd: fmt.Sprintf("find {{.SIMDIR}}/{{.PATH}} -type f -name simulation.yml -print0  | xargs -r -0 rm -f"),
-		})
-		*task.Generates = append(*task.Generates, fmt.Sprintf("{{.SIMDIR}}/{{.PATH}}/**"))
-	}(&modelTask, model)
+// ModelTask returns the Task which is generated for a model of the
+// simulation.
+func ModelTask(model ast.Model, simSpec ast.SimulationSpec) (Task, error) {
+	return buildModel(model, simSpec)
+}
+
+func buildModel(model ast.Model, simSpec ast.SimulationSpec) (Task, error) {
+	mc, err := newModelContext(model, simSpec)
+	if err != nil {
+		return Task{}, err
+	}
+	modelUses := mc.Uses
+	usesDownloadFilePaths := map[string]string{}
+	modelTask := genericModelTask(mc, modelHandlerFor(mc))
 
 	// Parse: user files
 	func(task *Task, model ast.Model) {
 		if model.Files != nil {
 			for _, f := range *model.Files {
 				// Calculate the model relative path (i.e. generates).
-				dir, file := filepath.Split(f.Name)
-				switch {
-				case len(dir) == 0 && strings.EqualFold(filepath.Ext(file), ".lua"):
-					dir = "lua/"
-				case len(dir) == 0:
-					dir = "data/"
-				case strings.HasPrefix(dir, "./"):
-					// ./ prefix -> relative to model root (any file type)
-					cleanDir := strings.TrimRight(strings.TrimPrefix(dir, "./"), "/")
-					if len(cleanDir) == 0 {
-						dir = ""
-					} else {
-						dir = cleanDir + "/"
-					}
-				case strings.EqualFold(filepath.Ext(file), ".lua"):
-					// plain subdir + lua -> under lua/ folder
-					cleanDir := strings.TrimRight(dir, "/")
-					dir = fmt.Sprintf("lua/%s/", cleanDir)
-				default:
-					// plain subdir + non-lua -> model root relative (dir unchanged)
-				}
+				dir, file := modelFileDir(f.Name)
 				// Emit mkdir once, after dir is resolved.
 				// Skip "" (model root already exists) and "data/" (created by genericModelTask).
 				if dir != "" && dir != "data/" {
@@ -689,12 +607,16 @@ func buildModel(model ast.Model, simSpec ast.SimulationSpec) (Task, error) {
 					usesDownloadFilePaths[varUses.Name] = downloadFile
 
 					if filepath.Ext(expandEnvVars(varUses.Url)) == ".fmu" {
+						vars := map[string]string{
+							"URL":  varUses.Url,
+							"FILE": downloadFile,
+						}
+						if sha := downloadSha256(varUses); sha != "" {
+							vars["SHA256"] = sha
+						}
 						*task.Cmds = append(*task.Cmds, Cmd{
 							Task: "download-file",
-							Vars: &map[string]string{
-								"URL":  varUses.Url,
-								"FILE": downloadFile,
-							},
+							Vars: &vars,
 						})
 						*task.Cmds = append(*task.Cmds, Cmd{
 							Task: "unzip-rootdir",
@@ -800,16 +722,11 @@ func buildModel(model ast.Model, simSpec ast.SimulationSpec) (Task, error) {
 				Task: workflowTaskName,
 				Vars: &vars,
 			})
-			workflowFiles := []interface{}{}
-			func() {
-				defer func() {
-					if r := recover(); r != nil {
-					}
-				}()
-				workflowFiles = md["tasks"].(map[string]interface{})[workflow.Name].(map[string]interface{})["generates"].([]interface{})
-			}()
-			for _, file := range workflowFiles {
-				*task.Generates = append(*task.Generates, fmt.Sprintf("{{.SIMDIR}}/{{.PATH}}/%s", file.(string)))
+			workflowFiles, _ := metadataValue(mc.Metadata, "tasks", workflow.Name, "generates")
+			if files, ok := workflowFiles.([]interface{}); ok {
+				for _, file := range files {
+					*task.Generates = append(*task.Generates, fmt.Sprintf("{{.SIMDIR}}/{{.PATH}}/%v", file))
+				}
 			}
 		}
 	}(&modelTask, model)
@@ -837,6 +754,9 @@ func globalWorkflowParseUrl(global_wf_cmds *[]Cmd, uses *ast.Uses) string {
 
 			apiURL, _ := u.Parse(fmt.Sprintf("/api/v3/repos/%s/%s", pathParts[1], pathParts[2]))
 			vars["API_URL"] = apiURL.String()
+			if sha := downloadSha256(uses); sha != "" {
+				vars["SHA256"] = sha
+			}
 
 			*global_wf_cmds = append(*global_wf_cmds, Cmd{
 				Task: "download-file-github-asset",
@@ -866,6 +786,9 @@ func globalWorkflowParseUrl(global_wf_cmds *[]Cmd, uses *ast.Uses) string {
 					vars["TOKEN"] = tokenValue
 				}
 			}
+			if sha := downloadSha256(uses); sha != "" {
+				vars["SHA256"] = sha
+			}
 
 			*global_wf_cmds = append(*global_wf_cmds, Cmd{
 				Task: "download-file",
@@ -969,7 +892,7 @@ func (c GenerateCommand) buildModelTasks() (map[string]Task, error) {
 			modelTaskNames = append(modelTaskNames, modelName)
 			mt, err := buildModel(model, simSpec)
 			if err != nil {
-				return nil, fmt.Errorf("Error building model (name=%s): %w", modelName, err)
+				return nil, fmt.Errorf("%sError building model (name=%s): %w", source.Prefix(model.Annotations), modelName, err)
 			}
 			modelTasks[modelName] = mt
 			stackDeps = append(stackDeps, Dep{
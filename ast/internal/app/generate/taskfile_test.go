@@ -5,12 +5,17 @@
 package generate
 
 import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
 	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/boschglobal/dse.sdp/ast/internal/pkg/provider"
 )
 
 func generateTaskfile(t *testing.T, input string) string {
@@ -146,8 +151,9 @@ func TestGenerateTaskfile_common_elements(t *testing.T) {
 	YamlContains(t, f, "$.tasks.download-file.vars.FILE", "{{.FILE}}")
 	YamlContains(t, f, "$.tasks.download-file.vars.AUTH", "{{if all .USER .TOKEN}}-u {{.USER}}:{{.TOKEN}}{{else}}{{end}}")
 	YamlContains(t, f, "$.tasks.download-file.cmds[2]", "curl --retry 5 {{.AUTH}} -fL {{.URL}} -o {{.FILE}}")
+	YamlContains(t, f, "$.tasks.download-file.cmds[3]", "if [ -n \"{{.SHA256}}\" ]; then echo \"{{.SHA256}}  {{.FILE}}\" | sha256sum -c - || { rm -f {{.FILE}}; exit 1; }; fi")
 	YamlContains(t, f, "$.tasks.download-file.generates[0]", "{{.FILE}}")
-	YamlContains(t, f, "$.tasks.download-file.status[0]", "test -f {{.FILE}}")
+	YamlContains(t, f, "$.tasks.download-file.status[0]", "test -f {{.FILE}} && { [ -z \"{{.SHA256}}\" ] || echo \"{{.SHA256}}  {{.FILE}}\" | sha256sum -c --status -; }")
 
 	YamlContains(t, f, "$.tasks.clean.cmds[0]", "find ./out -mindepth 1 -maxdepth 1 ! -name downloads ! -name simulation.json ! -name simulation.yaml ! -name Taskfile.yml -exec rm -rf {} +")
 
@@ -211,6 +217,22 @@ func TestGenerateTaskfile_model_modelc(t *testing.T) {
 	YamlContains(t, f, "$.tasks.model-input.generates[5]", "downloads/models/{{.MODEL}}/output.csv")
 }
 
+func TestGenerateTaskfile_model_fmu_sha256(t *testing.T) {
+	taskfileName := generateTaskfile(t, "testdata/ast__model_fmu_sha256.yaml")
+	assert.FileExists(t, taskfileName)
+	f, _ := os.ReadFile(taskfileName)
+
+	// The pin of the repo is the pin of its package.
+	YamlContains(t, f, "$.tasks.model-linear.deps[0].task", "download-file")
+	YamlContains(t, f, "$.tasks.model-linear.deps[0].vars.URL", "{{.PACKAGE_URL}}")
+	YamlContains(t, f, "$.tasks.model-linear.deps[0].vars.SHA256", "b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9")
+
+	// The pin of the (unversioned) uses entry is verified by the download.
+	YamlContains(t, f, "$.tasks.model-linear.deps[1].task", "download-file")
+	YamlContains(t, f, "$.tasks.model-linear.deps[1].vars.URL", "https://github.com/boschglobal/dse.fmi/releases/download/v1.1.20/Fmi-1.1.20-linux-amd64.zip")
+	YamlContains(t, f, "$.tasks.model-linear.deps[1].vars.SHA256", "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824")
+}
+
 func TestGenerateTaskfile_model_fmu(t *testing.T) {
 	taskfileName := generateTaskfile(t, "testdata/ast__model_fmu.yaml")
 	assert.FileExists(t, taskfileName)
@@ -269,3 +291,50 @@ func TestGenerateTaskfile_model_fmu(t *testing.T) {
 	YamlContains(t, f, "$.tasks.model-linear.generates[3]", "{{.SIMDIR}}/{{.PATH}}/data/model.yaml")
 	YamlContains(t, f, "$.tasks.model-linear.generates[4]", "{{.SIMDIR}}/{{.PATH}}/data/signalgroup.yaml")
 }
+
+func TestResolveRemoteTaskfile_provider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/group/repo/-/raw/v1.2.3/Taskfile.yml" {
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	providers := provider.NewRegistry()
+	assert.Equal(t, "", resolveRemoteTaskfile(providers, server.URL+"/group/repo", "v1.2.3"))
+	providers.Register(u.Host, provider.GitLab{})
+	assert.Equal(t, server.URL+"/group/repo/-/raw/v1.2.3/Taskfile.yml", resolveRemoteTaskfile(providers, server.URL+"/group/repo", "v1.2.3"))
+}
+
+func TestGenerateTaskfile_override(t *testing.T) {
+	checkout := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(checkout, "Taskfile.yml"), []byte("version: '3'\n"), 0644))
+	data, err := os.ReadFile("testdata/ast__includes.yaml")
+	require.NoError(t, err)
+	outFolder := filepath.Join("tmp", t.Name())
+	require.NoError(t, os.MkdirAll(filepath.Join("out", outFolder), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join("out", outFolder, "ast.yaml"), data, 0644))
+
+	cmd := NewGenerateCommand("test_generate_taskfile")
+	require.NoError(t, cmd.Parse([]string{"-taskfile", "-input", filepath.Join(outFolder, "ast.yaml"), "-output", outFolder,
+		"-override", "dse.modelc=file://" + checkout}))
+	require.NoError(t, cmd.Run())
+	f, err := os.ReadFile(filepath.Join("out", outFolder, "Taskfile.yml"))
+	require.NoError(t, err)
+	t.Logf("\n%s\n", f)
+
+	// Local Taskfile include (file:// handling).
+	YamlContains(t, f, "$.includes.'dse.modelc-v2.3.12'.taskfile", filepath.Join(checkout, "Taskfile.yml"))
+	YamlContains(t, f, "$.includes.'dse.modelc-v2.3.12'.dir", checkout)
+	YamlContains(t, f, "$.includes.'dse.modelc-v2.3.12'.vars.TAG", "2.3.12")
+	// Marked in the info task.
+	assert.Contains(t, string(f), "echo \"OVERRIDE           = dse.modelc -> file://"+checkout+"\"")
+
+	cmd = NewGenerateCommand("test_generate_taskfile")
+	require.NoError(t, cmd.Parse([]string{"-taskfile", "-input", filepath.Join(outFolder, "ast.yaml"), "-output", outFolder,
+		"-override", "dse.modelc=https://example.com/dse.modelc"}))
+	assert.ErrorContains(t, cmd.Run(), "only file:// locations are supported")
+}
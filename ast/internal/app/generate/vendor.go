@@ -0,0 +1,330 @@
+// Copyright 2025 Robert Bosch GmbH
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package generate
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/elliotchance/orderedmap/v2"
+
+	"github.com/boschglobal/dse.clib/extra/go/file/handler/kind"
+	"github.com/boschglobal/dse.schemas/code/go/dse/ast"
+
+	"github.com/boschglobal/dse.sdp/ast/internal/pkg/artifact"
+	"github.com/boschglobal/dse.sdp/ast/internal/pkg/cache"
+)
+
+// Download tasks of the generated Taskfile (see buildBaseTasks).
+var downloadTasks = []string{"download-file", "download-file-github-asset"}
+
+// Artifact is a remote file referenced by the generated Taskfile: an included
+// Taskfile or a file downloaded by a task.
+type Artifact struct {
+	Url    string
+	Kind   string            // artifact.KindTaskfile or artifact.KindFile.
+	Task   string            // Download task (files).
+	Vars   map[string]string // Rendered vars of the download task call (files).
+	Sha256 string            // SHA-256 pin of the uses entry, if any.
+}
+
+// downloadCall is a call (dep or cmd) of a download task by a task of the
+// generated Taskfile, the vars are rendered in the scope of the calling task.
+type downloadCall struct {
+	caller string
+	task   string
+	vars   map[string]string
+	dep    *Dep
+	cmd    *Cmd
+}
+
+// Artifacts returns the remote Taskfiles and downloads of the Taskfile which
+// would be generated for the AST (the options of the command apply). Remote
+// Taskfiles are located with the metadata providers (network access).
+func (c *GenerateCommand) Artifacts(spec ast.SimulationSpec, doc *kind.KindDoc) ([]Artifact, error) {
+	var err error
+	c.simulationAst = spec
+	c.simulationDoc = doc
+	if err = c.prepare(true); err != nil {
+		return nil, err
+	}
+	taskfile, err := c.buildTaskfile(backendFiles[BackendTaskfile])
+	if err != nil {
+		return nil, err
+	}
+
+	// Remote Taskfiles are not pinned (the metadata Taskfile of a repo is
+	// locked by ast resolve -lock), the pin of a repo is the pin of its package.
+	artifacts := []Artifact{}
+	for _, name := range sortedKeys(*taskfile.Includes) {
+		if include := (*taskfile.Includes)[name]; isRemote(include.Taskfile) {
+			artifacts = append(artifacts, Artifact{Url: include.Taskfile, Kind: artifact.KindTaskfile})
+		}
+	}
+	calls, err := downloadCalls(taskfile)
+	if err != nil {
+		return nil, err
+	}
+	for _, call := range calls {
+		if slices.ContainsFunc(artifacts, func(a Artifact) bool { return a.Url == call.vars["URL"] }) {
+			continue
+		}
+		artifacts = append(artifacts, Artifact{
+			Url:    call.vars["URL"],
+			Kind:   artifact.KindFile,
+			Task:   call.task,
+			Vars:   call.vars,
+			Sha256: call.vars["SHA256"],
+		})
+	}
+	return artifacts, nil
+}
+
+// Download is a file downloaded by a task of the generated Taskfile.
+type Download struct {
+	Url    string
+	File   string // Downloaded file (relative to OUTDIR).
+	Task   string // Calling task.
+	Model  string // Model of the calling task, if any.
+	Uses   string // Uses entry of the model.
+	Sha256 string // SHA-256 pin of the uses entry, if any.
+}
+
+// Downloads returns the downloads of the Taskfile which would be generated for
+// the AST (the options of the command apply), in task name order. Remote
+// Taskfiles are not located (no network access).
+func (c *GenerateCommand) Downloads(spec ast.SimulationSpec, doc *kind.KindDoc) ([]Download, error) {
+	var err error
+	c.simulationAst = spec
+	c.simulationDoc = doc
+	if err = c.prepare(false); err != nil {
+		return nil, err
+	}
+	taskfile, err := c.buildTaskfile(backendFiles[BackendTaskfile])
+	if err != nil {
+		return nil, err
+	}
+	calls, err := downloadCalls(taskfile)
+	if err != nil {
+		return nil, err
+	}
+	modelUses := map[string]string{}
+	for _, stack := range c.simulationAst.Stacks {
+		for _, model := range stack.Models {
+			modelUses[model.Name] = model.Uses
+		}
+	}
+	downloads := []Download{}
+	for _, call := range calls {
+		model, ok := strings.CutPrefix(call.caller, "model-")
+		if !ok {
+			model = ""
+		}
+		downloads = append(downloads, Download{
+			Url:    call.vars["URL"],
+			File:   call.vars["FILE"],
+			Task:   call.caller,
+			Model:  model,
+			Uses:   modelUses[model],
+			Sha256: call.vars["SHA256"],
+		})
+	}
+	return downloads, nil
+}
+
+// downloadCalls returns the calls of download tasks by the tasks of the
+// Taskfile (in task name order).
+func downloadCalls(taskfile *Taskfile) ([]downloadCall, error) {
+	calls := []downloadCall{}
+	if taskfile.Tasks == nil {
+		return calls, nil
+	}
+	// The URLs of downloads are rendered with the values of the global vars
+	// (rather than the shell variables set when the build starts).
+	nsScope, globals, err := rootScope(taskfile)
+	if err != nil {
+		return nil, err
+	}
+	for _, global := range globals {
+		nsScope[global[0]] = global[1]
+	}
+	for _, name := range sortedKeys(*taskfile.Tasks) {
+		task := (*taskfile.Tasks)[name]
+		if slices.Contains(downloadTasks, name) {
+			continue
+		}
+		scope, err := taskScope(nsScope, name, &task, nil)
+		if err != nil {
+			return nil, err
+		}
+		render := func(keys []string, get func(string) string) (map[string]string, error) {
+			vars := map[string]string{}
+			for _, k := range keys {
+				v, err := renderTemplate(get(k), scope)
+				if err != nil {
+					return nil, fmt.Errorf("task %s: %v", name, err)
+				}
+				vars[k] = v
+			}
+			return vars, nil
+		}
+		if task.Deps != nil {
+			for i := range *task.Deps {
+				dep := &(*task.Deps)[i]
+				if !slices.Contains(downloadTasks, dep.Task) || dep.Vars == nil {
+					continue
+				}
+				vars, err := render(dep.Vars.Keys(), func(k string) string { v, _ := dep.Vars.Get(k); return v })
+				if err != nil {
+					return nil, err
+				}
+				calls = append(calls, downloadCall{caller: name, task: dep.Task, vars: vars, dep: dep})
+			}
+		}
+		if task.Cmds != nil {
+			for i := range *task.Cmds {
+				cmd := &(*task.Cmds)[i]
+				if !slices.Contains(downloadTasks, cmd.Task) || cmd.Vars == nil {
+					continue
+				}
+				vars, err := render(sortedKeys(*cmd.Vars), func(k string) string { return (*cmd.Vars)[k] })
+				if err != nil {
+					return nil, err
+				}
+				calls = append(calls, downloadCall{caller: name, task: cmd.Task, vars: vars, cmd: cmd})
+			}
+		}
+	}
+	return calls, nil
+}
+
+// loadVendorIndex loads the index of the vendor dir (see ast vendor).
+func (c *GenerateCommand) loadVendorIndex() error {
+	index, err := artifact.Load(c.vendorDir)
+	if err != nil {
+		return fmt.Errorf("%v (run 'ast vendor' first)", err)
+	}
+	c.vendorIndex = index
+	return nil
+}
+
+// vendorPath returns the (absolute) path of the vendored file for a URL.
+func (c GenerateCommand) vendorPath(url string) (string, bool) {
+	entry := c.vendorIndex.Lookup(url)
+	if entry == nil {
+		return "", false
+	}
+	path, err := filepath.Abs(filepath.Join(c.vendorDir, entry.File))
+	if err != nil {
+		return "", false
+	}
+	return path, true
+}
+
+// vendoredTaskfile returns the vendored Taskfile of a remote uses entry, the
+// first candidate Taskfile URL (see resolveRemoteTaskfile) which is vendored.
+func (c GenerateCommand) vendoredTaskfile(repoUrl string, version string) string {
+	for _, taskfileUrl := range c.providers.TaskfileURLs(repoUrl, version) {
+		if path, ok := c.vendorPath(taskfileUrl); ok {
+			return path
+		}
+	}
+	return ""
+}
+
+// localTaskfile returns the local copy of a remote Taskfile for the build file
+// backends, which read the Taskfiles when generating (without network access):
+// the vendored Taskfile (if the vendor dir has an index), otherwise the
+// Taskfile cached by ast resolve. Returns "" if there is no local copy.
+func (c GenerateCommand) localTaskfile(taskfileUrl string) string {
+	if c.vendorIndex == nil {
+		if index, err := artifact.Load(c.vendorDir); err == nil {
+			c.vendorIndex = index
+		}
+	}
+	if c.vendorIndex != nil {
+		if path, ok := c.vendorPath(taskfileUrl); ok {
+			slog.Info("Using vendored Taskfile", "url", taskfileUrl, "taskfile", path)
+			return path
+		}
+	}
+	if metadata, err := cache.Open(c.cacheDir); err == nil {
+		path, err := filepath.Abs(metadata.Path(taskfileUrl))
+		if _, statErr := os.Stat(path); err == nil && statErr == nil {
+			slog.Info("Using cached Taskfile", "url", taskfileUrl, "taskfile", path)
+			return path
+		}
+	}
+	return ""
+}
+
+// verifyVendored checks a vendored file against the SHA-256 pin of its uses
+// entry, an empty pin is not checked.
+func verifyVendored(path string, pin string) error {
+	if pin == "" {
+		return nil
+	}
+	sha, _, err := artifact.FileSha256(path)
+	if err != nil {
+		return err
+	}
+	return artifact.Verify(pin, sha)
+}
+
+// vendorDownloads replaces the download task calls of the Taskfile with
+// copies of the vendored files. The vendored files of pinned uses entries are
+// verified.
+func (c GenerateCommand) vendorDownloads(taskfile *Taskfile) error {
+	calls, err := downloadCalls(taskfile)
+	if err != nil {
+		return err
+	}
+	issues := []string{}
+	for _, call := range calls {
+		path, ok := c.vendorPath(call.vars["URL"])
+		if !ok {
+			issues = append(issues, fmt.Sprintf("task %s: download not vendored: %s", call.caller, call.vars["URL"]))
+			continue
+		}
+		if err := verifyVendored(path, call.vars["SHA256"]); err != nil {
+			issues = append(issues, fmt.Sprintf("task %s: %v: %s", call.caller, err, call.vars["URL"]))
+			continue
+		}
+		var file string
+		if call.dep != nil {
+			file, _ = call.dep.Vars.Get("FILE")
+			*call.dep = Dep{
+				Task: "copy-file",
+				Vars: func() *OMap {
+					om := OMap{orderedmap.NewOrderedMap[string, string]()}
+					om.Set("URL", path)
+					om.Set("FILE", file)
+					return &om
+				}(),
+			}
+		} else {
+			file = (*call.cmd.Vars)["FILE"]
+			*call.cmd = Cmd{
+				Task: "copy-file",
+				Vars: &map[string]string{
+					"URL":  path,
+					"FILE": file,
+				},
+			}
+		}
+	}
+	for _, issue := range issues {
+		fmt.Fprintf(flag.CommandLine.Output(), "%s\n", issue)
+	}
+	if len(issues) > 0 {
+		return fmt.Errorf("vendored build failed: %d issue(s) found (run 'ast vendor')", len(issues))
+	}
+	return nil
+}
@@ -0,0 +1,484 @@
+// Copyright 2025 Robert Bosch GmbH
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package importsim
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/boschglobal/dse.clib/extra/go/command"
+	"github.com/boschglobal/dse.clib/extra/go/command/log"
+	"github.com/boschglobal/dse.clib/extra/go/command/util"
+	"github.com/boschglobal/dse.clib/extra/go/file/handler"
+	"github.com/boschglobal/dse.clib/extra/go/file/handler/kind"
+
+	"github.com/boschglobal/dse.schemas/code/go/dse/ast"
+	schema_kind "github.com/boschglobal/dse.schemas/code/go/dse/kind"
+
+	"github.com/boschglobal/dse.sdp/ast/internal/app/generate"
+)
+
+const (
+	defaultRedisUri     = "redis://localhost:6379"
+	defaultRedisTimeout = 60
+)
+
+type ImportSimCommand struct {
+	command.Command
+
+	inputPath  string
+	simDir     string
+	outputFile string
+	arch       string
+	logLevel   int
+
+	stackDocs []kind.KindDoc
+	modelDocs []kind.KindDoc
+	simDoc    *kind.KindDoc
+	warnings  []string
+
+	connections map[string]ast.Annotations
+}
+
+func NewImportSimCommand(name string) *ImportSimCommand {
+	c := &ImportSimCommand{
+		Command: command.Command{
+			Name:    name,
+			FlagSet: flag.NewFlagSet(name, flag.ExitOnError),
+		},
+		connections: map[string]ast.Annotations{},
+	}
+	c.FlagSet().StringVar(&c.inputPath, "input", "", "path to Simer simulation folder (or simulation.yaml)")
+	c.FlagSet().StringVar(&c.outputFile, "output", "", "path to write generated AST file")
+	c.FlagSet().StringVar(&c.arch, "arch", "linux-amd64", "simulation arch")
+	c.FlagSet().IntVar(&c.logLevel, "log", 4, "Loglevel")
+	return c
+}
+
+func (c ImportSimCommand) Name() string {
+	return c.Command.Name
+}
+
+func (c ImportSimCommand) FlagSet() *flag.FlagSet {
+	return c.Command.FlagSet
+}
+
+func (c *ImportSimCommand) Parse(args []string) error {
+	return c.FlagSet().Parse(args)
+}
+
+func (c *ImportSimCommand) Run() error {
+	slog.SetDefault(log.NewLogger(c.logLevel))
+
+	// File paths in the AST are relative to the project (i.e. without the
+	// out folder prefix).
+	c.simDir = c.inputPath
+	if !c.isDir() {
+		c.simDir = filepath.Dir(filepath.Dir(c.inputPath))
+	}
+	outDir := "out"
+	inputPath := filepath.Join("out", c.inputPath)
+	c.inputPath = inputPath
+	outputPath := filepath.Join(outDir, c.outputFile)
+	c.outputFile = outputPath
+
+	fmt.Fprintf(flag.CommandLine.Output(), "Reading simulation: %s\n", c.inputPath)
+	if err := c.loadSimulation(); err != nil {
+		return err
+	}
+
+	simulation := c.buildSimulationAST()
+	for _, w := range c.warnings {
+		slog.Warn(w)
+	}
+
+	fmt.Fprintf(flag.CommandLine.Output(), "Writing file: %s\n", c.outputFile)
+	return util.WriteYaml(&simulation, c.outputFile, false)
+}
+
+func (c *ImportSimCommand) warn(format string, a ...any) {
+	c.warnings = append(c.warnings, fmt.Sprintf(format, a...))
+}
+
+func (c *ImportSimCommand) isDir() bool {
+	info, err := os.Stat(filepath.Join("out", c.inputPath))
+	return err == nil && info.IsDir()
+}
+
+func (c *ImportSimCommand) loadSimulation() error {
+	info, err := os.Stat(c.inputPath)
+	if err != nil {
+		return fmt.Errorf("Error reading simulation: %v", err)
+	}
+	files := []string{}
+	if info.IsDir() {
+		err := filepath.Walk(c.inputPath, func(p string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !info.IsDir() && (strings.HasSuffix(p, ".yaml") || strings.HasSuffix(p, ".yml")) {
+				files = append(files, p)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	} else {
+		files = append(files, c.inputPath)
+	}
+	slices.Sort(files)
+
+	for _, file := range files {
+		_, docs, err := handler.ParseFile(file)
+		if err != nil {
+			slog.Debug(fmt.Sprintf("Skip file: %s (%v)", file, err))
+			continue
+		}
+		for _, doc := range docs.([]kind.KindDoc) {
+			slog.Info(fmt.Sprintf("kind: %s; name=%s (%s)", doc.Kind, doc.Metadata.Name, doc.File))
+			switch doc.Kind {
+			case "Stack":
+				c.stackDocs = append(c.stackDocs, doc)
+			case "Model":
+				c.modelDocs = append(c.modelDocs, doc)
+			case "Simulation":
+				if c.simDoc == nil {
+					c.simDoc = &doc
+				}
+			}
+		}
+		c.checkConnections(file)
+	}
+	if len(c.stackDocs) == 0 {
+		return fmt.Errorf("no Stack found in: %s", c.inputPath)
+	}
+	return nil
+}
+
+// checkConnections records Stack connections which differ from the default
+// connection of the generator as (connection) stack annotations.
+func (c *ImportSimCommand) checkConnections(file string) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var doc map[string]interface{}
+		if err := decoder.Decode(&doc); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return
+		}
+		if doc["kind"] != "Stack" {
+			continue
+		}
+		name := fmt.Sprintf("%v", getPath(doc, "metadata", "name"))
+		transport, ok := getPath(doc, "spec", "connection", "transport").(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if len(transport) != 1 {
+			c.warn("stack '%s': connection transport not supported by the generator", name)
+			continue
+		}
+		annotations := ast.Annotations{}
+		for transportKind, v := range transport {
+			switch transportKind {
+			case generate.TransportRedis, generate.TransportRedisPubSub, generate.TransportMq:
+			default:
+				c.warn("stack '%s': connection transport '%s' not supported by the generator", name, transportKind)
+				continue
+			}
+			if transportKind != generate.TransportRedis {
+				annotations[generate.AnnotationConnectionTransport] = transportKind
+			}
+			conn, _ := v.(map[string]interface{})
+			if uri, ok := conn["uri"].(string); ok && uri != defaultRedisUri {
+				annotations[generate.AnnotationConnectionUri] = uri
+			}
+			if timeout, ok := conn["timeout"].(int); ok && timeout != defaultRedisTimeout {
+				annotations[generate.AnnotationConnectionTimeout] = timeout
+			}
+		}
+		if len(annotations) > 0 {
+			c.connections[name] = annotations
+		}
+	}
+}
+
+func getPath(node interface{}, keys ...string) interface{} {
+	for _, key := range keys {
+		m, ok := node.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		if node, ok = m[key]; !ok {
+			return nil
+		}
+	}
+	return node
+}
+
+func (c *ImportSimCommand) buildSimulationAST() ast.Simulation {
+	labels := ast.Labels{"generator": "ast import-sim", "input_file": c.inputPath}
+	simulation := ast.Simulation{
+		Kind: "Simulation",
+		Metadata: &ast.ObjectMetadata{
+			Labels: &labels,
+		},
+	}
+	simulation.Spec.Arch = c.arch
+	if c.simDoc != nil {
+		if spec, ok := c.simDoc.Spec.(*ast.SimulationSpec); ok && spec.Arch != "" {
+			simulation.Spec.Arch = spec.Arch
+		}
+	}
+
+	channels := []string{}
+	addChannel := func(name string) {
+		if !slices.Contains(channels, name) {
+			channels = append(channels, name)
+		}
+	}
+	expectedCounts := map[string]int{}
+	modelCounts := map[string]int{}
+	missingUses := []string{} // Models which are neither external nor defined in the layout.
+	modelNames := []string{}
+	for _, doc := range c.modelDocs {
+		modelNames = append(modelNames, doc.Metadata.Name)
+	}
+
+	for _, doc := range c.stackDocs {
+		spec := doc.Spec.(*schema_kind.StackSpec)
+		stack := ast.Stack{
+			Name:   doc.Metadata.Name,
+			Models: []ast.Model{},
+		}
+
+		// Annotations (simulation annotation is mapped to stepsize/endtime).
+		annotations := ast.Annotations{}
+		for k, v := range doc.Metadata.Annotations {
+			if k == "simulation" {
+				if sim, ok := v.(map[string]interface{}); ok {
+					if stepsize, ok := toFloat(sim["stepsize"]); ok && simulation.Spec.Stepsize == nil {
+						simulation.Spec.Stepsize = &stepsize
+					}
+					if endtime, ok := toFloat(sim["endtime"]); ok && simulation.Spec.Endtime == nil {
+						simulation.Spec.Endtime = &endtime
+					}
+				}
+				continue
+			}
+			annotations[k] = v
+		}
+		for k, v := range c.connections[doc.Metadata.Name] {
+			annotations[k] = v
+		}
+		if len(annotations) > 0 {
+			stack.Annotations = &annotations
+		}
+
+		// Runtime.
+		if spec.Runtime != nil {
+			if spec.Runtime.Stacked != nil && *spec.Runtime.Stacked {
+				stack.Stacked = spec.Runtime.Stacked
+			}
+			if spec.Runtime.Sequential != nil && *spec.Runtime.Sequential {
+				stack.Sequential = spec.Runtime.Sequential
+			}
+			if spec.Runtime.Env != nil && len(*spec.Runtime.Env) > 0 {
+				env := envList(*spec.Runtime.Env)
+				stack.Env = &env
+			}
+		}
+
+		// Models.
+		if spec.Models != nil {
+			for _, mi := range *spec.Models {
+				if mi.Name == "simbus" {
+					if mi.Channels != nil {
+						for _, ch := range *mi.Channels {
+							if ch.Name == nil {
+								continue
+							}
+							addChannel(*ch.Name)
+							if ch.ExpectedModelCount != nil {
+								expectedCounts[*ch.Name] = *ch.ExpectedModelCount
+							}
+						}
+					}
+					continue
+				}
+				model := c.buildModel(mi, modelNames)
+				external := model.External != nil && *model.External
+				if !external && !slices.Contains(modelNames, model.Model) {
+					missingUses = append(missingUses, model.Name)
+				}
+				for _, ch := range model.Channels {
+					addChannel(ch.Name)
+					modelCounts[ch.Name] += 1
+				}
+				stack.Models = append(stack.Models, model)
+			}
+		}
+		simulation.Spec.Stacks = append(simulation.Spec.Stacks, stack)
+	}
+
+	// Channels, checking the SimBus expected counts (which the generator
+	// calculates from the model channels).
+	for _, name := range channels {
+		simulation.Spec.Channels = append(simulation.Spec.Channels, ast.SimulationChannel{Name: name})
+		if expected, ok := expectedCounts[name]; ok && expected != modelCounts[name] {
+			c.warn("channel '%s': SimBus expectedModelCount is %d, models connected is %d", name, expected, modelCounts[name])
+		}
+	}
+	if len(missingUses) > 0 {
+		c.warn("uses not represented in Simer layout, add the uses entries (and run resolve) of models: %s", strings.Join(missingUses, ", "))
+	}
+	simulation.Spec.Uses = &[]ast.Uses{}
+
+	return simulation
+}
+
+func (c *ImportSimCommand) buildModel(mi schema_kind.ModelInstance, modelNames []string) ast.Model {
+	model := ast.Model{
+		Name:     mi.Name,
+		Channels: []ast.ModelChannel{},
+	}
+	if mi.Uid != 0 {
+		uid := mi.Uid
+		model.Uid = &uid
+	}
+	if mi.Model != nil {
+		model.Model = mi.Model.Name
+		if !slices.Contains(modelNames, mi.Model.Name) {
+			c.warn("model '%s': Model definition '%s' not found", mi.Name, mi.Model.Name)
+		}
+	}
+
+	if mi.Channels != nil {
+		for _, ch := range *mi.Channels {
+			if ch.Name == nil {
+				continue
+			}
+			channel := ast.ModelChannel{Name: *ch.Name}
+			if ch.Alias != nil {
+				channel.Alias = *ch.Alias
+			}
+			model.Channels = append(model.Channels, channel)
+		}
+	}
+	if mi.Annotations != nil && len(*mi.Annotations) > 0 {
+		annotations := ast.Annotations{}
+		for k, v := range *mi.Annotations {
+			annotations[k] = v
+		}
+		model.Annotations = &annotations
+	}
+
+	if mi.Runtime == nil {
+		return model
+	}
+	rt := mi.Runtime
+	if rt.Env != nil && len(*rt.Env) > 0 {
+		env := envList(*rt.Env)
+		model.Env = &env
+	}
+	if rt.External != nil && *rt.External {
+		model.External = rt.External
+	}
+	switch {
+	case rt.I386 != nil && *rt.I386:
+		model.Arch = util.StringPtr("linux-i386")
+	case rt.X32 != nil && *rt.X32:
+		model.Arch = util.StringPtr("linux-x86")
+	}
+	if rt.Mcl != nil {
+		c.warn("model '%s': runtime mcl '%s' not represented, model must reference an MCL uses entry", mi.Name, *rt.Mcl)
+	}
+	if rt.Paths != nil {
+		for _, p := range *rt.Paths {
+			if p != fmt.Sprintf("model/%s/data", mi.Name) {
+				c.warn("model '%s': runtime path '%s' not represented", mi.Name, p)
+			}
+		}
+	}
+	if rt.Files != nil && len(*rt.Files) > 0 {
+		files := []ast.File{}
+		for _, f := range *rt.Files {
+			files = append(files, ast.File{
+				Name:  modelFileName(mi.Name, f),
+				Value: path.Join(filepath.ToSlash(c.simDir), f),
+			})
+			if !strings.HasPrefix(f, fmt.Sprintf("model/%s/", mi.Name)) {
+				c.warn("model '%s': runtime file '%s' is outside the model folder", mi.Name, f)
+			}
+		}
+		model.Files = &files
+	}
+	return model
+}
+
+// modelFileName reverses the runtime file layout of the generator (see
+// generateModelRuntime) to calculate the AST file name.
+func modelFileName(modelName string, file string) string {
+	prefix := fmt.Sprintf("model/%s/", modelName)
+	if !strings.HasPrefix(file, prefix) {
+		return path.Base(file)
+	}
+	rel := strings.TrimPrefix(file, prefix)
+	dir, name := path.Split(rel)
+	dir = strings.TrimSuffix(dir, "/")
+	switch {
+	case dir == "data":
+		return name
+	case dir == "lua" && strings.EqualFold(path.Ext(name), ".lua"):
+		return name
+	case strings.HasPrefix(dir, "lua/") && strings.EqualFold(path.Ext(name), ".lua"):
+		return path.Join(strings.TrimPrefix(dir, "lua/"), name)
+	case dir == "":
+		return "./" + name
+	case strings.EqualFold(path.Ext(name), ".lua"):
+		return "./" + rel
+	default:
+		return rel
+	}
+}
+
+func envList(env map[string]string) []ast.Var {
+	keys := []string{}
+	for k := range env {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	vars := []ast.Var{}
+	for _, k := range keys {
+		vars = append(vars, ast.Var{Name: k, Value: env[k]})
+	}
+	return vars
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch f := v.(type) {
+	case float64:
+		return f, true
+	case int:
+		return float64(f), true
+	}
+	return 0, false
+}
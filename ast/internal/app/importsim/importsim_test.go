@@ -0,0 +1,105 @@
+// Copyright 2025 Robert Bosch GmbH
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package importsim
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/boschglobal/dse.schemas/code/go/dse/ast"
+
+	"github.com/boschglobal/dse.sdp/ast/internal/app/generate"
+)
+
+func TestImportSim(t *testing.T) {
+	c := NewImportSimCommand("test")
+	c.inputPath = "testdata/sim"
+	c.simDir = "sim"
+	require.NoError(t, c.loadSimulation())
+	sim := c.buildSimulationAST()
+	for _, w := range c.warnings {
+		t.Log(w)
+	}
+	spec := sim.Spec
+
+	assert.Equal(t, "linux-amd64", spec.Arch)
+	require.NotNil(t, spec.Stepsize)
+	assert.Equal(t, 0.0005, *spec.Stepsize)
+	require.NotNil(t, spec.Endtime)
+	assert.Equal(t, 0.04, *spec.Endtime)
+	assert.Equal(t, []ast.SimulationChannel{{Name: "physical"}, {Name: "network"}}, spec.Channels)
+
+	require.Len(t, spec.Stacks, 1)
+	stack := spec.Stacks[0]
+	assert.Equal(t, "default", stack.Name)
+	assert.Equal(t, &ast.Annotations{
+		"connection.transport": "redispubsub",
+		"connection.uri":       "redis://redis:6379",
+	}, stack.Annotations)
+	assert.Equal(t, &[]ast.Var{{Name: "SIMBUS_LOGLEVEL", Value: "4"}}, stack.Env)
+
+	require.Len(t, stack.Models, 2)
+	input := stack.Models[0]
+	assert.Equal(t, "input", input.Name)
+	assert.Equal(t, "dse.modelc.csv", input.Model)
+	assert.Equal(t, 1, *input.Uid)
+	assert.Equal(t, []ast.ModelChannel{{Name: "physical", Alias: "signal_channel"}}, input.Channels)
+	assert.Equal(t, &[]ast.Var{{Name: "CSV_FILE", Value: "model/input/data/input.csv"}}, input.Env)
+	assert.Equal(t, &[]ast.File{{Name: "input.csv", Value: "sim/model/input/data/input.csv"}}, input.Files)
+
+	linear := stack.Models[1]
+	assert.Equal(t, "linear", linear.Name)
+	assert.Equal(t, "linux-x86", *linear.Arch)
+	assert.Len(t, linear.Channels, 2)
+	assert.Equal(t, &[]ast.File{
+		{Name: "signalgroup.yaml", Value: "sim/model/linear/data/signalgroup.yaml"},
+		{Name: "model.lua", Value: "sim/model/linear/lua/model.lua"},
+	}, linear.Files)
+
+	assert.Nil(t, sim.Metadata.Annotations)
+
+	assert.Contains(t, c.warnings, "channel 'network': SimBus expectedModelCount is 2, models connected is 1")
+	assert.Contains(t, c.warnings, "model 'input': Model definition 'dse.modelc.csv' not found")
+	assert.NotContains(t, c.warnings, "model 'linear': Model definition 'linear' not found")
+	// Models defined in the layout are not reported, one warning for all others.
+	assert.Contains(t, c.warnings, "uses not represented in Simer layout, add the uses entries (and run resolve) of models: input")
+}
+
+func TestModelFileName(t *testing.T) {
+	tests := []struct {
+		file string
+		want string
+	}{
+		{file: "model/foo/data/signal.yaml", want: "signal.yaml"},
+		{file: "model/foo/lua/model.lua", want: "model.lua"},
+		{file: "model/foo/lua/lib/util.lua", want: "lib/util.lua"},
+		{file: "model/foo/README.md", want: "./README.md"},
+		{file: "model/foo/config/sub.yaml", want: "config/sub.yaml"},
+		{file: "data/global.yaml", want: "global.yaml"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, modelFileName("foo", tt.file), tt.file)
+	}
+}
+
+func TestImportSimCommand(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.CopyFS(filepath.Join(dir, "out", "sim"), os.DirFS("testdata/sim")))
+	t.Chdir(dir)
+
+	cmd := NewImportSimCommand("test_import_sim")
+	require.NoError(t, cmd.Parse([]string{"-input", "sim", "-output", "ast.yaml"}))
+	require.NoError(t, cmd.Run())
+
+	spec, doc, err := generate.LoadSimulationAst(filepath.Join("out", "ast.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, "ast import-sim", doc.Metadata.Labels["generator"])
+	assert.Len(t, spec.Stacks, 1)
+	assert.Len(t, spec.Stacks[0].Models, 2)
+}
@@ -0,0 +1,163 @@
+// Copyright 2025 Robert Bosch GmbH
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package outdated
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/boschglobal/dse.clib/extra/go/command"
+	"github.com/boschglobal/dse.clib/extra/go/command/log"
+
+	"github.com/boschglobal/dse.schemas/code/go/dse/ast"
+
+	"github.com/boschglobal/dse.sdp/ast/internal/app/generate"
+	"github.com/boschglobal/dse.sdp/ast/internal/pkg/provider"
+	"github.com/boschglobal/dse.sdp/ast/internal/pkg/semver"
+)
+
+type OutdatedCommand struct {
+	command.Command
+
+	inputFile    string
+	providerFile string
+	timeout      time.Duration
+	logLevel     int
+}
+
+func NewOutdatedCommand(name string) *OutdatedCommand {
+	c := &OutdatedCommand{
+		Command: command.Command{
+			Name:    name,
+			FlagSet: flag.NewFlagSet(name, flag.ExitOnError),
+		},
+	}
+	c.FlagSet().StringVar(&c.inputFile, "input", "", "path to Simulation AST file")
+	c.FlagSet().StringVar(&c.providerFile, "providers", "", "path to metadata provider config file")
+	c.FlagSet().DurationVar(&c.timeout, "timeout", 30*time.Second, "timeout for each tag list request")
+	c.FlagSet().IntVar(&c.logLevel, "log", 4, "Loglevel")
+	return c
+}
+
+func (c OutdatedCommand) Name() string {
+	return c.Command.Name
+}
+
+func (c OutdatedCommand) FlagSet() *flag.FlagSet {
+	return c.Command.FlagSet
+}
+
+func (c *OutdatedCommand) Parse(args []string) error {
+	return c.FlagSet().Parse(args)
+}
+
+func (c *OutdatedCommand) Run() error {
+	slog.SetDefault(log.NewLogger(c.logLevel))
+
+	inputPath := filepath.Join("out", c.inputFile)
+	c.inputFile = inputPath
+
+	spec, _, err := generate.LoadSimulationAst(c.inputFile)
+	if err != nil {
+		return err
+	}
+	providers, err := provider.LoadRegistry(c.providerFile)
+	if err != nil {
+		return err
+	}
+	client := &http.Client{Timeout: c.timeout}
+
+	w := tabwriter.NewWriter(flag.CommandLine.Output(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tCURRENT\tCONSTRAINT\tWANTED\tLATEST\tNEWER")
+	if spec.Uses != nil {
+		for _, uses := range *spec.Uses {
+			report := Check(providers, client, uses)
+			if report == nil {
+				continue
+			}
+			fmt.Fprintln(w, report)
+		}
+	}
+	return w.Flush()
+}
+
+// Report lists the newer versions available for a uses entry.
+type Report struct {
+	Name       string
+	Current    string
+	Constraint string
+	Wanted     string
+	Latest     string
+	Newer      []string
+	Err        error
+}
+
+func (r Report) String() string {
+	dash := func(s string) string {
+		if s == "" {
+			return "-"
+		}
+		return s
+	}
+	if r.Err != nil {
+		return fmt.Sprintf("%s\t%s\t%s\t-\t-\t(%v)", r.Name, dash(r.Current), dash(r.Constraint), r.Err)
+	}
+	newer := "-"
+	if len(r.Newer) > 0 {
+		newer = strings.Join(r.Newer, ", ")
+	}
+	return fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s", r.Name, dash(r.Current), dash(r.Constraint), dash(r.Wanted), dash(r.Latest), newer)
+}
+
+// Check lists the tags of the uses repo and reports the newer versions. Returns
+// nil for uses entries which are not versioned repos (e.g. file:// or asset
+// links).
+func Check(providers *provider.Registry, client *http.Client, uses ast.Uses) *Report {
+	if uses.Version == nil || *uses.Version == "" || strings.HasPrefix(uses.Url, "file://") {
+		return nil
+	}
+	r := &Report{Name: uses.Name, Current: *uses.Version}
+	if uses.Metadata != nil {
+		if v, ok := (*uses.Metadata)["version_constraint"].(string); ok {
+			r.Constraint = v
+		}
+	}
+	if semver.IsConstraint(r.Current) {
+		// Not resolved.
+		r.Constraint, r.Current = r.Current, ""
+	}
+
+	tags, err := providers.Tags(client, uses.Url)
+	if err != nil {
+		r.Err = err
+		return r
+	}
+	versions := semver.Versions(tags)
+	current, currentOk := semver.Parse(r.Current)
+	for _, v := range versions {
+		if v.Pre != "" {
+			continue
+		}
+		r.Latest = v.Tag
+		if currentOk && semver.Compare(v, current) > 0 {
+			r.Newer = append(r.Newer, v.Tag)
+		}
+	}
+	if r.Constraint != "" {
+		constraint, err := semver.ParseConstraint(r.Constraint)
+		if err != nil {
+			r.Err = err
+			return r
+		}
+		r.Wanted, _ = constraint.Best(tags)
+	}
+	return r
+}
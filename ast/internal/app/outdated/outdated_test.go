@@ -0,0 +1,84 @@
+// Copyright 2025 Robert Bosch GmbH
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package outdated
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/boschglobal/dse.clib/extra/go/command/util"
+	"github.com/boschglobal/dse.schemas/code/go/dse/ast"
+
+	"github.com/boschglobal/dse.sdp/ast/internal/pkg/provider"
+)
+
+func TestOutdated(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/tags/dse.modelc.json" {
+			w.Write([]byte(`["v2.1.20", "v2.1.23", "v2.1.24", "v2.2.0", "v2.3.0-rc1"]`))
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	t.Chdir(t.TempDir())
+	require.NoError(t, os.MkdirAll("out", 0755))
+	require.NoError(t, os.WriteFile("providers.yaml", []byte(`---
+providers:
+  - host: `+u.Host+`
+    type: http
+    base_url: `+server.URL+`
+    tags: "tags/{{.Repo}}.json"
+`), 0644))
+	providers, err := provider.LoadRegistry("providers.yaml")
+	require.NoError(t, err)
+
+	repoUrl := server.URL + "/boschglobal/dse.modelc"
+	metadata := map[string]interface{}{"version_constraint": "~2.1"}
+	report := Check(providers, nil, ast.Uses{Name: "dse.modelc", Url: repoUrl, Version: util.StringPtr("v2.1.23"), Metadata: &metadata})
+	require.NotNil(t, report)
+	require.NoError(t, report.Err)
+	assert.Equal(t, "~2.1", report.Constraint)
+	assert.Equal(t, "v2.1.24", report.Wanted)
+	assert.Equal(t, "v2.2.0", report.Latest)
+	assert.Equal(t, []string{"v2.1.24", "v2.2.0"}, report.Newer)
+
+	report = Check(providers, nil, ast.Uses{Name: "dse.modelc", Url: repoUrl, Version: util.StringPtr("latest")})
+	require.NotNil(t, report)
+	assert.Equal(t, "", report.Current)
+	assert.Equal(t, "v2.2.0", report.Wanted)
+
+	report = Check(providers, nil, ast.Uses{Name: "dse.fmi", Url: "https://unknown.example.com/org/dse.fmi", Version: util.StringPtr("v1.0.0")})
+	require.NotNil(t, report)
+	assert.ErrorContains(t, report.Err, "tag listing not supported")
+
+	assert.Nil(t, Check(providers, nil, ast.Uses{Name: "local", Url: "file:///repo/dse.modelc"}))
+
+	require.NoError(t, os.WriteFile("out/ast.yaml", []byte(`---
+kind: Simulation
+metadata:
+  name: test
+spec:
+  arch: linux-amd64
+  channels: []
+  stacks: []
+  uses:
+    - name: dse.modelc
+      url: `+repoUrl+`
+      version: v2.1.23
+`), 0644))
+	cmd := NewOutdatedCommand("test_outdated")
+	require.NoError(t, cmd.Parse([]string{"-input", "ast.yaml", "-providers", "providers.yaml"}))
+	assert.NoError(t, cmd.Run())
+}
@@ -0,0 +1,204 @@
+// Copyright 2025 Robert Bosch GmbH
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package overlay
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+)
+
+const (
+	patchKey     = "$patch"
+	patchMerge   = "merge"
+	patchReplace = "replace"
+	patchDelete  = "delete"
+)
+
+// Lists of the Simulation AST which are merged by name (other lists, e.g. the
+// channel selectors of a model, are replaced).
+var namedLists = []string{
+	"stacks", "models", "channels", "networks", "uses",
+	"env", "vars", "files", "workflows",
+}
+
+// Conflict of an overlay with the base AST.
+type Conflict struct {
+	Path    string
+	Message string
+}
+
+func (c Conflict) String() string {
+	return fmt.Sprintf("%s: %s", c.Path, c.Message)
+}
+
+type merger struct {
+	conflicts []Conflict
+}
+
+func (m *merger) conflict(path string, format string, args ...any) {
+	m.conflicts = append(m.conflicts, Conflict{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// mergeMap merges the patch into the base map. Keys of the patch replace
+// those of the base, maps are merged, named lists are merged by name and a
+// null value removes the key.
+func (m *merger) mergeMap(base map[string]any, patch map[string]any, path string) map[string]any {
+	if base == nil {
+		base = map[string]any{}
+	}
+	keys := make([]string, 0, len(patch))
+	for k := range patch {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v, keyPath := patch[k], path+"."+k
+		if v == nil {
+			delete(base, k)
+			continue
+		}
+		current, exists := base[k]
+		if !exists || current == nil {
+			base[k] = withoutDirectives(v)
+			continue
+		}
+		switch v := v.(type) {
+		case map[string]any:
+			if c, ok := current.(map[string]any); ok {
+				base[k] = m.mergeMap(c, v, keyPath)
+			} else {
+				m.conflict(keyPath, "cannot merge a map with a %s", typeName(current))
+			}
+		case []any:
+			c, ok := current.([]any)
+			if !ok {
+				m.conflict(keyPath, "cannot merge a list with a %s", typeName(current))
+			} else if slices.Contains(namedLists, k) {
+				base[k] = m.mergeList(c, v, keyPath)
+			} else {
+				base[k] = v
+			}
+		default:
+			if _, ok := current.(map[string]any); ok {
+				m.conflict(keyPath, "cannot replace a map with a %s", typeName(v))
+			} else if _, ok := current.([]any); ok {
+				m.conflict(keyPath, "cannot replace a list with a %s", typeName(v))
+			} else {
+				base[k] = v
+			}
+		}
+	}
+	return base
+}
+
+// mergeList merges the items of a patch list into the base list by name. The
+// $patch directive of an item selects the operation: merge (default, the item
+// is added if not in the base), replace or delete.
+func (m *merger) mergeList(base []any, patch []any, path string) []any {
+	result := slices.Clone(base)
+	indexOf := func(name string) int {
+		return slices.IndexFunc(result, func(item any) bool {
+			n, _ := itemName(item)
+			return n == name
+		})
+	}
+	seen := []string{}
+	for i, p := range patch {
+		item, ok := p.(map[string]any)
+		name, named := itemName(p)
+		if !ok || !named {
+			m.conflict(fmt.Sprintf("%s[%d]", path, i), "item has no name")
+			continue
+		}
+		itemPath := fmt.Sprintf("%s[%s]", path, name)
+		if slices.Contains(seen, name) {
+			m.conflict(itemPath, "duplicate item in overlay")
+			continue
+		}
+		seen = append(seen, name)
+
+		op := patchMerge
+		if d, ok := item[patchKey]; ok {
+			op = fmt.Sprint(d)
+			item = withoutKey(item, patchKey)
+		}
+		idx := indexOf(name)
+		switch op {
+		case patchDelete:
+			if idx < 0 {
+				m.conflict(itemPath, "delete of an item not in the base")
+				continue
+			}
+			result = slices.Delete(result, idx, idx+1)
+		case patchReplace:
+			if idx < 0 {
+				result = append(result, withoutDirectives(item))
+			} else {
+				result[idx] = withoutDirectives(item)
+			}
+		case patchMerge:
+			if idx < 0 {
+				result = append(result, withoutDirectives(item))
+			} else {
+				result[idx] = m.mergeMap(result[idx].(map[string]any), item, itemPath)
+			}
+		default:
+			m.conflict(itemPath, "unsupported %s directive: %s", patchKey, op)
+		}
+	}
+	return result
+}
+
+func itemName(item any) (string, bool) {
+	m, ok := item.(map[string]any)
+	if !ok {
+		return "", false
+	}
+	name, ok := m["name"].(string)
+	return name, ok && name != ""
+}
+
+func withoutKey(m map[string]any, key string) map[string]any {
+	result := make(map[string]any, len(m))
+	for k, v := range m {
+		if k != key {
+			result[k] = v
+		}
+	}
+	return result
+}
+
+// withoutDirectives removes the $patch directives of an item (and its nested
+// items) which is added to, or replaces an item of, the base.
+func withoutDirectives(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		result := withoutKey(v, patchKey)
+		for k, item := range result {
+			result[k] = withoutDirectives(item)
+		}
+		return result
+	case []any:
+		result := make([]any, len(v))
+		for i, item := range v {
+			result[i] = withoutDirectives(item)
+		}
+		return result
+	default:
+		return v
+	}
+}
+
+func typeName(v any) string {
+	switch v.(type) {
+	case map[string]any:
+		return "map"
+	case []any:
+		return "list"
+	default:
+		return "value"
+	}
+}
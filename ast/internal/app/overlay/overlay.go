@@ -0,0 +1,207 @@
+// Copyright 2025 Robert Bosch GmbH
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package overlay
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/boschglobal/dse.clib/extra/go/command"
+	"github.com/boschglobal/dse.clib/extra/go/command/log"
+	"github.com/boschglobal/dse.clib/extra/go/command/util"
+
+	"github.com/boschglobal/dse.schemas/code/go/dse/ast"
+
+	"github.com/boschglobal/dse.sdp/ast/internal/app/validate"
+)
+
+const (
+	OverlayKind  = "SimulationOverlay"
+	overlayLabel = "overlay"
+)
+
+type OverlayCommand struct {
+	command.Command
+
+	baseFile    string
+	overlayFile string
+	outputFile  string
+	logLevel    int
+}
+
+func NewOverlayCommand(name string) *OverlayCommand {
+	c := &OverlayCommand{
+		Command: command.Command{
+			Name:    name,
+			FlagSet: flag.NewFlagSet(name, flag.ExitOnError),
+		},
+	}
+	c.FlagSet().StringVar(&c.baseFile, "base", "", "path to base Simulation AST file")
+	c.FlagSet().StringVar(&c.overlayFile, "overlay", "", "path to overlay file")
+	c.FlagSet().StringVar(&c.outputFile, "output", "", "path to write the Simulation AST file")
+	c.FlagSet().IntVar(&c.logLevel, "log", 4, "Loglevel")
+	return c
+}
+
+func (c OverlayCommand) Name() string {
+	return c.Command.Name
+}
+
+func (c OverlayCommand) FlagSet() *flag.FlagSet {
+	return c.Command.FlagSet
+}
+
+func (c *OverlayCommand) Parse(args []string) error {
+	return c.FlagSet().Parse(args)
+}
+
+func (c *OverlayCommand) Run() error {
+	slog.SetDefault(log.NewLogger(c.logLevel))
+
+	outDir := "out"
+	basePath := filepath.Join("out", c.baseFile)
+	c.baseFile = basePath
+	outputPath := filepath.Join(outDir, c.outputFile)
+	c.outputFile = outputPath
+
+	fmt.Fprintf(flag.CommandLine.Output(), "Reading file: %s\n", c.baseFile)
+	base, err := readDoc(c.baseFile)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(flag.CommandLine.Output(), "Reading overlay: %s\n", c.overlayFile)
+	overlay, err := readDoc(c.overlayFile)
+	if err != nil {
+		return err
+	}
+
+	simulation, conflicts, err := Apply(base, overlay)
+	if err != nil {
+		return err
+	}
+	for _, conflict := range conflicts {
+		fmt.Fprintf(flag.CommandLine.Output(), "%s\n", conflict)
+	}
+	if len(conflicts) > 0 {
+		return fmt.Errorf("overlay failed: %d conflict(s) found", len(conflicts))
+	}
+
+	if simulation.Metadata == nil {
+		simulation.Metadata = &ast.ObjectMetadata{}
+	}
+	if simulation.Metadata.Labels == nil {
+		simulation.Metadata.Labels = &ast.Labels{}
+	}
+	if overlays, ok := (*simulation.Metadata.Labels)[overlayLabel]; ok && overlays != "" {
+		(*simulation.Metadata.Labels)[overlayLabel] = overlays + "," + c.overlayFile
+	} else {
+		(*simulation.Metadata.Labels)[overlayLabel] = c.overlayFile
+	}
+
+	fmt.Fprintf(flag.CommandLine.Output(), "Writing file: %s\n", c.outputFile)
+	if err := os.MkdirAll(filepath.Dir(c.outputFile), os.ModePerm); err != nil {
+		return err
+	}
+	return util.WriteYaml(simulation, c.outputFile, false)
+}
+
+func readDoc(file string) (map[string]any, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading file: %v", err)
+	}
+	var doc map[string]any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("Error parsing file %s: %v", file, err)
+	}
+	if doc == nil {
+		return nil, fmt.Errorf("empty file: %s", file)
+	}
+	return doc, nil
+}
+
+// Apply applies an overlay to a base Simulation AST (both as decoded YAML
+// documents). The metadata and spec of the overlay are merged into the base
+// with strategic-merge semantics: maps are merged, scalars are replaced and
+// the named lists (stacks, models, channels, networks, uses, env, vars, files
+// and workflows) are merged by name:
+//
+//	stacks:
+//	  - name: default
+//	    models:
+//	      - name: linear        # merged (or added)
+//	        external: true
+//	      - name: input
+//	        $patch: delete      # removed
+//	      - name: hil
+//	        $patch: replace     # replaced (or added)
+//	        model: dse.hil
+//
+// Conflicts are reported for overlays which do not apply (e.g. delete of an
+// item not in the base) and for validation issues which the overlay
+// introduces (e.g. a model channel which is no longer declared).
+func Apply(base map[string]any, overlay map[string]any) (*ast.Simulation, []Conflict, error) {
+	if kind, _ := base["kind"].(string); kind != "Simulation" {
+		return nil, nil, fmt.Errorf("base is not a Simulation AST (kind=%v)", base["kind"])
+	}
+	if kind, ok := overlay["kind"]; ok && kind != OverlayKind {
+		return nil, nil, fmt.Errorf("overlay is not a %s (kind=%v)", OverlayKind, kind)
+	}
+
+	baseSim, err := decodeSimulation(base)
+	if err != nil {
+		return nil, nil, err
+	}
+	m := merger{}
+	for _, key := range []string{"metadata", "spec"} {
+		patch, ok := overlay[key]
+		if !ok || patch == nil {
+			continue
+		}
+		p, ok := patch.(map[string]any)
+		if !ok {
+			m.conflict(key, "overlay %s must be a map", key)
+			continue
+		}
+		current, _ := base[key].(map[string]any)
+		base[key] = m.mergeMap(current, p, key)
+	}
+	if len(m.conflicts) > 0 {
+		return nil, m.conflicts, nil
+	}
+	simulation, err := decodeSimulation(base)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	baseIssues := []string{}
+	for _, issue := range validate.Validate(baseSim.Spec) {
+		baseIssues = append(baseIssues, issue.String())
+	}
+	for _, issue := range validate.Validate(simulation.Spec) {
+		if !slices.Contains(baseIssues, issue.String()) {
+			m.conflict("spec", "%s", issue)
+		}
+	}
+	return simulation, m.conflicts, nil
+}
+
+func decodeSimulation(doc map[string]any) (*ast.Simulation, error) {
+	data, err := yaml.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	var simulation ast.Simulation
+	if err := yaml.Unmarshal(data, &simulation); err != nil {
+		return nil, fmt.Errorf("invalid Simulation AST: %v", err)
+	}
+	return &simulation, nil
+}
@@ -0,0 +1,210 @@
+// Copyright 2025 Robert Bosch GmbH
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package overlay
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/boschglobal/dse.sdp/ast/internal/app/generate"
+)
+
+const baseAst = `---
+kind: Simulation
+metadata:
+  name: sil
+spec:
+  arch: linux-amd64
+  stepsize: 0.0005
+  endtime: 0.02
+  channels:
+    - name: physical
+    - name: network
+      networks:
+        - name: CAN
+          mime_type: application/x-automotive-bus;bus=can
+  uses:
+    - name: dse.modelc
+      url: https://github.com/boschglobal/dse.modelc
+      version: v2.1.17
+    - name: dse.fmi
+      url: https://github.com/boschglobal/dse.fmi
+      version: v1.1.20
+  vars:
+    - name: BUS_ID
+      value: "1"
+  stacks:
+    - name: default
+      env:
+        - name: SIMBUS_LOGLEVEL
+          value: "4"
+      models:
+        - name: input
+          model: dse.modelc.csv
+          uses: dse.modelc
+          channels:
+            - alias: signal_channel
+              name: physical
+          env:
+            - name: CSV_FILE
+              value: data/input.csv
+        - name: linear
+          model: dse.fmi.mcl
+          uses: dse.fmi
+          arch: linux-amd64
+          channels:
+            - alias: signal_channel
+              name: physical
+            - alias: network_channel
+              name: network
+`
+
+func readYaml(t *testing.T, s string) map[string]any {
+	var doc map[string]any
+	require.NoError(t, yaml.Unmarshal([]byte(s), &doc))
+	return doc
+}
+
+func TestApply(t *testing.T) {
+	simulation, conflicts, err := Apply(readYaml(t, baseAst), readYaml(t, `---
+kind: SimulationOverlay
+metadata:
+  annotations:
+    connection.uri: redis://hil:6379
+spec:
+  stepsize: 0.001
+  uses:
+    - name: dse.fmi
+      version: v1.1.21
+  vars:
+    - name: BUS_ID
+      value: "2"
+  stacks:
+    - name: default
+      env:
+        - name: SIMBUS_LOGLEVEL
+          value: "2"
+      models:
+        - name: linear
+          external: true
+          arch: null
+          channels:
+            - name: network
+              $patch: delete
+        - name: input
+          $patch: replace
+          model: dse.modelc.gateway
+          channels:
+            - alias: signal_channel
+              name: physical
+    - name: hil
+      models:
+        - name: rig
+          model: Gateway
+          channels:
+            - alias: signal_channel
+              name: physical
+`))
+	require.NoError(t, err)
+	require.Empty(t, conflicts)
+
+	spec := simulation.Spec
+	assert.Equal(t, "redis://hil:6379", (*simulation.Metadata.Annotations)["connection.uri"])
+	assert.Equal(t, "sil", *simulation.Metadata.Name)
+	assert.Equal(t, 0.001, *spec.Stepsize)
+	assert.Equal(t, 0.02, *spec.Endtime)
+	assert.Equal(t, "v1.1.21", *(*spec.Uses)[1].Version)
+	assert.Equal(t, "https://github.com/boschglobal/dse.fmi", (*spec.Uses)[1].Url)
+	assert.Equal(t, "2", (*spec.Vars)[0].Value)
+
+	require.Len(t, spec.Stacks, 2)
+	assert.Equal(t, "2", (*spec.Stacks[0].Env)[0].Value)
+	models := spec.Stacks[0].Models
+	require.Len(t, models, 2)
+	assert.Equal(t, "dse.modelc.gateway", models[0].Model)
+	assert.Equal(t, "", models[0].Uses)
+	assert.Nil(t, models[0].Env)
+	assert.Equal(t, "linear", models[1].Name)
+	assert.True(t, *models[1].External)
+	assert.Nil(t, models[1].Arch)
+	require.Len(t, models[1].Channels, 1)
+	assert.Equal(t, "physical", models[1].Channels[0].Name)
+	assert.Equal(t, "hil", spec.Stacks[1].Name)
+	assert.Equal(t, "rig", spec.Stacks[1].Models[0].Name)
+}
+
+func TestApply_conflicts(t *testing.T) {
+	_, conflicts, err := Apply(readYaml(t, baseAst), readYaml(t, `---
+spec:
+  channels:
+    - name: can
+      $patch: delete
+    - name: physical
+      $patch: patch
+    - alias: signal_channel
+  vars:
+    - name: BUS_ID
+      value: "2"
+    - name: BUS_ID
+      value: "3"
+  stacks:
+    - name: default
+      env: SIMBUS_LOGLEVEL=2
+`))
+	require.NoError(t, err)
+	var messages []string
+	for _, c := range conflicts {
+		messages = append(messages, c.String())
+	}
+	assert.Equal(t, []string{
+		"spec.channels[can]: delete of an item not in the base",
+		"spec.channels[physical]: unsupported $patch directive: patch",
+		"spec.channels[2]: item has no name",
+		"spec.stacks[default].env: cannot replace a list with a value",
+		"spec.vars[BUS_ID]: duplicate item in overlay",
+	}, messages)
+
+	// Overlay which applies, but leaves a model channel undeclared.
+	_, conflicts, err = Apply(readYaml(t, baseAst), readYaml(t, `---
+spec:
+  channels:
+    - name: network
+      $patch: delete
+`))
+	require.NoError(t, err)
+	require.Len(t, conflicts, 1)
+	assert.Equal(t, "spec: E001: model 'linear' (stack 'default'): channel 'network' not declared in spec.channels", conflicts[0].String())
+
+	_, _, err = Apply(readYaml(t, baseAst), readYaml(t, "kind: Stack\n"))
+	assert.ErrorContains(t, err, "overlay is not a SimulationOverlay")
+}
+
+func TestOverlayCommand(t *testing.T) {
+	t.Chdir(t.TempDir())
+	require.NoError(t, os.MkdirAll("out", 0755))
+	require.NoError(t, os.WriteFile("out/ast.yaml", []byte(baseAst), 0644))
+	require.NoError(t, os.WriteFile("hil.yaml", []byte(`---
+kind: SimulationOverlay
+spec:
+  stacks:
+    - name: default
+      models:
+        - name: linear
+          external: true
+`), 0644))
+
+	cmd := NewOverlayCommand("overlay")
+	require.NoError(t, cmd.Parse([]string{"-base", "ast.yaml", "-overlay", "hil.yaml", "-output", "hil/ast.yaml"}))
+	require.NoError(t, cmd.Run())
+
+	spec, doc, err := generate.LoadSimulationAst("out/hil/ast.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, "hil.yaml", doc.Metadata.Labels["overlay"])
+	assert.True(t, *spec.Stacks[0].Models[1].External)
+}
@@ -0,0 +1,155 @@
+// Copyright 2025 Robert Bosch GmbH
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package resolve
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+const lockFileVersion = 1
+
+// LockFile records the metadata resolved for each (remote) uses entry so that
+// subsequent resolves are reproducible (and possible without network access).
+type LockFile struct {
+	Version int         `yaml:"version"`
+	Uses    []LockEntry `yaml:"uses"`
+}
+
+type LockEntry struct {
+	Name     string `yaml:"name"`
+	Url      string `yaml:"url"`
+	Tag      string `yaml:"tag,omitempty"`
+	Resolved string `yaml:"resolved"`
+	Variant  string `yaml:"variant"`
+	Sha256   string `yaml:"sha256"`
+}
+
+func contentSha256(data []byte) string {
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
+
+func newLockEntry(name string, url string, tag string, resolved string, data []byte) LockEntry {
+	return LockEntry{
+		Name:     name,
+		Url:      url,
+		Tag:      tag,
+		Resolved: resolved,
+		Variant:  path.Base(resolved),
+		Sha256:   contentSha256(data),
+	}
+}
+
+// LoadLockFile loads a lockfile, a missing file results in an empty lockfile.
+func LoadLockFile(file string) (*LockFile, error) {
+	lock := &LockFile{Version: lockFileVersion, Uses: []LockEntry{}}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return lock, nil
+		}
+		return nil, fmt.Errorf("Error reading lockfile: %v", err)
+	}
+	if err := yaml.Unmarshal(data, lock); err != nil {
+		return nil, fmt.Errorf("Error parsing lockfile: %v", err)
+	}
+	if lock.Version != lockFileVersion {
+		return nil, fmt.Errorf("unsupported lockfile version: %d (expected %d)", lock.Version, lockFileVersion)
+	}
+	return lock, nil
+}
+
+func (l *LockFile) Save(file string) error {
+	slices.SortFunc(l.Uses, func(a, b LockEntry) int {
+		if a.Name < b.Name {
+			return -1
+		} else if a.Name > b.Name {
+			return 1
+		}
+		return 0
+	})
+	data, err := yaml.Marshal(l)
+	if err != nil {
+		return fmt.Errorf("failed to marshal lockfile: %v", err)
+	}
+	if dir := filepath.Dir(file); dir != "" {
+		os.MkdirAll(dir, os.ModePerm)
+	}
+	return os.WriteFile(file, data, 0644)
+}
+
+// Lookup returns the lock entry for a uses entry, provided that the url and
+// tag of the uses entry have not changed since the entry was locked.
+func (l *LockFile) Lookup(name string, url string, tag string) *LockEntry {
+	for i := range l.Uses {
+		e := &l.Uses[i]
+		if e.Name == name && e.Url == url && e.Tag == tag {
+			return e
+		}
+	}
+	return nil
+}
+
+func (l *LockFile) Update(entry LockEntry) {
+	for i := range l.Uses {
+		if l.Uses[i].Name == entry.Name {
+			l.Uses[i] = entry
+			return
+		}
+	}
+	l.Uses = append(l.Uses, entry)
+}
+
+// Verify checks each lock entry against the cache and returns a list of
+// detected drift (missing or modified cache files).
+func (l *LockFile) Verify(cacheDir string) []string {
+	drift := []string{}
+	for _, e := range l.Uses {
+		cacheFile := filepath.Join(cacheDir, calculateSha256(e.Resolved))
+		data, err := os.ReadFile(cacheFile)
+		if err != nil {
+			drift = append(drift, fmt.Sprintf("uses '%s': not in cache (%s)", e.Name, e.Resolved))
+			continue
+		}
+		if sha := contentSha256(data); sha != e.Sha256 {
+			drift = append(drift, fmt.Sprintf("uses '%s': sha256 mismatch (lock %s, cache %s)", e.Name, e.Sha256, sha))
+		}
+	}
+	return drift
+}
+
+// VerifyUses checks that each (remote) uses entry of the AST has a matching
+// lock entry, and that the lockfile has no stale entries.
+func (l *LockFile) VerifyUses(uses []map[string]string) []string {
+	drift := []string{}
+	names := []string{}
+	for _, use := range uses {
+		names = append(names, use["name"])
+		if l.Lookup(use["name"], use["url"], use["version"]) == nil {
+			drift = append(drift, fmt.Sprintf("uses '%s': not locked (url=%s, version=%s)", use["name"], use["url"], use["version"]))
+		}
+	}
+	for _, e := range l.Uses {
+		if !slices.Contains(names, e.Name) {
+			drift = append(drift, fmt.Sprintf("uses '%s': locked but not used", e.Name))
+		}
+	}
+	return drift
+}
+
+// Retain removes lock entries which are not in the names list.
+func (l *LockFile) Retain(names []string) {
+	l.Uses = slices.DeleteFunc(l.Uses, func(e LockEntry) bool {
+		return !slices.Contains(names, e.Name)
+	})
+}
@@ -0,0 +1,123 @@
+// Copyright 2025 Robert Bosch GmbH
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package resolve
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+const lockTestAst = `---
+kind: Simulation
+spec:
+  arch: linux-amd64
+  stacks:
+    - name: default
+      models:
+        - name: input
+          model: dse.modelc.csv
+          uses: dse.modelc
+  uses:
+    - name: dse.modelc
+      url: https://github.com/boschglobal/dse.modelc
+      version: v2.1.23
+`
+
+const lockTestTaskfile = `---
+version: '3'
+metadata:
+  package:
+    download: '{{.REPO}}/releases/download/v{{.TAG}}/ModelC-{{.TAG}}-{{.PLATFORM_ARCH}}.zip'
+  models:
+    dse.modelc.csv:
+      name: csv
+`
+
+const lockTestUrl = "https://raw.githubusercontent.com/boschglobal/dse.modelc/refs/tags/v2.1.23/Taskfile.yml"
+
+func setupLockTest(t *testing.T) {
+	t.Chdir(t.TempDir())
+	require.NoError(t, os.MkdirAll("out/cache", 0755))
+	require.NoError(t, os.WriteFile("out/ast.yaml", []byte(lockTestAst), 0644))
+	// Only the Taskfile.yml variant is available (in the cache).
+	cacheFile := filepath.Join("out/cache", calculateSha256(lockTestUrl))
+	require.NoError(t, os.WriteFile(cacheFile, []byte(lockTestTaskfile), 0644))
+}
+
+func runResolve(t *testing.T, args ...string) error {
+	cmd := NewResolveCommand("test_resolve")
+	require.NoError(t, cmd.Parse(append([]string{"-input", "ast.yaml"}, args...)))
+	return cmd.Run()
+}
+
+func TestResolveLock_offline(t *testing.T) {
+	setupLockTest(t)
+
+	err := runResolve(t, "-offline", "-lock", "sdp.lock")
+	require.NoError(t, err)
+
+	lock, err := LoadLockFile("sdp.lock")
+	require.NoError(t, err)
+	require.Len(t, lock.Uses, 1)
+	assert.Equal(t, LockEntry{
+		Name:     "dse.modelc",
+		Url:      "https://github.com/boschglobal/dse.modelc",
+		Tag:      "v2.1.23",
+		Resolved: lockTestUrl,
+		Variant:  "Taskfile.yml",
+		Sha256:   contentSha256([]byte(lockTestTaskfile)),
+	}, lock.Uses[0])
+
+	data, err := os.ReadFile("out/ast.yaml")
+	require.NoError(t, err)
+	var doc map[string]interface{}
+	require.NoError(t, yaml.Unmarshal(data, &doc))
+	assert.NotNil(t, getYamlPath(doc, "spec", "uses").([]interface{})[0].(map[string]interface{})["metadata"].(map[string]interface{})["package"])
+
+	// Locked resolve, and verify.
+	require.NoError(t, runResolve(t, "-offline", "-lock", "sdp.lock"))
+	require.NoError(t, runResolve(t, "-verify", "-lock", "sdp.lock"))
+}
+
+func TestResolveLock_offlineNotCached(t *testing.T) {
+	setupLockTest(t)
+	require.NoError(t, os.RemoveAll("out/cache"))
+
+	err := runResolve(t, "-offline", "-lock", "sdp.lock")
+	assert.ErrorContains(t, err, "offline: uses 'dse.modelc' cannot be resolved from lockfile or cache")
+}
+
+func TestResolveLock_drift(t *testing.T) {
+	setupLockTest(t)
+	require.NoError(t, runResolve(t, "-offline", "-lock", "sdp.lock"))
+
+	// Modify the cached content.
+	cacheFile := filepath.Join("out/cache", calculateSha256(lockTestUrl))
+	require.NoError(t, os.WriteFile(cacheFile, []byte(lockTestTaskfile+"\n# changed\n"), 0644))
+
+	err := runResolve(t, "-verify", "-lock", "sdp.lock")
+	assert.ErrorContains(t, err, "1 issue(s)")
+	err = runResolve(t, "-offline", "-lock", "sdp.lock")
+	assert.ErrorContains(t, err, "uses 'dse.modelc': sha256 mismatch")
+}
+
+func TestLockFile_verifyUses(t *testing.T) {
+	lock := LockFile{Version: lockFileVersion, Uses: []LockEntry{
+		{Name: "dse.modelc", Url: "https://github.com/boschglobal/dse.modelc", Tag: "v2.1.23"},
+		{Name: "dse.fmi", Url: "https://github.com/boschglobal/dse.fmi", Tag: "v1.1.8"},
+	}}
+	drift := lock.VerifyUses([]map[string]string{
+		{"name": "dse.modelc", "url": "https://github.com/boschglobal/dse.modelc", "version": "v2.1.24"},
+	})
+	assert.Equal(t, []string{
+		"uses 'dse.modelc': not locked (url=https://github.com/boschglobal/dse.modelc, version=v2.1.24)",
+		"uses 'dse.fmi': locked but not used",
+	}, drift)
+}
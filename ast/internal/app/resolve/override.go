@@ -0,0 +1,85 @@
+// Copyright 2025 Robert Bosch GmbH
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package resolve
+
+import (
+	"log/slog"
+	"strings"
+
+	"github.com/boschglobal/dse.sdp/ast/internal/pkg/override"
+)
+
+// applyOverrides redirects uses entries to the local directory or file of an
+// override (see -override and sdp.override.yaml). The original URL is kept in
+// the uses metadata, and the overrides are marked in the AST labels. Overrides
+// of a previous resolve which are no longer specified are reverted.
+func (c *ResolveCommand) applyOverrides() error {
+	overrides, err := override.Load(c.overrideFile, c.overrideFlags)
+	if err != nil {
+		return err
+	}
+	applied := override.Overrides{}
+	uses, _ := getYamlPath(c.yamlAst, "spec", "uses").([]interface{})
+	for _, _use := range uses {
+		use := _use.(map[string]interface{})
+		name, _ := use["name"].(string)
+		md, ok := use["metadata"].(map[string]interface{})
+		if !ok {
+			md = map[string]interface{}{}
+		}
+		originalUrl, overridden := md[override.MetadataKey].(string)
+		if location, ok := overrides[name]; ok {
+			if !overridden {
+				md[override.MetadataKey] = use["url"]
+			}
+			use["url"] = location
+			use["metadata"] = md
+			applied[name] = location
+			c.overriddenUses = append(c.overriddenUses, name)
+			slog.Info("Override uses", "name", name, "url", location)
+		} else if overridden {
+			slog.Info("Revert override of uses", "name", name, "url", originalUrl)
+			use["url"] = originalUrl
+			delete(md, override.MetadataKey)
+		}
+	}
+	for _, name := range overrides.Names() {
+		if _, ok := applied[name]; !ok {
+			slog.Warn("Override does not match a uses entry", "name", name)
+		}
+	}
+
+	// Mark the overrides in the AST labels.
+	md, ok := c.yamlAst["metadata"].(map[string]interface{})
+	if !ok {
+		md = map[string]interface{}{}
+		c.yamlAst["metadata"] = md
+	}
+	labels, ok := md["labels"].(map[string]interface{})
+	if !ok {
+		labels = map[string]interface{}{}
+	}
+	for k := range labels {
+		if strings.HasPrefix(k, override.LabelPrefix) {
+			delete(labels, k)
+		}
+	}
+	for name, location := range applied {
+		labels[override.LabelPrefix+name] = location
+	}
+	if len(labels) > 0 {
+		md["labels"] = labels
+	}
+	return nil
+}
+
+// originalUrl returns the URL of a uses entry before it was overridden.
+func originalUrl(use map[string]interface{}) string {
+	if u, ok := getYamlPath(use, "metadata", override.MetadataKey).(string); ok {
+		return u
+	}
+	u, _ := use["url"].(string)
+	return u
+}
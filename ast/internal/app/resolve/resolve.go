@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"maps"
 	"net/http"
 	"net/url"
 	"os"
@@ -18,10 +19,17 @@ import (
 	"regexp"
 	"slices"
 	"strings"
+	"sync"
+	"time"
 
 	"gopkg.in/yaml.v3"
 
 	"github.com/boschglobal/dse.clib/extra/go/command"
+
+	"github.com/boschglobal/dse.sdp/ast/internal/pkg/artifact"
+	"github.com/boschglobal/dse.sdp/ast/internal/pkg/cache"
+	"github.com/boschglobal/dse.sdp/ast/internal/pkg/override"
+	"github.com/boschglobal/dse.sdp/ast/internal/pkg/provider"
 )
 
 type ResolveCommand struct {
@@ -32,9 +40,28 @@ type ResolveCommand struct {
 	repoName     string
 	metadataFile string
 	cacheDir     string
+	lockFile     string
+	offline      bool
+	verify       bool
+	providerFile string
+	cacheTTL     time.Duration
+	refresh      string
+	jobs         int
+	timeout      time.Duration
+	retries      int
+	overrideFile string
+
+	overrideFlags  override.Flag
+	overriddenUses []string
 
 	yamlAst      map[string]interface{}
 	yamlMetadata map[string]interface{}
+	lock         *LockFile
+	lockedUses   []string
+	providers    *provider.Registry
+	cache        *cache.Cache
+	refreshUses  []string
+	client       *http.Client
 }
 
 var luaModels []string
@@ -82,6 +109,17 @@ func NewResolveCommand(name string) *ResolveCommand {
 	c.FlagSet().StringVar(&c.repoName, "uses", "", "repository name (hidden)")
 	c.FlagSet().StringVar(&c.metadataFile, "file", "", "path to metadata file")
 	c.FlagSet().StringVar(&c.cacheDir, "cache", "out/cache", "cache directory")
+	c.FlagSet().DurationVar(&c.cacheTTL, "cache-ttl", 0, "refetch cache entries older than this duration (e.g. 24h, 0 never expires)")
+	c.FlagSet().StringVar(&c.refresh, "refresh", "", "comma separated list of uses names to refetch (bypass the cache)")
+	c.FlagSet().IntVar(&c.jobs, "jobs", 4, "number of parallel metadata fetches")
+	c.FlagSet().DurationVar(&c.timeout, "timeout", 30*time.Second, "timeout for each metadata request")
+	c.FlagSet().IntVar(&c.retries, "retries", 3, "retries for failed metadata requests (network errors and 5xx)")
+	c.FlagSet().StringVar(&c.lockFile, "lock", "", "path to lockfile (e.g. sdp.lock)")
+	c.FlagSet().BoolVar(&c.offline, "offline", false, "resolve from lockfile and cache only (no network access)")
+	c.FlagSet().StringVar(&c.providerFile, "providers", "", "path to metadata provider config file")
+	c.FlagSet().BoolVar(&c.verify, "verify", false, "verify the lockfile against the cache (AST is not updated)")
+	c.FlagSet().Var(&c.overrideFlags, "override", "override a uses entry with a local directory or file: name=file:///path (repeatable)")
+	c.FlagSet().StringVar(&c.overrideFile, "override-file", "", "path to override file (default sdp.override.yaml, if present)")
 	return c
 }
 
@@ -108,10 +146,53 @@ func (c *ResolveCommand) Run() error {
 	if err := c.loadYamlAST(); err != nil {
 		return err
 	}
+	providers, err := provider.LoadRegistry(c.providerFile)
+	if err != nil {
+		return err
+	}
+	c.providers = providers
+	if c.cacheDir != "" {
+		cacheIndex, err := cache.Open(c.cacheDir)
+		if err != nil {
+			return err
+		}
+		c.cache = cacheIndex
+	}
+	if c.refresh != "" {
+		c.refreshUses = strings.Split(c.refresh, ",")
+	}
+	c.client = &http.Client{Timeout: c.timeout}
+	if c.lockFile != "" {
+		slog.Info("Reading lockfile", "file", c.lockFile)
+		lock, err := LoadLockFile(c.lockFile)
+		if err != nil {
+			return err
+		}
+		c.lock = lock
+	}
+	if !c.verify {
+		if err := c.applyOverrides(); err != nil {
+			return err
+		}
+	}
+	if err := c.resolveVersions(); err != nil {
+		return err
+	}
+	if c.verify {
+		return c.verifyLock()
+	}
 	slog.Info("Load metadata files")
 	if err := c.loadMetadata(); err != nil {
 		return err
 	}
+	if c.lock != nil {
+		// Overridden uses entries keep their lock entry.
+		c.lock.Retain(append(c.lockedUses, c.overriddenUses...))
+		slog.Info("Updating lockfile", "file", c.lockFile)
+		if err := c.lock.Save(c.lockFile); err != nil {
+			return err
+		}
+	}
 	slog.Info("Updating AST file", "file", c.inputFile)
 	if err := c.updateMetadata(); err != nil {
 		return err
@@ -125,14 +206,6 @@ func calculateSha256(url string) string {
 	return hashString
 }
 
-func dirExists(path string) bool {
-	info, err := os.Stat(path)
-	if os.IsNotExist(err) {
-		return false
-	}
-	return info.IsDir()
-}
-
 func FileExists(path string) bool {
 	info, err := os.Stat(path)
 	if os.IsNotExist(err) {
@@ -141,32 +214,6 @@ func FileExists(path string) bool {
 	return err == nil && !info.IsDir()
 }
 
-func createCacheDir(path string) {
-	err := os.MkdirAll(path, os.ModePerm)
-	if err != nil {
-		slog.Error("Unable to create cache dir", "path", path, "err", err)
-		return
-	}
-}
-
-func saveCacheFile(filePath string, data map[string]interface{}) error {
-	yamlData, err := yaml.Marshal(data)
-	if len(yamlData) != 3 {
-		if err != nil {
-			return fmt.Errorf("failed to marshal YAML: %v", err)
-		}
-
-		if err := os.WriteFile(filePath, yamlData, 0644); err != nil {
-			return fmt.Errorf("failed to write cache file: %v", err)
-		}
-	}
-	return nil
-}
-
-func appendFileName(path, filename string) string {
-	return filepath.Join(path, filename)
-}
-
 func (c *ResolveCommand) loadYamlAST() error {
 	data, err := os.ReadFile(c.inputFile)
 	if err != nil {
@@ -331,10 +378,11 @@ func (c *ResolveCommand) loadMetadata() error {
 		slog.Error("Path spec/users not found in AST file")
 		return nil
 	}
+	jobs := []*metadataJob{}
 	for _, _use := range uses.([]interface{}) {
 		use := _use.(map[string]interface{})
 		// Fetch metadata.
-		var yamlData = map[string]interface{}{}
+		job := &metadataJob{use: use}
 		slog.Debug("Fetch metadata for uses", "name", use["name"].(string))
 		useUrl, ok := use["url"].(string)
 		if !ok {
@@ -356,67 +404,81 @@ func (c *ResolveCommand) loadMetadata() error {
 				return nil
 			}
 			slog.Info("Loading Taskfile from local path", "path", abs)
-			yamlData, err = loadTaskfile(abs)
-
+			job.yamlData, _ = loadTaskfile(abs)
+			job.loaded = true
 		} else {
 			// eg uses block, dse.sdp https://github.com/boschglobal/dse.sdp v0.8.26
-			rawUrls := genGitRawURL(use)
-			if len(rawUrls) == 0 {
+			job.rawUrls = c.genRawURLs(use)
+			if len(job.rawUrls) == 0 {
 				continue
 			}
-			var loaded bool
-			for _, rawUrl := range rawUrls {
-				// Search the cache.
-				if c.cacheDir != "" {
-					var cacheFilepath = appendFileName(c.cacheDir, calculateSha256(rawUrl))
-					if !dirExists(c.cacheDir) {
-						createCacheDir(c.cacheDir)
-					}
-
-					if FileExists(cacheFilepath) {
-						data, err := os.ReadFile(cacheFilepath)
-						if err != nil {
-							continue
-						}
-						if err := yaml.Unmarshal(data, &yamlData); err == nil {
-							slog.Info("Metadata download", "url", rawUrl)
-							loaded = true
-							break
-						}
-					} else {
-						yamlData = fetchMetadata(rawUrl, use)
-						if len(yamlData) != 0 {
-							slog.Info("Metadata download", "url", rawUrl)
-							saveCacheFile(cacheFilepath, yamlData)
-							loaded = true
-							break
-						}
-					}
-				} else {
-					yamlData = fetchMetadata(rawUrl, use)
-					if len(yamlData) != 0 {
-						loaded = true
-						break
-					}
+		}
+		jobs = append(jobs, job)
+	}
+
+	// Fetch remote metadata (in parallel), then merge in AST order.
+	c.fetchRemoteMetadata(jobs)
+	for _, job := range jobs {
+		name := job.use["name"].(string)
+		if job.rawUrls != nil {
+			if c.lock != nil {
+				c.lockedUses = append(c.lockedUses, name)
+				if job.lockEntry != nil {
+					c.lock.Update(*job.lockEntry)
 				}
 			}
-
-			if !loaded {
+			if job.err != nil {
+				return job.err
+			}
+			if !job.loaded {
 				slog.Warn(
 					"404 Not Found: Taskfile.yml / Taskfile.yaml not found",
-					"use", use["name"],
-					"url", use["url"],
+					"use", job.use["name"],
+					"url", job.use["url"],
 				)
 				continue
 			}
 		}
 
-		slog.Info("Update metadata for repo", "name", use["name"].(string))
-		c.yamlMetadata[use["name"].(string)] = yamlData
+		slog.Info("Update metadata for repo", "name", name)
+		c.yamlMetadata[name] = job.yamlData
 	}
 	return nil
 }
 
+type metadataJob struct {
+	use     map[string]interface{}
+	rawUrls []string
+
+	yamlData  map[string]interface{}
+	loaded    bool
+	lockEntry *LockEntry
+	err       error
+}
+
+// fetchRemoteMetadata loads the metadata of remote uses entries with a bounded
+// pool of workers (see -jobs).
+func (c *ResolveCommand) fetchRemoteMetadata(jobs []*metadataJob) {
+	queue := make(chan *metadataJob)
+	var wg sync.WaitGroup
+	for range max(1, c.jobs) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range queue {
+				job.yamlData, job.loaded, job.lockEntry, job.err = c.loadRemoteMetadata(job.use, job.rawUrls)
+			}
+		}()
+	}
+	for _, job := range jobs {
+		if job.rawUrls != nil {
+			queue <- job
+		}
+	}
+	close(queue)
+	wg.Wait()
+}
+
 func (c *ResolveCommand) updateMetadata() error {
 	c.updateAstUsesMetadata()
 	c.updateAstModelMetadata()
@@ -427,94 +489,221 @@ func (c *ResolveCommand) updateMetadata() error {
 	return nil
 }
 
-func genGitRawURL(useMap map[string]interface{}) []string {
+// genRawURLs returns the candidate Taskfile URLs of a uses entry, as calculated
+// by the provider of the uses host.
+func (c *ResolveCommand) genRawURLs(useMap map[string]interface{}) []string {
 	useUrl, ok := useMap["url"].(string)
 	if !ok {
 		slog.Error("Invalid or missing URL in uses map")
 		return nil
 	}
 	version, _ := useMap["version"].(string)
+	return c.providers.TaskfileURLs(useUrl, version)
+}
 
-	// Encode the URL, especially for https://{{.GHE_TOKEN}}@github ....
-	u, _ := func() (*url.URL, error) {
-		_u := useUrl
-		_u = strings.ReplaceAll(_u, `{`, `%7B`)
-		_u = strings.ReplaceAll(_u, `}`, `%7D`)
-		return url.Parse(_u)
-	}()
-	if strings.HasPrefix(u.Host, "github.") == false {
-		slog.Debug("Unsupported metadata url", "url", useUrl)
-		return nil
+// loadRemoteMetadata loads the metadata of a uses entry from the cache or by
+// fetching the Taskfile variants (rawUrls). When a lockfile is used, a locked
+// entry is loaded from its resolved URL and the content is verified, otherwise
+// a new lock entry is returned. Safe for concurrent use.
+func (c *ResolveCommand) loadRemoteMetadata(use map[string]interface{}, rawUrls []string) (map[string]interface{}, bool, *LockEntry, error) {
+	name := use["name"].(string)
+	useUrl, _ := use["url"].(string)
+	version, _ := use["version"].(string)
+	refresh := slices.Contains(c.refreshUses, name)
+
+	if c.lock != nil {
+		if entry := c.lock.Lookup(name, useUrl, version); entry != nil {
+			data := c.readMetadata(entry.Resolved, refresh)
+			if data == nil {
+				if c.offline {
+					return nil, false, nil, fmt.Errorf("offline: uses '%s' is locked but not in cache (%s)", name, entry.Resolved)
+				}
+				return nil, false, nil, fmt.Errorf("uses '%s': locked metadata not found (%s)", name, entry.Resolved)
+			}
+			if sha := contentSha256(data); sha != entry.Sha256 {
+				return nil, false, nil, fmt.Errorf("uses '%s': sha256 mismatch for %s (lock %s, got %s)", name, entry.Resolved, entry.Sha256, sha)
+			}
+			yamlData, err := parseMetadata(data)
+			if err != nil {
+				return nil, false, nil, fmt.Errorf("Error parsing metadata (%s): %v", entry.Resolved, err)
+			}
+			slog.Info("Metadata locked", "url", entry.Resolved)
+			return yamlData, true, nil, nil
+		}
+	}
+
+	for _, rawUrl := range rawUrls {
+		data := c.readMetadata(rawUrl, refresh)
+		if data == nil {
+			continue
+		}
+		yamlData, err := parseMetadata(data)
+		if err != nil {
+			continue
+		}
+		slog.Info("Metadata download", "url", rawUrl)
+		entry := newLockEntry(name, useUrl, version, rawUrl, data)
+		return yamlData, true, &entry, nil
+	}
+	if c.offline {
+		return nil, false, nil, fmt.Errorf("offline: uses '%s' cannot be resolved from lockfile or cache (url=%s, version=%s)", name, useUrl, version)
+	}
+	return nil, false, nil, nil
+}
+
+// parseMetadata parses metadata content (a Taskfile). Content which is not a
+// (non empty) YAML mapping, e.g. an HTML error page, is rejected.
+func parseMetadata(data []byte) (map[string]interface{}, error) {
+	yamlData := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &yamlData); err != nil {
+		return nil, err
+	}
+	if len(yamlData) == 0 {
+		return nil, fmt.Errorf("empty metadata")
+	}
+	return yamlData, nil
+}
+
+// readMetadata reads the metadata content from the cache, or fetches (and then
+// caches) the content. Stale cache entries (see -cache-ttl) are refetched with a
+// conditional request. Fetched content is only cached when it can be parsed
+// (see parseMetadata). Returns nil if the content is not available.
+func (c *ResolveCommand) readMetadata(rawUrl string, refresh bool) []byte {
+	var cached []byte
+	var etag string
+	if c.cache != nil {
+		if data, entry, ok := c.cache.Get(rawUrl); ok {
+			switch {
+			case c.offline:
+				return data
+			case refresh:
+				slog.Info("Cache refresh", "url", rawUrl)
+			case cache.Stale(entry, c.cacheTTL):
+				slog.Info("Cache entry stale", "url", rawUrl)
+				cached = data
+				if entry != nil {
+					etag = entry.ETag
+				}
+			default:
+				return data
+			}
+		}
 	}
-	pathParts := strings.Split(u.Path, string(os.PathSeparator))
-	if len(pathParts) > 3 {
-		// Not a repo path, more likely an asset link (for download).
+	if c.offline {
 		return nil
 	}
-	useUrl = func() string {
-		var finalUrl *url.URL
-		switch u.Host {
-		case "github.com":
-			u.Host = "raw.githubusercontent.com"
-			finalUrl, _ = u.Parse(fmt.Sprintf("/%s/%s/refs/tags/%s/Taskfile.yml", pathParts[1], pathParts[2], version))
-		case "github.boschdevcloud.com":
-			u.Host = "raw.github.boschdevcloud.com"
-			finalUrl, _ = u.Parse(fmt.Sprintf("/%s/%s/%s/Taskfile.yml", pathParts[1], pathParts[2], version))
-		default:
-			slog.Error("Unsupported URL hostname")
-		}
-		return func() string {
-			// Remove encoding.
-			_u := finalUrl.String()
-			_u = strings.ReplaceAll(_u, `%7B`, `{`)
-			_u = strings.ReplaceAll(_u, `%7D`, `}`)
-			return _u
-		}()
-	}()
+	data, respEtag, notModified := c.fetchData(rawUrl, etag)
+	if notModified {
+		if err := c.cache.Touch(rawUrl); err != nil {
+			slog.Error("Unable to update cache index", "err", err)
+		}
+		return cached
+	}
+	if data != nil {
+		if _, err := parseMetadata(data); err != nil {
+			slog.Warn("Invalid metadata", "url", artifact.RedactUrl(rawUrl), "err", err)
+			data = nil
+		}
+	}
+	if data == nil {
+		if cached != nil {
+			slog.Warn("Metadata fetch failed, using stale cache entry", "url", rawUrl)
+		}
+		return cached
+	}
+	if c.cache != nil {
+		if err := c.cache.Put(rawUrl, data, respEtag); err != nil {
+			slog.Error("Unable to write cache file", "url", rawUrl, "err", err)
+		}
+	}
+	return data
+}
 
-	return []string{
-		strings.Replace(useUrl, "Taskfile.yml", "Taskfile.sdp.yml", 1),
-		strings.Replace(useUrl, "Taskfile.yml", "Taskfile.sdp.yaml", 1),
-		strings.Replace(useUrl, "Taskfile.yml", "Taskfile.yml", 1),
-		strings.Replace(useUrl, "Taskfile.yml", "Taskfile.yaml", 1),
+func (c *ResolveCommand) verifyLock() error {
+	if c.lock == nil {
+		return fmt.Errorf("verify requires a lockfile (-lock)")
+	}
+	remoteUses := []map[string]string{}
+	if uses, ok := getYamlPath(c.yamlAst, "spec", "uses").([]interface{}); ok {
+		for _, _use := range uses {
+			use := _use.(map[string]interface{})
+			// Overridden uses entries are verified with their original URL.
+			useUrl := originalUrl(use)
+			if strings.HasPrefix(useUrl, "file://") {
+				continue
+			}
+			u := map[string]string{"url": useUrl}
+			for _, k := range []string{"name", "version"} {
+				u[k], _ = use[k].(string)
+			}
+			if len(c.providers.TaskfileURLs(useUrl, u["version"])) == 0 {
+				continue
+			}
+			remoteUses = append(remoteUses, u)
+		}
 	}
+	drift := c.lock.VerifyUses(remoteUses)
+	drift = append(drift, c.lock.Verify(c.cacheDir)...)
+	for _, d := range drift {
+		fmt.Fprintf(flag.CommandLine.Output(), "%s\n", d)
+	}
+	if len(drift) > 0 {
+		return fmt.Errorf("lockfile verify failed: %d issue(s) found", len(drift))
+	}
+	fmt.Fprintf(flag.CommandLine.Output(), "Lockfile verified: %s\n", c.lockFile)
+	return nil
+}
+
+// fetchData fetches the content of a URL, if an etag is provided the request is
+// conditional (notModified is then set when the server responds with 304).
+// Network errors and 5xx responses are retried (see -retries).
+func (c *ResolveCommand) fetchData(url string, etag string) (data []byte, respEtag string, notModified bool) {
+	artifact.Retry(c.retries, "Metadata fetch failed, retrying", url, func() bool {
+		var retry bool
+		data, respEtag, notModified, retry = c.fetchOnce(url, etag)
+		return retry
+	})
+	return data, respEtag, notModified
 }
 
-func fetchMetadata(url string, use map[string]interface{}) map[string]interface{} {
-	var yamlData = map[string]interface{}{}
+func (c *ResolveCommand) fetchOnce(url string, etag string) (data []byte, respEtag string, notModified bool, retry bool) {
 	url = strings.ReplaceAll(url, `{{.GHE_TOKEN}}`, os.Getenv("GHE_TOKEN"))
-	resp, err := http.Get(url)
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		slog.Error("Error fetching the URL", "err", err)
+		return nil, "", false, false
+	}
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	client := c.client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
 	if err != nil {
 		slog.Error("Error fetching the URL", "err", err)
-		return yamlData
+		return nil, "", false, true
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified && etag != "" {
+		return nil, etag, true, false
+	}
 	if resp.StatusCode == http.StatusNotFound {
-		return yamlData
+		return nil, "", false, false
 	}
 
 	if resp.StatusCode != http.StatusOK {
 		slog.Error("Bad return code", "code", resp.StatusCode, "url", url)
-		return yamlData
+		return nil, "", false, resp.StatusCode >= http.StatusInternalServerError
 	}
 
-	data, err := io.ReadAll(resp.Body)
+	data, err = io.ReadAll(resp.Body)
 	if err != nil {
 		slog.Error("Error reading the YAML file", "err", err)
-		return yamlData
-	}
-	if err := yaml.Unmarshal(data, &yamlData); err != nil {
-		slog.Error("Error parsing YAML", "err", err)
-		slog.Error(
-			fmt.Sprintf(
-				"failed to unmarshal taskfile yaml\nrepo=%s\n%s",
-				url,
-				err,
-			),
-		)
-		return yamlData
+		return nil, "", false, true
 	}
-	return yamlData
+	return data, resp.Header.Get("ETag"), false, false
 }
 
 func updateFile(data interface{}, filePath string) error {
@@ -571,7 +760,7 @@ func (c *ResolveCommand) updateAstUsesMetadata() {
 				continue
 			}
 
-			if err != nil || strings.HasPrefix(parsedUrl.Host, "github.") == false {
+			if err != nil || c.providers.Lookup(parsedUrl.Host) == nil {
 				continue
 			}
 
@@ -633,8 +822,9 @@ func (c *ResolveCommand) updateAstModelMetadata() {
 				continue
 			}
 
-			for repoName, _repo := range repos.(map[string]interface{}) {
-				repo := _repo.(map[string]interface{})
+			// Iterate repos in a stable order (deterministic output).
+			for _, repoName := range slices.Sorted(maps.Keys(repos.(map[string]interface{}))) {
+				repo := repos.(map[string]interface{})[repoName].(map[string]interface{})
 				models := getYamlPath(repo, "metadata", "models")
 				if models == nil {
 					slog.Debug("Repo does not have metadata", "repoName", repoName)
@@ -733,10 +923,10 @@ func (c *ResolveCommand) updateAstModelMetadata() {
 
 						// Step 3: Normalize ALL vars back to []{name,value}
 						var out []interface{}
-						for k, v := range existingVars {
+						for _, k := range slices.Sorted(maps.Keys(existingVars)) {
 							out = append(out, map[string]interface{}{
 								"name":  k,
-								"value": v,
+								"value": existingVars[k],
 							})
 						}
 						model["vars"] = out
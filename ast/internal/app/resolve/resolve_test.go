@@ -3,3 +3,387 @@
 // SPDX-License-Identifier: Apache-2.0
 
 package resolve
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/boschglobal/dse.sdp/ast/internal/pkg/artifact"
+	"github.com/boschglobal/dse.sdp/ast/internal/pkg/cache"
+)
+
+// setupProviderTest stages an AST with a uses entry hosted on the server, and
+// a provider config (http provider, path: /sdp/<repo>/<version>/<taskfile>).
+func setupProviderTest(t *testing.T, server *httptest.Server) {
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	t.Chdir(t.TempDir())
+	require.NoError(t, os.MkdirAll("out", 0755))
+	ast := strings.ReplaceAll(lockTestAst, "https://github.com/boschglobal/dse.modelc", server.URL+"/boschglobal/dse.modelc")
+	require.NoError(t, os.WriteFile("out/ast.yaml", []byte(ast), 0644))
+	require.NoError(t, os.WriteFile("providers.yaml", []byte(`---
+providers:
+  - host: `+u.Host+`
+    type: http
+    base_url: `+server.URL+`/sdp
+    path: "{{.Repo}}/{{.Version}}/{{.Taskfile}}"
+`), 0644))
+}
+
+func TestResolve_provider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/sdp/dse.modelc/v2.1.23/Taskfile.yml" {
+			w.Write([]byte(lockTestTaskfile))
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+	setupProviderTest(t, server)
+
+	require.NoError(t, runResolve(t, "-providers", "providers.yaml", "-lock", "sdp.lock"))
+
+	data, err := os.ReadFile("out/ast.yaml")
+	require.NoError(t, err)
+	var doc map[string]interface{}
+	require.NoError(t, yaml.Unmarshal(data, &doc))
+	use := getYamlPath(doc, "spec", "uses").([]interface{})[0].(map[string]interface{})
+	assert.NotNil(t, getYamlPath(use, "metadata", "package"))
+	assert.Equal(t, "dse.modelc", getYamlPath(doc, "spec", "stacks").([]interface{})[0].(map[string]interface{})["models"].([]interface{})[0].(map[string]interface{})["uses"])
+
+	lock, err := LoadLockFile("sdp.lock")
+	require.NoError(t, err)
+	require.Len(t, lock.Uses, 1)
+	assert.Equal(t, server.URL+"/sdp/dse.modelc/v2.1.23/Taskfile.yml", lock.Uses[0].Resolved)
+}
+
+func TestResolve_sha256(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/sdp/dse.modelc/v2.1.23/Taskfile.yml" {
+			w.Write([]byte(lockTestTaskfile))
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+	setupProviderTest(t, server)
+	pin := func(sha string) {
+		data, err := os.ReadFile("out/ast.yaml")
+		require.NoError(t, err)
+		ast := strings.Replace(string(data), "version: v2.1.23\n", "version: v2.1.23\n      metadata:\n        sha256: "+sha+"\n", 1)
+		require.NoError(t, os.WriteFile("out/ast.yaml", []byte(ast), 0644))
+	}
+
+	// The pin (of the package) is kept, the metadata Taskfile is not verified
+	// against the pin (but against the lockfile).
+	pin(contentSha256([]byte("package")))
+	require.NoError(t, runResolve(t, "-providers", "providers.yaml", "-lock", "sdp.lock"))
+	data, err := os.ReadFile("out/ast.yaml")
+	require.NoError(t, err)
+	var doc map[string]interface{}
+	require.NoError(t, yaml.Unmarshal(data, &doc))
+	use := getYamlPath(doc, "spec", "uses").([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, contentSha256([]byte("package")), getYamlPath(use, "metadata", "sha256"))
+	assert.NotNil(t, getYamlPath(use, "metadata", "package"))
+	lock, err := LoadLockFile("sdp.lock")
+	require.NoError(t, err)
+	require.Len(t, lock.Uses, 1)
+	assert.Equal(t, contentSha256([]byte(lockTestTaskfile)), lock.Uses[0].Sha256)
+}
+
+func TestResolve_cacheTTL(t *testing.T) {
+	var fetches, conditional int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/sdp/dse.modelc/v2.1.23/Taskfile.yml" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			conditional++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		fetches++
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(lockTestTaskfile))
+	}))
+	defer server.Close()
+	setupProviderTest(t, server)
+	taskfileUrl := server.URL + "/sdp/dse.modelc/v2.1.23/Taskfile.yml"
+
+	require.NoError(t, runResolve(t, "-providers", "providers.yaml"))
+	assert.Equal(t, 1, fetches)
+	c, err := cache.Open("out/cache")
+	require.NoError(t, err)
+	entry := c.Lookup(taskfileUrl)
+	require.NotNil(t, entry)
+	assert.Equal(t, `"v1"`, entry.ETag)
+	assert.Equal(t, int64(len(lockTestTaskfile)), entry.Size)
+
+	// Cached.
+	require.NoError(t, runResolve(t, "-providers", "providers.yaml"))
+	assert.Equal(t, 1, fetches)
+
+	// Stale, conditional request.
+	require.NoError(t, runResolve(t, "-providers", "providers.yaml", "-cache-ttl", "1ns"))
+	assert.Equal(t, 1, fetches)
+	assert.Equal(t, 1, conditional)
+
+	// Refresh, bypass the cache.
+	require.NoError(t, runResolve(t, "-providers", "providers.yaml", "-refresh", "dse.modelc"))
+	assert.Equal(t, 2, fetches)
+}
+
+func TestResolve_invalidMetadata(t *testing.T) {
+	var unavailable atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/sdp/dse.modelc/v2.1.23/Taskfile.yml" {
+			http.NotFound(w, r)
+			return
+		}
+		if unavailable.Load() {
+			// Error page with status OK (e.g. a proxy).
+			w.Write([]byte("<html><body>Service unavailable</body></html>\n"))
+			return
+		}
+		w.Write([]byte(lockTestTaskfile))
+	}))
+	defer server.Close()
+	setupProviderTest(t, server)
+	taskfileUrl := server.URL + "/sdp/dse.modelc/v2.1.23/Taskfile.yml"
+	require.NoError(t, runResolve(t, "-providers", "providers.yaml", "-lock", "sdp.lock"))
+
+	// The error page is neither cached nor locked, the stale entry is used.
+	unavailable.Store(true)
+	require.NoError(t, runResolve(t, "-providers", "providers.yaml", "-lock", "sdp.lock", "-cache-ttl", "1ns"))
+	c, err := cache.Open("out/cache")
+	require.NoError(t, err)
+	data, _, ok := c.Get(taskfileUrl)
+	require.True(t, ok)
+	assert.Equal(t, lockTestTaskfile, string(data))
+	lock, err := LoadLockFile("sdp.lock")
+	require.NoError(t, err)
+	require.Len(t, lock.Uses, 1)
+	assert.Equal(t, contentSha256([]byte(lockTestTaskfile)), lock.Uses[0].Sha256)
+	require.NoError(t, runResolve(t, "-providers", "providers.yaml", "-lock", "sdp.lock", "-offline"))
+}
+
+func TestResolve_retry(t *testing.T) {
+	backoff := artifact.RetryBackoff
+	artifact.RetryBackoff = time.Millisecond
+	t.Cleanup(func() { artifact.RetryBackoff = backoff })
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/sdp/dse.modelc/v2.1.23/Taskfile.yml" {
+			http.NotFound(w, r)
+			return
+		}
+		if requests.Add(1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(lockTestTaskfile))
+	}))
+	defer server.Close()
+	setupProviderTest(t, server)
+
+	require.NoError(t, runResolve(t, "-providers", "providers.yaml", "-retries", "2"))
+	assert.Equal(t, int32(3), requests.Load())
+
+	// Retries exhausted.
+	requests.Store(0)
+	c := NewResolveCommand("test_resolve")
+	require.NoError(t, c.Parse([]string{"-retries", "1"}))
+	data, _, _ := c.fetchData(server.URL+"/sdp/dse.modelc/v2.1.23/Taskfile.yml", "")
+	assert.Nil(t, data)
+	assert.Equal(t, int32(2), requests.Load())
+
+	// Not found, no retry.
+	requests.Store(0)
+	data, _, _ = c.fetchData(server.URL+"/sdp/dse.modelc/v2.1.23/Taskfile.sdp.yml", "")
+	assert.Nil(t, data)
+}
+
+func TestResolve_timeout(t *testing.T) {
+	backoff := artifact.RetryBackoff
+	artifact.RetryBackoff = time.Millisecond
+	t.Cleanup(func() { artifact.RetryBackoff = backoff })
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		time.Sleep(200 * time.Millisecond)
+		w.Write([]byte(lockTestTaskfile))
+	}))
+	defer server.Close()
+
+	c := NewResolveCommand("test_resolve")
+	require.NoError(t, c.Parse([]string{"-retries", "1"}))
+	c.client = &http.Client{Timeout: 20 * time.Millisecond}
+	data, _, _ := c.fetchData(server.URL+"/Taskfile.yml", "")
+	assert.Nil(t, data)
+	assert.Equal(t, int32(2), requests.Load())
+}
+
+func TestResolve_parallel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Path: /sdp/<repo>/<version>/Taskfile.yml
+		parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+		if len(parts) != 4 || parts[3] != "Taskfile.yml" {
+			http.NotFound(w, r)
+			return
+		}
+		// Vary the response time, so that fetches complete out of order.
+		repo := parts[1]
+		time.Sleep(time.Duration(len(repo)%5) * 5 * time.Millisecond)
+		w.Write([]byte(strings.ReplaceAll(lockTestTaskfile, "dse.modelc.csv", repo+".model")))
+	}))
+	defer server.Close()
+	setupProviderTest(t, server)
+
+	var ast strings.Builder
+	ast.WriteString("---\nkind: Simulation\nspec:\n  arch: linux-amd64\n  stacks:\n    - name: default\n      models:\n")
+	for i := range 16 {
+		fmt.Fprintf(&ast, "        - name: model_%d\n          model: repo%s.model\n", i, strings.Repeat("x", i))
+	}
+	ast.WriteString("  uses:\n")
+	for i := range 16 {
+		fmt.Fprintf(&ast, "    - name: repo%s\n      url: %s/org/repo%s\n      version: v1.0.%d\n", strings.Repeat("x", i), server.URL, strings.Repeat("x", i), i)
+	}
+
+	results := [][]byte{}
+	for _, jobs := range []string{"1", "8"} {
+		require.NoError(t, os.RemoveAll("out/cache"))
+		require.NoError(t, os.WriteFile("out/ast.yaml", []byte(ast.String()), 0644))
+		require.NoError(t, runResolve(t, "-providers", "providers.yaml", "-jobs", jobs, "-lock", "sdp.lock"))
+		data, err := os.ReadFile("out/ast.yaml")
+		require.NoError(t, err)
+		lock, err := os.ReadFile("sdp.lock")
+		require.NoError(t, err)
+		results = append(results, append(data, lock...))
+	}
+	assert.Equal(t, string(results[0]), string(results[1]))
+	assert.Contains(t, string(results[1]), "resolved: "+server.URL+"/sdp/repoxxxxxxxxxxxxxxx/v1.0.15/Taskfile.yml")
+}
+
+func TestResolve_versionConstraint(t *testing.T) {
+	tags := `["v2.0.0", "v2.1.20", "v2.1.23", "v2.2.0", "nightly"]`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/sdp/dse.modelc/tags.json":
+			w.Write([]byte(tags))
+		case strings.HasSuffix(r.URL.Path, "/Taskfile.yml"):
+			w.Write([]byte(lockTestTaskfile))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	setupProviderTest(t, server)
+	ast, err := os.ReadFile("out/ast.yaml")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile("out/ast.yaml", []byte(strings.ReplaceAll(string(ast), "version: v2.1.23", "version: ~2.1")), 0644))
+	f, err := os.OpenFile("providers.yaml", os.O_APPEND|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	f.WriteString("    tags: \"{{.Repo}}/tags.json\"\n")
+	f.Close()
+
+	resolvedUse := func() map[string]interface{} {
+		data, err := os.ReadFile("out/ast.yaml")
+		require.NoError(t, err)
+		var doc map[string]interface{}
+		require.NoError(t, yaml.Unmarshal(data, &doc))
+		return getYamlPath(doc, "spec", "uses").([]interface{})[0].(map[string]interface{})
+	}
+
+	require.NoError(t, runResolve(t, "-providers", "providers.yaml", "-lock", "sdp.lock"))
+	use := resolvedUse()
+	assert.Equal(t, "v2.1.23", use["version"])
+	assert.Equal(t, "~2.1", getYamlPath(use, "metadata", "version_constraint"))
+	lock, err := LoadLockFile("sdp.lock")
+	require.NoError(t, err)
+	require.Len(t, lock.Uses, 1)
+	assert.Equal(t, "v2.1.23", lock.Uses[0].Tag)
+
+	// New tag, the locked version is kept.
+	tags = `["v2.1.23", "v2.1.24", "v2.2.0"]`
+	require.NoError(t, runResolve(t, "-providers", "providers.yaml", "-lock", "sdp.lock"))
+	assert.Equal(t, "v2.1.23", resolvedUse()["version"])
+
+	// Refresh, the newest matching version is selected.
+	require.NoError(t, runResolve(t, "-providers", "providers.yaml", "-lock", "sdp.lock", "-refresh", "dse.modelc"))
+	assert.Equal(t, "v2.1.24", resolvedUse()["version"])
+	lock, err = LoadLockFile("sdp.lock")
+	require.NoError(t, err)
+	assert.Equal(t, "v2.1.24", lock.Uses[0].Tag)
+
+	// No matching version.
+	tags = `["v3.0.0"]`
+	require.NoError(t, os.Remove("sdp.lock"))
+	err = runResolve(t, "-providers", "providers.yaml")
+	assert.ErrorContains(t, err, "no version matches '~2.1'")
+}
+
+func TestResolve_override(t *testing.T) {
+	setupLockTest(t)
+	require.NoError(t, runResolve(t, "-offline", "-lock", "sdp.lock"))
+
+	// Local checkout of the repo.
+	checkout, err := filepath.Abs("dse.modelc")
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(checkout, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(checkout, "Taskfile.yml"), []byte(lockTestTaskfile), 0644))
+	require.NoError(t, os.WriteFile("sdp.override.yaml", []byte("overrides:\n  dse.modelc: dse.modelc\n"), 0644))
+
+	require.NoError(t, runResolve(t, "-offline", "-lock", "sdp.lock"))
+	data, err := os.ReadFile("out/ast.yaml")
+	require.NoError(t, err)
+	var doc map[string]interface{}
+	require.NoError(t, yaml.Unmarshal(data, &doc))
+	use := getYamlPath(doc, "spec", "uses").([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "file://"+checkout, use["url"])
+	assert.Equal(t, "https://github.com/boschglobal/dse.modelc", getYamlPath(use, "metadata", "original_url"))
+	assert.NotNil(t, getYamlPath(use, "metadata", "package"))
+	assert.Equal(t, "file://"+checkout, getYamlPath(doc, "metadata", "labels", "override/dse.modelc"))
+
+	// The lock entry is kept, and verified with the original URL.
+	lock, err := LoadLockFile("sdp.lock")
+	require.NoError(t, err)
+	require.Len(t, lock.Uses, 1)
+	require.NoError(t, runResolve(t, "-verify", "-lock", "sdp.lock"))
+
+	// Option takes precedence over the file.
+	require.NoError(t, runResolve(t, "-offline", "-override", "dse.modelc=file:///not/found"))
+	data, err = os.ReadFile("out/ast.yaml")
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "override/dse.modelc: file:///not/found")
+
+	// Override removed, the original URL is restored.
+	require.NoError(t, os.Remove("sdp.override.yaml"))
+	require.NoError(t, runResolve(t, "-offline", "-lock", "sdp.lock"))
+	data, err = os.ReadFile("out/ast.yaml")
+	require.NoError(t, err)
+	doc = map[string]interface{}{}
+	require.NoError(t, yaml.Unmarshal(data, &doc))
+	use = getYamlPath(doc, "spec", "uses").([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "https://github.com/boschglobal/dse.modelc", use["url"])
+	assert.Nil(t, getYamlPath(use, "metadata", "original_url"))
+	assert.NotContains(t, string(data), "override/")
+
+	err = runResolve(t, "-override", "dse.modelc")
+	assert.ErrorContains(t, err, "invalid override 'dse.modelc'")
+}
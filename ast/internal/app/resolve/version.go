@@ -0,0 +1,86 @@
+// Copyright 2025 Robert Bosch GmbH
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package resolve
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/boschglobal/dse.sdp/ast/internal/pkg/semver"
+)
+
+// resolveVersions replaces version constraints of uses entries (e.g. ~2.3,
+// ^1.2, ">=1.1 <2" or latest) with the concrete version (tag) selected from
+// the tag list of the repo. The constraint is kept in the uses metadata
+// (version_constraint), so that a resolved AST can be resolved again.
+//
+// When a lockfile is used, a locked tag which satisfies the constraint is
+// selected (unless the uses entry is refreshed), which keeps the resolve
+// reproducible (and possible offline).
+func (c *ResolveCommand) resolveVersions() error {
+	uses, ok := getYamlPath(c.yamlAst, "spec", "uses").([]interface{})
+	if !ok {
+		return nil
+	}
+	for _, _use := range uses {
+		use := _use.(map[string]interface{})
+		name, _ := use["name"].(string)
+		useUrl := originalUrl(use)
+		version, _ := use["version"].(string)
+		if v, ok := getYamlPath(use, "metadata", "version_constraint").(string); ok {
+			version = v
+		}
+		if !semver.IsConstraint(version) {
+			continue
+		}
+		constraint, err := semver.ParseConstraint(version)
+		if err != nil {
+			return fmt.Errorf("uses '%s': %v", name, err)
+		}
+
+		tag := c.lockedVersion(name, useUrl, constraint)
+		if tag == "" {
+			if c.verify {
+				return fmt.Errorf("uses '%s': version '%s' not locked", name, version)
+			}
+			if c.offline {
+				return fmt.Errorf("offline: uses '%s' version '%s' cannot be resolved from lockfile", name, version)
+			}
+			tags, err := c.providers.Tags(c.client, useUrl)
+			if err != nil {
+				return fmt.Errorf("uses '%s': %v", name, err)
+			}
+			var found bool
+			if tag, found = constraint.Best(tags); !found {
+				return fmt.Errorf("uses '%s': no version matches '%s' (%d tags)", name, version, len(tags))
+			}
+		}
+		slog.Info("Resolved version", "name", name, "constraint", version, "version", tag)
+		use["version"] = tag
+		if _, ok := use["metadata"].(map[string]interface{}); !ok {
+			use["metadata"] = map[string]interface{}{}
+		}
+		use["metadata"].(map[string]interface{})["version_constraint"] = version
+	}
+	return nil
+}
+
+// lockedVersion returns the locked tag of a uses entry if it satisfies the
+// constraint, otherwise "".
+func (c *ResolveCommand) lockedVersion(name string, url string, constraint *semver.Constraint) string {
+	if c.lock == nil || slices.Contains(c.refreshUses, name) {
+		return ""
+	}
+	for _, e := range c.lock.Uses {
+		if e.Name != name || e.Url != url {
+			continue
+		}
+		if v, ok := semver.Parse(e.Tag); ok && constraint.Check(v) {
+			return e.Tag
+		}
+	}
+	return ""
+}
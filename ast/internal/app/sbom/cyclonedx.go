@@ -0,0 +1,132 @@
+// Copyright 2025 Robert Bosch GmbH
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package sbom
+
+import (
+	"strings"
+	"time"
+
+	"github.com/boschglobal/dse.sdp/ast/internal/pkg/artifact"
+)
+
+// CycloneDX 1.5 (JSON), see https://cyclonedx.org/docs/1.5/json/.
+
+type CycloneDXDocument struct {
+	BomFormat    string                `json:"bomFormat"`
+	SpecVersion  string                `json:"specVersion"`
+	SerialNumber string                `json:"serialNumber"`
+	Version      int                   `json:"version"`
+	Metadata     CycloneDXMetadata     `json:"metadata"`
+	Components   []CycloneDXComponent  `json:"components"`
+	Dependencies []CycloneDXDependency `json:"dependencies"`
+}
+
+type CycloneDXMetadata struct {
+	Timestamp string             `json:"timestamp"`
+	Tools     CycloneDXTools     `json:"tools"`
+	Component CycloneDXComponent `json:"component"`
+}
+
+type CycloneDXTools struct {
+	Components []CycloneDXComponent `json:"components"`
+}
+
+type CycloneDXComponent struct {
+	Type               string                       `json:"type"`
+	BomRef             string                       `json:"bom-ref,omitempty"`
+	Name               string                       `json:"name"`
+	Version            string                       `json:"version,omitempty"`
+	Hashes             []CycloneDXHash              `json:"hashes,omitempty"`
+	Purl               string                       `json:"purl,omitempty"`
+	ExternalReferences []CycloneDXExternalReference `json:"externalReferences,omitempty"`
+	Properties         []CycloneDXProperty          `json:"properties,omitempty"`
+	Components         []CycloneDXComponent         `json:"components,omitempty"`
+}
+
+type CycloneDXHash struct {
+	Alg     string `json:"alg"`
+	Content string `json:"content"`
+}
+
+type CycloneDXExternalReference struct {
+	Type string `json:"type"`
+	Url  string `json:"url"`
+}
+
+type CycloneDXProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type CycloneDXDependency struct {
+	Ref       string   `json:"ref"`
+	DependsOn []string `json:"dependsOn,omitempty"`
+}
+
+// newCycloneDXDocument returns the CycloneDX BOM of the Bom. The simulation is
+// the metadata component, which depends on the components. Licence files are
+// listed as (file) components of their package.
+func newCycloneDXDocument(bom *Bom) CycloneDXDocument {
+	simRef := "simulation"
+	doc := CycloneDXDocument{
+		BomFormat:    "CycloneDX",
+		SpecVersion:  "1.5",
+		SerialNumber: "urn:uuid:" + newUUID(),
+		Version:      1,
+		Metadata: CycloneDXMetadata{
+			Timestamp: time.Now().UTC().Format(time.RFC3339),
+			Tools: CycloneDXTools{Components: []CycloneDXComponent{
+				{Type: "application", Name: "dse-ast"},
+			}},
+			Component: CycloneDXComponent{Type: "application", BomRef: simRef, Name: bom.Name},
+		},
+		Components: []CycloneDXComponent{},
+	}
+	dependsOn := []string{}
+	for _, component := range bom.Components {
+		c := CycloneDXComponent{
+			Type:    "file",
+			BomRef:  component.Ref,
+			Name:    component.Name,
+			Version: component.Version,
+			Purl:    packageUrl(component),
+		}
+		if component.Version != "" {
+			c.Type = "library"
+		}
+		if component.Sha256 != "" {
+			c.Hashes = []CycloneDXHash{{Alg: "SHA-256", Content: strings.ToLower(component.Sha256)}}
+		}
+		if isRemote(component.Url) {
+			c.ExternalReferences = []CycloneDXExternalReference{{Type: "distribution", Url: artifact.RedactUrl(component.Url)}}
+		}
+		property := func(name string, value string) {
+			if value != "" {
+				c.Properties = append(c.Properties, CycloneDXProperty{Name: "sdp:" + name, Value: value})
+			}
+		}
+		property("kind", component.Kind)
+		property("uses", component.Uses)
+		property("path", component.Path)
+		for _, model := range component.Models {
+			property("model", model)
+		}
+		property("package.sha256", component.Pin)
+		for _, license := range component.Licenses {
+			c.Components = append(c.Components, CycloneDXComponent{
+				Type:   "file",
+				Name:   license.Path,
+				Hashes: []CycloneDXHash{{Alg: "SHA-256", Content: license.Sha256}},
+				Properties: []CycloneDXProperty{
+					{Name: "sdp:kind", Value: "license"},
+				},
+			})
+		}
+		doc.Components = append(doc.Components, c)
+		dependsOn = append(dependsOn, component.Ref)
+	}
+	doc.Dependencies = []CycloneDXDependency{{Ref: simRef, DependsOn: dependsOn}}
+	return doc
+}
@@ -0,0 +1,366 @@
+// Copyright 2025 Robert Bosch GmbH
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package sbom
+
+import (
+	"archive/zip"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/boschglobal/dse.clib/extra/go/command"
+	"github.com/boschglobal/dse.clib/extra/go/command/log"
+
+	"github.com/boschglobal/dse.schemas/code/go/dse/ast"
+
+	"github.com/boschglobal/dse.sdp/ast/internal/app/generate"
+	"github.com/boschglobal/dse.sdp/ast/internal/pkg/artifact"
+	"github.com/boschglobal/dse.sdp/ast/internal/pkg/override"
+)
+
+// Output formats.
+const (
+	FormatSpdxJson  = "spdx-json"
+	FormatCycloneDX = "cyclonedx"
+)
+
+// Kinds of components.
+const (
+	KindUses    = "uses"    // Uses entry (repo or file).
+	KindPackage = "package" // Model package (e.g. ModelC-2.1.15-linux-amd64.zip).
+	KindFmu     = "fmu"     // FMU (or package containing the FMU).
+)
+
+type SbomCommand struct {
+	command.Command
+
+	inputFile     string
+	outputFile    string
+	format        string
+	vendorDir     string
+	overrideFlags override.Flag
+	overrideFile  string
+	logLevel      int
+}
+
+func NewSbomCommand(name string) *SbomCommand {
+	c := &SbomCommand{
+		Command: command.Command{
+			Name:    name,
+			FlagSet: flag.NewFlagSet(name, flag.ExitOnError),
+		},
+	}
+	c.FlagSet().StringVar(&c.inputFile, "input", "", "path to Simulation AST file")
+	c.FlagSet().StringVar(&c.outputFile, "output", "", "path to SBOM file (default stdout)")
+	c.FlagSet().StringVar(&c.format, "format", FormatSpdxJson, "SBOM format (spdx-json or cyclonedx)")
+	c.FlagSet().StringVar(&c.vendorDir, "vendor-dir", "vendor", "path to vendor folder (see ast vendor), used to locate packages")
+	c.FlagSet().Var(&c.overrideFlags, "override", "override a uses entry, name=file:///path (repeatable)")
+	c.FlagSet().StringVar(&c.overrideFile, "override-file", "", "path to overrides file (default sdp.override.yaml, if present)")
+	c.FlagSet().IntVar(&c.logLevel, "log", 4, "Loglevel")
+	return c
+}
+
+func (c SbomCommand) Name() string {
+	return c.Command.Name
+}
+
+func (c SbomCommand) FlagSet() *flag.FlagSet {
+	return c.Command.FlagSet
+}
+
+func (c *SbomCommand) Parse(args []string) error {
+	return c.FlagSet().Parse(args)
+}
+
+func (c *SbomCommand) Run() error {
+	slog.SetDefault(log.NewLogger(c.logLevel))
+
+	if c.format != FormatSpdxJson && c.format != FormatCycloneDX {
+		return fmt.Errorf("unsupported format: %s (expected %s or %s)", c.format, FormatSpdxJson, FormatCycloneDX)
+	}
+	c.inputFile = filepath.Join("out", c.inputFile)
+	if !filepath.IsAbs(c.vendorDir) {
+		c.vendorDir = filepath.Join("out", c.vendorDir)
+	}
+
+	spec, doc, err := generate.LoadSimulationAst(c.inputFile)
+	if err != nil {
+		return err
+	}
+	gen := generate.NewGenerateCommand("generate")
+	if err := gen.Parse(c.generateArgs()); err != nil {
+		return err
+	}
+	downloads, err := gen.Downloads(*spec, doc)
+	if err != nil {
+		return err
+	}
+	name := doc.Metadata.Name
+	if name == "" {
+		name = strings.TrimSuffix(filepath.Base(c.inputFile), filepath.Ext(c.inputFile))
+	}
+	bom := c.build(name, *spec, downloads)
+
+	var data []byte
+	switch c.format {
+	case FormatSpdxJson:
+		data, err = json.MarshalIndent(newSpdxDocument(bom), "", "  ")
+	case FormatCycloneDX:
+		data, err = json.MarshalIndent(newCycloneDXDocument(bom), "", "  ")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to marshal SBOM: %v", err)
+	}
+	data = append(data, '\n')
+	if c.outputFile == "" {
+		_, err := flag.CommandLine.Output().Write(data)
+		return err
+	}
+	outputPath := filepath.Join("out", c.outputFile)
+	fmt.Fprintf(flag.CommandLine.Output(), "Writing SBOM: %s\n", outputPath)
+	if err := os.MkdirAll(filepath.Dir(outputPath), os.ModePerm); err != nil {
+		return err
+	}
+	return os.WriteFile(outputPath, data, 0644)
+}
+
+// generateArgs returns the options of the generate command which locates the
+// downloads.
+func (c *SbomCommand) generateArgs() []string {
+	args := []string{fmt.Sprintf("-log=%d", c.logLevel)}
+	for _, o := range c.overrideFlags {
+		args = append(args, "-override", o)
+	}
+	if c.overrideFile != "" {
+		args = append(args, "-override-file", c.overrideFile)
+	}
+	return args
+}
+
+// Bom is the bill of materials of a simulation, independent of the output
+// format.
+type Bom struct {
+	Name       string
+	Components []*Component
+}
+
+// Component is a uses entry, or a package (or FMU) downloaded by the generated
+// Taskfile.
+type Component struct {
+	Ref      string // Unique reference (within the Bom).
+	Kind     string
+	Name     string
+	Version  string
+	Url      string
+	Path     string   // Path inside the archive (uses path=).
+	Sha256   string   // SHA-256 of the file (if known).
+	Pin      string   // SHA-256 pin of a repo (its package).
+	Uses     string   // Uses entry of a package.
+	Models   []string // Models which reference the component.
+	Licenses []LicenseFile
+}
+
+// LicenseFile is a licence file found in a (downloaded) package.
+type LicenseFile struct {
+	Path   string
+	Sha256 string
+	Text   string
+}
+
+// build collects the components of the simulation: the uses entries and the
+// downloads of the model tasks (packages and FMUs). Downloaded files are
+// located in the vendor folder or the download folder of the build (out/), for
+// their checksum and licence files.
+func (c *SbomCommand) build(name string, spec ast.SimulationSpec, downloads []generate.Download) *Bom {
+	bom := &Bom{Name: name, Components: []*Component{}}
+	index, err := artifact.Load(c.vendorDir)
+	if err != nil {
+		slog.Debug("No vendor index", "err", err)
+		index = &artifact.Index{}
+	}
+	byUrl := map[string]*Component{}
+	if spec.Uses != nil {
+		for i, uses := range *spec.Uses {
+			component := &Component{
+				Ref:  fmt.Sprintf("uses-%d-%s", i, uses.Name),
+				Kind: KindUses,
+				Name: uses.Name,
+				Url:  uses.Url,
+			}
+			if uses.Version != nil {
+				component.Version = *uses.Version
+			}
+			if uses.Path != nil {
+				component.Path = *uses.Path
+			}
+			if strings.EqualFold(path.Ext(component.Url), ".fmu") || strings.EqualFold(path.Ext(component.Path), ".fmu") {
+				component.Kind = KindFmu
+			}
+			if component.Version != "" {
+				component.Pin = artifact.UsesSha256(uses)
+			} else {
+				component.Sha256 = artifact.UsesSha256(uses)
+			}
+			bom.Components = append(bom.Components, component)
+			byUrl[uses.Url] = component
+		}
+	}
+
+	for _, download := range downloads {
+		component, ok := byUrl[download.Url]
+		if !ok {
+			component = &Component{
+				Ref:  fmt.Sprintf("package-%d", len(bom.Components)),
+				Kind: KindPackage,
+				Name: path.Base(download.Url),
+				Url:  download.Url,
+			}
+			if u, err := url.Parse(download.Url); err == nil {
+				component.Name = path.Base(u.Path)
+			}
+			if strings.EqualFold(path.Ext(component.Name), ".fmu") {
+				component.Kind = KindFmu
+			}
+			component.Uses = download.Uses
+			if spec.Uses != nil && component.Uses != "" {
+				for _, uses := range *spec.Uses {
+					if uses.Name == component.Uses && uses.Version != nil {
+						component.Version = *uses.Version
+					}
+				}
+			}
+			bom.Components = append(bom.Components, component)
+			byUrl[download.Url] = component
+		}
+		if download.Model != "" && !slices.Contains(component.Models, download.Model) {
+			component.Models = append(component.Models, download.Model)
+		}
+		if component.Licenses != nil {
+			continue
+		}
+		file := c.locate(index, download)
+		if file == "" {
+			slog.Warn("Package not found (run 'ast vendor' or the build first)", "url", download.Url)
+			continue
+		}
+		sha, _, err := artifact.FileSha256(file)
+		if err != nil {
+			slog.Warn("Unable to read package", "file", file, "err", err)
+			continue
+		}
+		pin := component.Sha256
+		if pin == "" {
+			pin = download.Sha256
+		}
+		if pin != "" && !strings.EqualFold(pin, sha) {
+			slog.Warn("Package does not match the uses sha256", "file", file, "sha256", pin)
+		}
+		component.Sha256 = sha
+		component.Licenses = licenseFiles(file)
+	}
+	return bom
+}
+
+// locate returns the local file of a download (vendored, or downloaded by the
+// build), or "".
+func (c *SbomCommand) locate(index *artifact.Index, download generate.Download) string {
+	if entry := index.Lookup(download.Url); entry != nil {
+		file := filepath.Join(c.vendorDir, entry.File)
+		if _, err := os.Stat(file); err == nil {
+			return file
+		}
+	}
+	if download.File != "" && !filepath.IsAbs(download.File) {
+		file := filepath.Join("out", download.File)
+		if _, err := os.Stat(file); err == nil {
+			return file
+		}
+	}
+	return ""
+}
+
+var licensePattern = regexp.MustCompile(`(?i)^(licen[cs]e|copying|notice)`)
+
+// licenseFiles returns the licence files of a package (zip or FMU): files in a
+// licenses (licences) dir, or files named LICENSE, COPYING or NOTICE.
+func licenseFiles(file string) []LicenseFile {
+	licenses := []LicenseFile{}
+	r, err := zip.OpenReader(file)
+	if err != nil {
+		return licenses
+	}
+	defer r.Close()
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() || !isLicenseFile(f.Name) {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			continue
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			continue
+		}
+		hash := sha256.Sum256(content)
+		licenses = append(licenses, LicenseFile{Path: f.Name, Sha256: hex.EncodeToString(hash[:]), Text: string(content)})
+	}
+	slices.SortFunc(licenses, func(a, b LicenseFile) int { return strings.Compare(a.Path, b.Path) })
+	return licenses
+}
+
+func isLicenseFile(name string) bool {
+	parts := strings.Split(path.Clean(name), "/")
+	for _, dir := range parts[:len(parts)-1] {
+		if strings.EqualFold(dir, "licenses") || strings.EqualFold(dir, "licences") {
+			return true
+		}
+	}
+	return licensePattern.MatchString(parts[len(parts)-1])
+}
+
+func isRemote(s string) bool {
+	return strings.HasPrefix(s, "https://") || strings.HasPrefix(s, "http://")
+}
+
+// packageUrl returns the package URL (purl) of a versioned component, a github
+// purl for repos hosted on github.com, otherwise a generic purl, or "".
+func packageUrl(component *Component) string {
+	if component.Version == "" || !isRemote(component.Url) {
+		return ""
+	}
+	u, err := url.Parse(component.Url)
+	if err != nil {
+		return ""
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if u.Host == "github.com" && len(parts) == 2 {
+		return fmt.Sprintf("pkg:github/%s/%s@%s", strings.ToLower(parts[0]), strings.ToLower(strings.TrimSuffix(parts[1], ".git")), url.PathEscape(component.Version))
+	}
+	u.User = nil
+	return fmt.Sprintf("pkg:generic/%s@%s?download_url=%s", url.PathEscape(component.Name), url.PathEscape(component.Version), url.QueryEscape(u.String()))
+}
+
+// newUUID returns a random (version 4) UUID.
+func newUUID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
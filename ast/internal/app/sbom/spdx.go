@@ -0,0 +1,178 @@
+// Copyright 2025 Robert Bosch GmbH
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package sbom
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/boschglobal/dse.sdp/ast/internal/pkg/artifact"
+)
+
+// SPDX 2.3 (JSON), see https://spdx.github.io/spdx-spec/v2.3/.
+
+const spdxNoAssertion = "NOASSERTION"
+
+type SpdxDocument struct {
+	SpdxVersion       string             `json:"spdxVersion"`
+	DataLicense       string             `json:"dataLicense"`
+	SpdxId            string             `json:"SPDXID"`
+	Name              string             `json:"name"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	CreationInfo      SpdxCreationInfo   `json:"creationInfo"`
+	Packages          []SpdxPackage      `json:"packages"`
+	Relationships     []SpdxRelationship `json:"relationships"`
+	// Licence files of the packages.
+	ExtractedLicenses []SpdxExtractedLicense `json:"hasExtractedLicensingInfos,omitempty"`
+}
+
+type SpdxCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type SpdxPackage struct {
+	SpdxId           string            `json:"SPDXID"`
+	Name             string            `json:"name"`
+	VersionInfo      string            `json:"versionInfo,omitempty"`
+	DownloadLocation string            `json:"downloadLocation"`
+	FilesAnalyzed    bool              `json:"filesAnalyzed"`
+	Checksums        []SpdxChecksum    `json:"checksums,omitempty"`
+	LicenseConcluded string            `json:"licenseConcluded"`
+	LicenseDeclared  string            `json:"licenseDeclared"`
+	CopyrightText    string            `json:"copyrightText"`
+	Comment          string            `json:"comment,omitempty"`
+	ExternalRefs     []SpdxExternalRef `json:"externalRefs,omitempty"`
+}
+
+type SpdxExtractedLicense struct {
+	LicenseId     string `json:"licenseId"`
+	Name          string `json:"name"`
+	ExtractedText string `json:"extractedText"`
+	Comment       string `json:"comment,omitempty"`
+}
+
+type SpdxChecksum struct {
+	Algorithm     string `json:"algorithm"`
+	ChecksumValue string `json:"checksumValue"`
+}
+
+type SpdxExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+type SpdxRelationship struct {
+	SpdxElementId      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSpdxElement string `json:"relatedSpdxElement"`
+}
+
+var spdxIdPattern = regexp.MustCompile(`[^a-zA-Z0-9.-]+`)
+
+func spdxId(ref string) string {
+	return "SPDXRef-" + spdxIdPattern.ReplaceAllString(ref, "-")
+}
+
+// newSpdxDocument returns the SPDX document of the Bom. The simulation is the
+// described package, which depends on the components. The files of a package
+// are not analyzed, its licence files are extracted licensing infos (with the
+// text of the file) which form the declared licence of the package (the
+// licences are not concluded).
+func newSpdxDocument(bom *Bom) SpdxDocument {
+	doc := SpdxDocument{
+		SpdxVersion:       "SPDX-2.3",
+		DataLicense:       "CC0-1.0",
+		SpdxId:            "SPDXRef-DOCUMENT",
+		Name:              bom.Name,
+		DocumentNamespace: fmt.Sprintf("https://spdx.org/spdxdocs/%s-%s", spdxIdPattern.ReplaceAllString(bom.Name, "-"), newUUID()),
+		CreationInfo: SpdxCreationInfo{
+			Created:  time.Now().UTC().Format(time.RFC3339),
+			Creators: []string{"Tool: dse-ast"},
+		},
+		Packages:      []SpdxPackage{},
+		Relationships: []SpdxRelationship{},
+	}
+	simId := spdxId("Simulation")
+	doc.Packages = append(doc.Packages, SpdxPackage{
+		SpdxId:           simId,
+		Name:             bom.Name,
+		DownloadLocation: spdxNoAssertion,
+		LicenseConcluded: spdxNoAssertion,
+		LicenseDeclared:  spdxNoAssertion,
+		CopyrightText:    spdxNoAssertion,
+	})
+	doc.Relationships = append(doc.Relationships, SpdxRelationship{
+		SpdxElementId:      doc.SpdxId,
+		RelationshipType:   "DESCRIBES",
+		RelatedSpdxElement: simId,
+	})
+
+	for _, component := range bom.Components {
+		id := spdxId(component.Ref)
+		pkg := SpdxPackage{
+			SpdxId:           id,
+			Name:             component.Name,
+			VersionInfo:      component.Version,
+			DownloadLocation: spdxNoAssertion,
+			LicenseConcluded: spdxNoAssertion,
+			LicenseDeclared:  spdxNoAssertion,
+			CopyrightText:    spdxNoAssertion,
+			Comment:          componentComment(component),
+		}
+		if isRemote(component.Url) {
+			pkg.DownloadLocation = artifact.RedactUrl(component.Url)
+		}
+		if component.Sha256 != "" {
+			pkg.Checksums = []SpdxChecksum{{Algorithm: "SHA256", ChecksumValue: strings.ToLower(component.Sha256)}}
+		}
+		if purl := packageUrl(component); purl != "" {
+			pkg.ExternalRefs = []SpdxExternalRef{{ReferenceCategory: "PACKAGE-MANAGER", ReferenceType: "purl", ReferenceLocator: purl}}
+		}
+		licenseIds := []string{}
+		for i, license := range component.Licenses {
+			licenseId := fmt.Sprintf("LicenseRef-%s-%d", spdxIdPattern.ReplaceAllString(component.Ref, "-"), i)
+			doc.ExtractedLicenses = append(doc.ExtractedLicenses, SpdxExtractedLicense{
+				LicenseId:     licenseId,
+				Name:          license.Path,
+				ExtractedText: license.Text,
+				Comment:       fmt.Sprintf("%s: %s (sha256: %s)", component.Name, license.Path, license.Sha256),
+			})
+			licenseIds = append(licenseIds, licenseId)
+		}
+		if len(licenseIds) > 0 {
+			pkg.LicenseDeclared = strings.Join(licenseIds, " AND ")
+		}
+		doc.Packages = append(doc.Packages, pkg)
+		doc.Relationships = append(doc.Relationships, SpdxRelationship{
+			SpdxElementId:      simId,
+			RelationshipType:   "DEPENDS_ON",
+			RelatedSpdxElement: id,
+		})
+	}
+	return doc
+}
+
+// componentComment describes the component (kind, path, models, pin), which
+// is not otherwise represented in the SPDX package.
+func componentComment(component *Component) string {
+	parts := []string{fmt.Sprintf("kind: %s", component.Kind)}
+	if component.Uses != "" {
+		parts = append(parts, fmt.Sprintf("uses: %s", component.Uses))
+	}
+	if component.Path != "" {
+		parts = append(parts, fmt.Sprintf("path: %s", component.Path))
+	}
+	if len(component.Models) > 0 {
+		parts = append(parts, fmt.Sprintf("models: %s", strings.Join(component.Models, ", ")))
+	}
+	if component.Pin != "" {
+		parts = append(parts, fmt.Sprintf("package sha256: %s", component.Pin))
+	}
+	return strings.Join(parts, "; ")
+}
@@ -0,0 +1,186 @@
+// Copyright 2025 Robert Bosch GmbH
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package sweep
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/boschglobal/dse.schemas/code/go/dse/ast"
+)
+
+// Parameter of a sweep, the values are applied in the order of the matrix
+// file.
+type Parameter struct {
+	Name   string
+	Values []string
+}
+
+// Matrix of sweep parameters, each combination of values is a variant.
+type Matrix []Parameter
+
+// Variant of a simulation, generated to the folder <output>/<id>.
+type Variant struct {
+	Id         string            `yaml:"id"`
+	Parameters map[string]string `yaml:"parameters"`
+}
+
+// LoadMatrix loads a matrix file:
+//
+//	matrix:
+//	  stepsize: [0.0005, 0.001]
+//	  models.input.env.CSV_FILE: [data/a.csv, data/b.csv]
+func LoadMatrix(file string) (Matrix, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading matrix file: %v", err)
+	}
+	var doc struct {
+		Matrix yaml.Node `yaml:"matrix"`
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("Error parsing matrix file: %v", err)
+	}
+	if doc.Matrix.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("matrix file %s: matrix must be a map of parameter values", file)
+	}
+	matrix := Matrix{}
+	for i := 0; i+1 < len(doc.Matrix.Content); i += 2 {
+		name, values := doc.Matrix.Content[i].Value, doc.Matrix.Content[i+1]
+		p := Parameter{Name: name}
+		switch values.Kind {
+		case yaml.ScalarNode:
+			p.Values = []string{values.Value}
+		case yaml.SequenceNode:
+			for _, v := range values.Content {
+				if v.Kind != yaml.ScalarNode {
+					return nil, fmt.Errorf("line %d: value of parameter '%s' must be a scalar", v.Line, name)
+				}
+				p.Values = append(p.Values, v.Value)
+			}
+		default:
+			return nil, fmt.Errorf("line %d: parameter '%s' must be a list of values", values.Line, name)
+		}
+		if len(p.Values) == 0 {
+			return nil, fmt.Errorf("line %d: parameter '%s' has no values", values.Line, name)
+		}
+		if slices.ContainsFunc(matrix, func(m Parameter) bool { return m.Name == name }) {
+			return nil, fmt.Errorf("line %d: duplicate parameter '%s'", values.Line, name)
+		}
+		matrix = append(matrix, p)
+	}
+	return matrix, nil
+}
+
+// Variants returns each combination of the matrix values (the last parameter
+// varies fastest), numbered from 1.
+func (m Matrix) Variants() []Variant {
+	count := 1
+	for _, p := range m {
+		count *= len(p.Values)
+	}
+	width := max(3, len(strconv.Itoa(count)))
+	variants := make([]Variant, count)
+	for i := range variants {
+		variants[i] = Variant{
+			Id:         fmt.Sprintf("%0*d", width, i+1),
+			Parameters: map[string]string{},
+		}
+		n := i
+		for j := len(m) - 1; j >= 0; j-- {
+			values := m[j].Values
+			variants[i].Parameters[m[j].Name] = values[n%len(values)]
+			n /= len(values)
+		}
+	}
+	return variants
+}
+
+// Apply sets a sweep parameter in the simulation AST. Parameters are named:
+//
+//	stepsize | endtime          simulation stepsize/endtime
+//	<NAME> | vars.<NAME>        simulation var
+//	stacks.<stack>.env.<NAME>   stack env
+//	models.<model>.env.<NAME>   model env
+//	models.<model>.vars.<NAME>  model var
+//
+// Vars and env which do not exist are added.
+func Apply(spec *ast.SimulationSpec, name string, value string) error {
+	parts := strings.SplitN(name, ".", 4)
+	switch {
+	case name == "stepsize" || name == "endtime":
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("sweep parameter %s: invalid value '%s'", name, value)
+		}
+		if name == "stepsize" {
+			spec.Stepsize = &f
+		} else {
+			spec.Endtime = &f
+		}
+	case len(parts) == 1:
+		spec.Vars = setVar(spec.Vars, name, value)
+	case len(parts) == 2 && parts[0] == "vars":
+		spec.Vars = setVar(spec.Vars, parts[1], value)
+	case len(parts) == 4 && parts[0] == "stacks" && parts[2] == "env":
+		stack := findStack(spec, parts[1])
+		if stack == nil {
+			return fmt.Errorf("sweep parameter %s: stack not found (name=%s)", name, parts[1])
+		}
+		stack.Env = setVar(stack.Env, parts[3], value)
+	case len(parts) == 4 && parts[0] == "models" && (parts[2] == "env" || parts[2] == "vars"):
+		model := findModel(spec, parts[1])
+		if model == nil {
+			return fmt.Errorf("sweep parameter %s: model not found (name=%s)", name, parts[1])
+		}
+		if parts[2] == "env" {
+			model.Env = setVar(model.Env, parts[3], value)
+		} else {
+			model.Vars = setVar(model.Vars, parts[3], value)
+		}
+	default:
+		return fmt.Errorf("unsupported sweep parameter: %s", name)
+	}
+	return nil
+}
+
+func setVar(vars *[]ast.Var, name string, value string) *[]ast.Var {
+	if vars == nil {
+		vars = &[]ast.Var{}
+	}
+	for i := range *vars {
+		if (*vars)[i].Name == name {
+			(*vars)[i].Value = value
+			return vars
+		}
+	}
+	*vars = append(*vars, ast.Var{Name: name, Value: value})
+	return vars
+}
+
+func findStack(spec *ast.SimulationSpec, name string) *ast.Stack {
+	for i := range spec.Stacks {
+		if spec.Stacks[i].Name == name {
+			return &spec.Stacks[i]
+		}
+	}
+	return nil
+}
+
+func findModel(spec *ast.SimulationSpec, name string) *ast.Model {
+	for i := range spec.Stacks {
+		for j := range spec.Stacks[i].Models {
+			if spec.Stacks[i].Models[j].Name == name {
+				return &spec.Stacks[i].Models[j]
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+// Copyright 2025 Robert Bosch GmbH
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package sweep
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/boschglobal/dse.clib/extra/go/command"
+	"github.com/boschglobal/dse.clib/extra/go/command/log"
+	"github.com/boschglobal/dse.clib/extra/go/command/util"
+
+	"github.com/boschglobal/dse.schemas/code/go/dse/ast"
+
+	"github.com/boschglobal/dse.sdp/ast/internal/app/generate"
+)
+
+const manifestFile = "manifest.yaml"
+
+// Manifest of a sweep, written to the output folder.
+type Manifest struct {
+	Input    string    `yaml:"input"`
+	Matrix   string    `yaml:"matrix"`
+	Variants []Variant `yaml:"variants"`
+}
+
+type SweepCommand struct {
+	command.Command
+
+	inputFile    string
+	matrixFile   string
+	outputPath   string
+	backend      string
+	providerFile string
+	genCompose   bool
+	logLevel     int
+}
+
+func NewSweepCommand(name string) *SweepCommand {
+	c := &SweepCommand{
+		Command: command.Command{
+			Name:    name,
+			FlagSet: flag.NewFlagSet(name, flag.ExitOnError),
+		},
+	}
+	c.FlagSet().StringVar(&c.inputFile, "input", "", "path to Simulation AST file")
+	c.FlagSet().StringVar(&c.matrixFile, "matrix", "", "path to sweep matrix file")
+	c.FlagSet().StringVar(&c.outputPath, "output", "sweep", "path to write the generated variants")
+	c.FlagSet().StringVar(&c.backend, "backend", generate.BackendTaskfile, "build backend: taskfile, make or sh")
+	c.FlagSet().StringVar(&c.providerFile, "providers", "", "path to metadata provider config file")
+	c.FlagSet().BoolVar(&c.genCompose, "compose", false, "Generate a docker-compose.yaml (in addition)")
+	c.FlagSet().IntVar(&c.logLevel, "log", 4, "Loglevel")
+	return c
+}
+
+func (c SweepCommand) Name() string {
+	return c.Command.Name
+}
+
+func (c SweepCommand) FlagSet() *flag.FlagSet {
+	return c.Command.FlagSet
+}
+
+func (c *SweepCommand) Parse(args []string) error {
+	return c.FlagSet().Parse(args)
+}
+
+func (c *SweepCommand) Run() error {
+	slog.SetDefault(log.NewLogger(c.logLevel))
+
+	outDir := "out"
+	inputPath := filepath.Join("out", c.inputFile)
+	c.inputFile = inputPath
+	c.matrixFile = filepath.Join(outDir, c.matrixFile)
+	outputPath := filepath.Join(outDir, c.outputPath)
+	c.outputPath = outputPath
+
+	fmt.Fprintf(flag.CommandLine.Output(), "Reading file: %s\n", c.inputFile)
+	spec, doc, err := generate.LoadSimulationAst(c.inputFile)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(flag.CommandLine.Output(), "Reading matrix: %s\n", c.matrixFile)
+	matrix, err := LoadMatrix(c.matrixFile)
+	if err != nil {
+		return err
+	}
+
+	manifest := Manifest{
+		Input:    c.inputFile,
+		Matrix:   c.matrixFile,
+		Variants: matrix.Variants(),
+	}
+	for _, variant := range manifest.Variants {
+		variantSpec, err := variantAst(*spec, matrix, variant)
+		if err != nil {
+			return err
+		}
+		slog.Info("Generate variant", "id", variant.Id, "parameters", variant.Parameters)
+		gen := generate.NewGenerateCommand("generate")
+		if err := gen.Parse(c.generateArgs()); err != nil {
+			return err
+		}
+		if err := gen.GenerateAst(*variantSpec, doc, filepath.Join(c.outputPath, variant.Id)); err != nil {
+			return fmt.Errorf("variant %s: %v", variant.Id, err)
+		}
+	}
+
+	manifestPath := filepath.Join(c.outputPath, manifestFile)
+	fmt.Fprintf(flag.CommandLine.Output(), "Writing manifest: %s\n", manifestPath)
+	if err := os.MkdirAll(c.outputPath, os.ModePerm); err != nil {
+		return err
+	}
+	return util.WriteYaml(&manifest, manifestPath, false)
+}
+
+// generateArgs returns the options of the generate command, for each variant.
+func (c *SweepCommand) generateArgs() []string {
+	args := []string{"-backend", c.backend, fmt.Sprintf("-log=%d", c.logLevel)}
+	if c.providerFile != "" {
+		args = append(args, "-providers", c.providerFile)
+	}
+	if c.genCompose {
+		args = append(args, "-compose")
+	}
+	return args
+}
+
+// variantAst returns a copy of the simulation AST with the parameters of the
+// variant applied (in matrix order).
+func variantAst(spec ast.SimulationSpec, matrix Matrix, variant Variant) (*ast.SimulationSpec, error) {
+	data, err := yaml.Marshal(&spec)
+	if err != nil {
+		return nil, err
+	}
+	var variantSpec ast.SimulationSpec
+	if err := yaml.Unmarshal(data, &variantSpec); err != nil {
+		return nil, err
+	}
+	for _, p := range matrix {
+		if err := Apply(&variantSpec, p.Name, variant.Parameters[p.Name]); err != nil {
+			return nil, err
+		}
+	}
+	return &variantSpec, nil
+}
@@ -0,0 +1,156 @@
+// Copyright 2025 Robert Bosch GmbH
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package sweep
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/boschglobal/dse.schemas/code/go/dse/ast"
+)
+
+const testAst = `---
+kind: Simulation
+metadata:
+  name: sweep
+spec:
+  arch: linux-amd64
+  stepsize: 0.0005
+  endtime: 0.02
+  channels:
+    - name: physical
+  vars:
+    - name: GAIN
+      value: "1"
+  stacks:
+    - name: default
+      models:
+        - name: input
+          model: dse.modelc.csv
+          channels:
+            - alias: signal_channel
+              name: physical
+          env:
+            - name: CSV_FILE
+              value: data/input.csv
+`
+
+func TestLoadMatrix(t *testing.T) {
+	t.Chdir(t.TempDir())
+	require.NoError(t, os.WriteFile("matrix.yaml", []byte(`---
+matrix:
+  stepsize: [0.0005, 0.001]
+  models.input.env.CSV_FILE:
+    - data/a.csv
+    - data/b.csv
+    - data/c.csv
+  GAIN: 2
+`), 0644))
+	matrix, err := LoadMatrix("matrix.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, Matrix{
+		{Name: "stepsize", Values: []string{"0.0005", "0.001"}},
+		{Name: "models.input.env.CSV_FILE", Values: []string{"data/a.csv", "data/b.csv", "data/c.csv"}},
+		{Name: "GAIN", Values: []string{"2"}},
+	}, matrix)
+
+	require.NoError(t, os.WriteFile("matrix.yaml", []byte("matrix:\n  stepsize: []\n"), 0644))
+	_, err = LoadMatrix("matrix.yaml")
+	assert.ErrorContains(t, err, "parameter 'stepsize' has no values")
+	require.NoError(t, os.WriteFile("matrix.yaml", []byte("matrix: [stepsize]\n"), 0644))
+	_, err = LoadMatrix("matrix.yaml")
+	assert.ErrorContains(t, err, "matrix must be a map")
+}
+
+func TestVariants(t *testing.T) {
+	matrix := Matrix{
+		{Name: "stepsize", Values: []string{"0.0005", "0.001"}},
+		{Name: "GAIN", Values: []string{"1", "2", "3"}},
+	}
+	variants := matrix.Variants()
+	require.Len(t, variants, 6)
+	assert.Equal(t, Variant{Id: "001", Parameters: map[string]string{"stepsize": "0.0005", "GAIN": "1"}}, variants[0])
+	assert.Equal(t, Variant{Id: "003", Parameters: map[string]string{"stepsize": "0.0005", "GAIN": "3"}}, variants[2])
+	assert.Equal(t, Variant{Id: "004", Parameters: map[string]string{"stepsize": "0.001", "GAIN": "1"}}, variants[3])
+	assert.Len(t, Matrix{}.Variants(), 1)
+}
+
+func TestApply(t *testing.T) {
+	var doc struct {
+		Spec ast.SimulationSpec `yaml:"spec"`
+	}
+	require.NoError(t, yaml.Unmarshal([]byte(testAst), &doc))
+	spec := &doc.Spec
+
+	require.NoError(t, Apply(spec, "endtime", "0.5"))
+	assert.Equal(t, 0.5, *spec.Endtime)
+	require.NoError(t, Apply(spec, "GAIN", "4"))
+	require.NoError(t, Apply(spec, "vars.OFFSET", "1"))
+	assert.Equal(t, []ast.Var{{Name: "GAIN", Value: "4"}, {Name: "OFFSET", Value: "1"}}, *spec.Vars)
+	require.NoError(t, Apply(spec, "stacks.default.env.SIMBUS_LOGLEVEL", "2"))
+	assert.Equal(t, []ast.Var{{Name: "SIMBUS_LOGLEVEL", Value: "2"}}, *spec.Stacks[0].Env)
+	require.NoError(t, Apply(spec, "models.input.env.CSV_FILE", "data/b.csv"))
+	assert.Equal(t, []ast.Var{{Name: "CSV_FILE", Value: "data/b.csv"}}, *spec.Stacks[0].Models[0].Env)
+	require.NoError(t, Apply(spec, "models.input.vars.FACTOR", "3"))
+	assert.Equal(t, []ast.Var{{Name: "FACTOR", Value: "3"}}, *spec.Stacks[0].Models[0].Vars)
+
+	assert.ErrorContains(t, Apply(spec, "stepsize", "fast"), "invalid value 'fast'")
+	assert.ErrorContains(t, Apply(spec, "models.foo.env.X", "1"), "model not found (name=foo)")
+	assert.ErrorContains(t, Apply(spec, "stacks.foo.env.X", "1"), "stack not found (name=foo)")
+	assert.ErrorContains(t, Apply(spec, "models.input.files.X", "1"), "unsupported sweep parameter")
+}
+
+func TestSweep(t *testing.T) {
+	t.Chdir(t.TempDir())
+	require.NoError(t, os.MkdirAll("out", 0755))
+	require.NoError(t, os.WriteFile("out/ast.yaml", []byte(testAst), 0644))
+	require.NoError(t, os.WriteFile("out/matrix.yaml", []byte(`---
+matrix:
+  stepsize: [0.0005, 0.001]
+  models.input.env.CSV_FILE: [data/a.csv, data/b.csv]
+`), 0644))
+
+	cmd := NewSweepCommand("sweep")
+	require.NoError(t, cmd.Parse([]string{"-input", "ast.yaml", "-matrix", "matrix.yaml"}))
+	require.NoError(t, cmd.Run())
+
+	data, err := os.ReadFile("out/sweep/manifest.yaml")
+	require.NoError(t, err)
+	var manifest Manifest
+	require.NoError(t, yaml.Unmarshal(data, &manifest))
+	require.Len(t, manifest.Variants, 4)
+	assert.Equal(t, Variant{Id: "002", Parameters: map[string]string{
+		"stepsize":                  "0.0005",
+		"models.input.env.CSV_FILE": "data/b.csv",
+	}}, manifest.Variants[1])
+
+	for _, v := range manifest.Variants {
+		assert.FileExists(t, filepath.Join("out/sweep", v.Id, "Taskfile.yml"))
+		assert.FileExists(t, filepath.Join("out/sweep", v.Id, "simulation.yaml"))
+	}
+	sim, err := os.ReadFile("out/sweep/003/simulation.yaml")
+	require.NoError(t, err)
+	assert.Contains(t, string(sim), "stepsize: 0.001")
+	assert.Contains(t, string(sim), "CSV_FILE: data/a.csv")
+
+	// The build of a variant uses the folder of the variant.
+	data, err = os.ReadFile("out/sweep/003/Taskfile.yml")
+	require.NoError(t, err)
+	var taskfile struct {
+		Vars  map[string]string `yaml:"vars"`
+		Tasks map[string]struct {
+			Cmds []interface{} `yaml:"cmds"`
+		} `yaml:"tasks"`
+	}
+	require.NoError(t, yaml.Unmarshal(data, &taskfile))
+	assert.Equal(t, "out/sweep/003", taskfile.Vars["OUT"])
+	assert.Equal(t, "{{.PWD}}/out/sweep/003", taskfile.Vars["OUTDIR"])
+	assert.Contains(t, taskfile.Tasks["build-setup-sim"].Cmds, "cp {{.PROJDIR}}/out/sweep/003/simulation.yaml {{.SIMDIR}}/data/simulation.yaml")
+}
@@ -0,0 +1,314 @@
+// Copyright 2025 Robert Bosch GmbH
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package validate
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"slices"
+
+	"github.com/boschglobal/dse.clib/extra/go/command"
+	"github.com/boschglobal/dse.clib/extra/go/command/log"
+
+	"github.com/boschglobal/dse.schemas/code/go/dse/ast"
+
+	"github.com/boschglobal/dse.sdp/ast/internal/app/generate"
+	"github.com/boschglobal/dse.sdp/ast/internal/pkg/artifact"
+	"github.com/boschglobal/dse.sdp/ast/internal/pkg/source"
+)
+
+// Issue codes reported by the validate command. Codes are stable and may be
+// used by tooling to filter or suppress specific checks.
+const (
+	CodeChannelUndefined      = "E001"
+	CodeModelUsesUndefined    = "E002"
+	CodeFileUsesUndefined     = "E003"
+	CodeVarUsesUndefined      = "E004"
+	CodeWorkflowUsesUndefined = "E005"
+	CodeDuplicateModelName    = "E006"
+	CodeDuplicateModelUid     = "E007"
+	CodeUnknownArch           = "E008"
+	CodeStepsizeEndtime       = "E009"
+	CodeConnection            = "E010"
+	CodeUsesSha256            = "E011"
+)
+
+// Issue is a validation issue. Source is the location (in the DSE script) of
+// the object with the issue, if known.
+type Issue struct {
+	Code    string
+	Message string
+	Source  string
+}
+
+func (i Issue) String() string {
+	if i.Source != "" {
+		return fmt.Sprintf("%s: %s: %s", i.Source, i.Code, i.Message)
+	}
+	return fmt.Sprintf("%s: %s", i.Code, i.Message)
+}
+
+type ValidateCommand struct {
+	command.Command
+
+	inputFile string
+	logLevel  int
+
+	simulationAst ast.SimulationSpec
+}
+
+func NewValidateCommand(name string) *ValidateCommand {
+	c := &ValidateCommand{
+		Command: command.Command{
+			Name:    name,
+			FlagSet: flag.NewFlagSet(name, flag.ExitOnError),
+		},
+	}
+	c.FlagSet().StringVar(&c.inputFile, "input", "", "path to Simulation AST file")
+	c.FlagSet().IntVar(&c.logLevel, "log", 4, "Loglevel")
+	return c
+}
+
+func (c ValidateCommand) Name() string {
+	return c.Command.Name
+}
+
+func (c ValidateCommand) FlagSet() *flag.FlagSet {
+	return c.Command.FlagSet
+}
+
+func (c *ValidateCommand) Parse(args []string) error {
+	return c.FlagSet().Parse(args)
+}
+
+func (c *ValidateCommand) Run() error {
+	slog.SetDefault(log.NewLogger(c.logLevel))
+
+	inputPath := filepath.Join("out", c.inputFile)
+	c.inputFile = inputPath
+
+	fmt.Fprintf(flag.CommandLine.Output(), "Reading file: %s\n", c.inputFile)
+	spec, doc, err := generate.LoadSimulationAst(c.inputFile)
+	if err != nil {
+		return err
+	}
+	c.simulationAst = *spec
+
+	issues := Validate(c.simulationAst)
+	issues = append(issues, ValidateConnections(doc.Metadata.Annotations, c.simulationAst)...)
+	for _, issue := range issues {
+		fmt.Fprintf(flag.CommandLine.Output(), "%s\n", issue)
+	}
+	if len(issues) > 0 {
+		return fmt.Errorf("validation failed: %d issue(s) found", len(issues))
+	}
+	fmt.Fprintf(flag.CommandLine.Output(), "Validation passed: %s\n", c.inputFile)
+	return nil
+}
+
+// Validate runs all semantic checks on the Simulation AST and returns the
+// issues found (in a deterministic order).
+func Validate(simSpec ast.SimulationSpec) []Issue {
+	issues := []Issue{}
+	issues = append(issues, checkSimulationTime(simSpec)...)
+	issues = append(issues, checkArch(simSpec)...)
+	issues = append(issues, checkChannels(simSpec)...)
+	issues = append(issues, checkUses(simSpec)...)
+	issues = append(issues, checkModelIdentity(simSpec)...)
+	return issues
+}
+
+// ValidateConnections checks the connection annotations of the simulation
+// (metadata.annotations of the AST document) and of each stack.
+func ValidateConnections(simAnnotations map[string]interface{}, simSpec ast.SimulationSpec) []Issue {
+	issues := []Issue{}
+	for _, stack := range simSpec.Stacks {
+		_, connIssues := generate.StackConnection(simAnnotations, stack)
+		for _, issue := range connIssues {
+			issues = append(issues, Issue{
+				Code:    CodeConnection,
+				Message: fmt.Sprintf("stack '%s': %s", stack.Name, issue),
+				Source:  source.Of(stack.Annotations),
+			})
+		}
+	}
+	return issues
+}
+
+func checkSimulationTime(simSpec ast.SimulationSpec) []Issue {
+	if simSpec.Stepsize == nil || simSpec.Endtime == nil {
+		return nil
+	}
+	if *simSpec.Stepsize >= *simSpec.Endtime {
+		return []Issue{{
+			Code:    CodeStepsizeEndtime,
+			Message: fmt.Sprintf("simulation stepsize (%v) must be less than endtime (%v)", *simSpec.Stepsize, *simSpec.Endtime),
+		}}
+	}
+	return nil
+}
+
+func checkArch(simSpec ast.SimulationSpec) []Issue {
+	issues := []Issue{}
+	check := func(arch string, context string, location string) {
+		if _, ok := generate.ArchPlatforms[arch]; !ok {
+			issues = append(issues, Issue{
+				Code:    CodeUnknownArch,
+				Message: fmt.Sprintf("%s: unknown arch '%s'", context, arch),
+				Source:  location,
+			})
+		}
+	}
+	if simSpec.Arch != "" {
+		check(simSpec.Arch, "simulation", "")
+	}
+	for _, stack := range simSpec.Stacks {
+		if stack.Arch != nil {
+			check(*stack.Arch, fmt.Sprintf("stack '%s'", stack.Name), source.Of(stack.Annotations))
+		}
+		for _, model := range stack.Models {
+			if model.Arch != nil {
+				check(*model.Arch, modelContext(stack, model), source.Of(model.Annotations))
+			}
+		}
+	}
+	return issues
+}
+
+func checkChannels(simSpec ast.SimulationSpec) []Issue {
+	issues := []Issue{}
+	channels := []string{}
+	for _, ch := range simSpec.Channels {
+		channels = append(channels, ch.Name)
+	}
+	for _, stack := range simSpec.Stacks {
+		for _, model := range stack.Models {
+			for _, ch := range model.Channels {
+				if !slices.Contains(channels, ch.Name) {
+					issues = append(issues, Issue{
+						Code:    CodeChannelUndefined,
+						Message: fmt.Sprintf("%s: channel '%s' not declared in spec.channels", modelContext(stack, model), ch.Name),
+						Source:  source.Of(model.Annotations, "channel", ch.Name),
+					})
+				}
+			}
+		}
+	}
+	return issues
+}
+
+func checkUses(simSpec ast.SimulationSpec) []Issue {
+	issues := []Issue{}
+	uses := []string{}
+	if simSpec.Uses != nil {
+		for _, u := range *simSpec.Uses {
+			uses = append(uses, u.Name)
+			if sha := artifact.UsesSha256(u); sha != "" && !artifact.ValidSha256(sha) {
+				issues = append(issues, Issue{
+					Code:    CodeUsesSha256,
+					Message: fmt.Sprintf("uses '%s': invalid sha256 '%s' (expected 64 hex digits)", u.Name, sha),
+				})
+			}
+		}
+	}
+	// The source locations are taken from the annotations of the enclosing
+	// stack or model, path locates the vars of a workflow.
+	checkVars := func(vars *[]ast.Var, context string, annotations *ast.Annotations, path ...string) {
+		if vars == nil {
+			return
+		}
+		for _, v := range *vars {
+			if v.Reference != nil && *v.Reference == ast.VarReferenceUses && !slices.Contains(uses, v.Value) {
+				issues = append(issues, Issue{
+					Code:    CodeVarUsesUndefined,
+					Message: fmt.Sprintf("%s: var '%s' uses '%s' not declared in spec.uses", context, v.Name, v.Value),
+					Source:  source.Of(annotations, append(slices.Clone(path), "var", v.Name)...),
+				})
+			}
+		}
+	}
+	checkWorkflows := func(workflows *[]ast.Workflow, context string, annotations *ast.Annotations) {
+		if workflows == nil {
+			return
+		}
+		for _, w := range *workflows {
+			wfContext := fmt.Sprintf("%s workflow '%s'", context, w.Name)
+			if w.Uses != nil && *w.Uses != "" && !slices.Contains(uses, *w.Uses) {
+				issues = append(issues, Issue{
+					Code:    CodeWorkflowUsesUndefined,
+					Message: fmt.Sprintf("%s: uses '%s' not declared in spec.uses", wfContext, *w.Uses),
+					Source:  source.Of(annotations),
+				})
+			}
+			checkVars(w.Vars, wfContext, annotations, "workflow", w.Name)
+		}
+	}
+
+	for _, stack := range simSpec.Stacks {
+		checkWorkflows(stack.Workflows, fmt.Sprintf("stack '%s'", stack.Name), stack.Annotations)
+		for _, model := range stack.Models {
+			context := modelContext(stack, model)
+			if model.Uses != "" && !slices.Contains(uses, model.Uses) {
+				issues = append(issues, Issue{
+					Code:    CodeModelUsesUndefined,
+					Message: fmt.Sprintf("%s: uses '%s' not declared in spec.uses", context, model.Uses),
+					Source:  source.Of(model.Annotations),
+				})
+			}
+			if model.Files != nil {
+				for _, f := range *model.Files {
+					if f.Reference != nil && *f.Reference == ast.FileReferenceUses && !slices.Contains(uses, f.Value) {
+						issues = append(issues, Issue{
+							Code:    CodeFileUsesUndefined,
+							Message: fmt.Sprintf("%s: file '%s' uses '%s' not declared in spec.uses", context, f.Name, f.Value),
+							Source:  source.Of(model.Annotations, "file", f.Name),
+						})
+					}
+				}
+			}
+			checkVars(model.Vars, context, model.Annotations)
+			checkWorkflows(model.Workflows, context, model.Annotations)
+		}
+	}
+	return issues
+}
+
+func checkModelIdentity(simSpec ast.SimulationSpec) []Issue {
+	issues := []Issue{}
+	names := map[string]string{}
+	uids := map[int]string{}
+	for _, stack := range simSpec.Stacks {
+		for _, model := range stack.Models {
+			if other, ok := names[model.Name]; ok {
+				issues = append(issues, Issue{
+					Code:    CodeDuplicateModelName,
+					Message: fmt.Sprintf("%s: duplicate model name (also in stack '%s')", modelContext(stack, model), other),
+					Source:  source.Of(model.Annotations),
+				})
+			} else {
+				names[model.Name] = stack.Name
+			}
+			if model.Uid == nil || *model.Uid == 0 {
+				continue
+			}
+			if other, ok := uids[*model.Uid]; ok {
+				issues = append(issues, Issue{
+					Code:    CodeDuplicateModelUid,
+					Message: fmt.Sprintf("%s: duplicate uid %d (also used by model '%s')", modelContext(stack, model), *model.Uid, other),
+					Source:  source.Of(model.Annotations),
+				})
+			} else {
+				uids[*model.Uid] = model.Name
+			}
+		}
+	}
+	return issues
+}
+
+func modelContext(stack ast.Stack, model ast.Model) string {
+	return fmt.Sprintf("model '%s' (stack '%s')", model.Name, stack.Name)
+}
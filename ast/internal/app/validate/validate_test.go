@@ -0,0 +1,100 @@
+// Copyright 2025 Robert Bosch GmbH
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package validate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/boschglobal/dse.sdp/ast/internal/app/generate"
+)
+
+func issueCodes(issues []Issue) []string {
+	codes := []string{}
+	for _, issue := range issues {
+		codes = append(codes, issue.Code)
+	}
+	return codes
+}
+
+func TestValidate_invalid(t *testing.T) {
+	spec, _, err := generate.LoadSimulationAst("testdata/ast__invalid.yaml")
+	require.NoError(t, err)
+
+	issues := Validate(*spec)
+	for _, issue := range issues {
+		t.Log(issue)
+	}
+	assert.Equal(t, []string{
+		CodeStepsizeEndtime,
+		CodeUnknownArch,
+		CodeChannelUndefined,
+		CodeUsesSha256,
+		CodeFileUsesUndefined,
+		CodeModelUsesUndefined,
+		CodeVarUsesUndefined,
+		CodeDuplicateModelName,
+		CodeDuplicateModelUid,
+	}, issueCodes(issues))
+	assert.Equal(t, "E001: model 'input' (stack 'other'): channel 'network' not declared in spec.channels", issues[2].String())
+}
+
+func TestValidate_valid(t *testing.T) {
+	spec, _, err := generate.LoadSimulationAst("../generate/testdata/ast__openloop.yaml")
+	require.NoError(t, err)
+
+	issues := Validate(*spec)
+	assert.Empty(t, issues)
+}
+
+func TestValidateCommand(t *testing.T) {
+	dir := t.TempDir()
+	data, err := os.ReadFile("testdata/ast__invalid.yaml")
+	require.NoError(t, err)
+	t.Chdir(dir)
+	require.NoError(t, os.MkdirAll("out", 0755))
+	require.NoError(t, os.WriteFile(filepath.Join("out", "ast.yaml"), data, 0644))
+
+	cmd := NewValidateCommand("test_validate")
+	err = cmd.Parse([]string{"-input", "ast.yaml"})
+	require.NoError(t, err)
+	err = cmd.Run()
+	assert.ErrorContains(t, err, "9 issue(s)")
+}
+
+func TestValidateConnections(t *testing.T) {
+	spec, doc, err := generate.LoadSimulationAst("../generate/testdata/ast__connection.yaml")
+	require.NoError(t, err)
+	assert.Empty(t, ValidateConnections(doc.Metadata.Annotations, *spec))
+
+	annotations := map[string]interface{}{"connection.transport": "mq", "connection.timeout": 10}
+	issues := ValidateConnections(annotations, *spec)
+	for _, issue := range issues {
+		t.Log(issue)
+	}
+	assert.Equal(t, []string{CodeConnection, CodeConnection}, issueCodes(issues))
+	assert.Equal(t, "E010: stack 'default': transport 'mq' does not support a connection timeout", issues[0].String())
+}
+
+func TestValidate_source(t *testing.T) {
+	spec, _, err := generate.LoadSimulationAst("testdata/ast__source.yaml")
+	require.NoError(t, err)
+
+	issues := Validate(*spec)
+	result := []string{}
+	for _, issue := range issues {
+		result = append(result, issue.String())
+	}
+	assert.Equal(t, []string{
+		"openloop.dse:8:1: E008: stack 'default': unknown arch 'linux-amd86'",
+		"openloop.dse:10:3: E001: model 'input' (stack 'default'): channel 'network' not declared in spec.channels",
+		"openloop.dse:9:1: E003: model 'input' (stack 'default'): file 'input.csv' uses 'input' not declared in spec.uses",
+		"openloop.dse:13:5: E004: model 'input' (stack 'default') workflow 'generate': var 'FMU_DIR' uses 'linear_fmu' not declared in spec.uses",
+	}, result)
+}
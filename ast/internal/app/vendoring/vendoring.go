@@ -0,0 +1,352 @@
+// Copyright 2025 Robert Bosch GmbH
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package vendoring
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/boschglobal/dse.clib/extra/go/command"
+	"github.com/boschglobal/dse.clib/extra/go/command/log"
+
+	"github.com/boschglobal/dse.sdp/ast/internal/app/generate"
+	"github.com/boschglobal/dse.sdp/ast/internal/pkg/artifact"
+	"github.com/boschglobal/dse.sdp/ast/internal/pkg/override"
+	"github.com/boschglobal/dse.sdp/ast/internal/pkg/provider"
+)
+
+type VendorCommand struct {
+	command.Command
+
+	inputFile     string
+	outputPath    string
+	jobs          int
+	timeout       time.Duration
+	retries       int
+	providerFile  string
+	overrideFlags override.Flag
+	overrideFile  string
+	logLevel      int
+
+	client *http.Client
+	index  *artifact.Index // Previous index (files which are unchanged are not fetched).
+}
+
+func NewVendorCommand(name string) *VendorCommand {
+	c := &VendorCommand{
+		Command: command.Command{
+			Name:    name,
+			FlagSet: flag.NewFlagSet(name, flag.ExitOnError),
+		},
+	}
+	c.FlagSet().StringVar(&c.inputFile, "input", "", "path to Simulation AST file")
+	c.FlagSet().StringVar(&c.outputPath, "output", "vendor", "path to vendor dir")
+	c.FlagSet().IntVar(&c.jobs, "jobs", 4, "number of parallel downloads")
+	c.FlagSet().DurationVar(&c.timeout, "timeout", 10*time.Minute, "timeout for each download")
+	c.FlagSet().IntVar(&c.retries, "retries", 3, "retries for failed downloads (network errors and 5xx)")
+	c.FlagSet().StringVar(&c.providerFile, "providers", "", "path to metadata provider config file")
+	c.FlagSet().Var(&c.overrideFlags, "override", "override a uses entry with a local directory or file: name=file:///path (repeatable)")
+	c.FlagSet().StringVar(&c.overrideFile, "override-file", "", "path to override file (default sdp.override.yaml, if present)")
+	c.FlagSet().IntVar(&c.logLevel, "log", 4, "Loglevel")
+	return c
+}
+
+func (c VendorCommand) Name() string {
+	return c.Command.Name
+}
+
+func (c VendorCommand) FlagSet() *flag.FlagSet {
+	return c.Command.FlagSet
+}
+
+func (c *VendorCommand) Parse(args []string) error {
+	return c.FlagSet().Parse(args)
+}
+
+func (c *VendorCommand) Run() error {
+	slog.SetDefault(log.NewLogger(c.logLevel))
+
+	c.inputFile = filepath.Join("out", c.inputFile)
+	if !filepath.IsAbs(c.outputPath) {
+		c.outputPath = filepath.Join("out", c.outputPath)
+	}
+	c.client = &http.Client{Timeout: c.timeout}
+
+	fmt.Fprintf(flag.CommandLine.Output(), "Reading file: %s\n", c.inputFile)
+	spec, doc, err := generate.LoadSimulationAst(c.inputFile)
+	if err != nil {
+		return err
+	}
+	gen := generate.NewGenerateCommand("generate")
+	if err := gen.Parse(c.generateArgs()); err != nil {
+		return err
+	}
+	artifacts, err := gen.Artifacts(*spec, doc)
+	if err != nil {
+		return err
+	}
+	if index, err := artifact.Load(c.outputPath); err == nil {
+		c.index = index
+	}
+
+	fmt.Fprintf(flag.CommandLine.Output(), "Vendoring to folder: %s\n", c.outputPath)
+	index, issues := c.vendor(artifacts)
+	indexPath := filepath.Join(c.outputPath, artifact.IndexFile)
+	fmt.Fprintf(flag.CommandLine.Output(), "Writing index: %s\n", indexPath)
+	if err := index.Save(c.outputPath); err != nil {
+		return err
+	}
+	for _, issue := range issues {
+		fmt.Fprintf(flag.CommandLine.Output(), "%s\n", issue)
+	}
+	if len(issues) > 0 {
+		return fmt.Errorf("vendor failed: %d issue(s) found", len(issues))
+	}
+	return nil
+}
+
+// generateArgs returns the options of the generate command which locates the
+// artifacts.
+func (c *VendorCommand) generateArgs() []string {
+	args := []string{fmt.Sprintf("-log=%d", c.logLevel)}
+	if c.providerFile != "" {
+		args = append(args, "-providers", c.providerFile)
+	}
+	for _, o := range c.overrideFlags {
+		args = append(args, "-override", o)
+	}
+	if c.overrideFile != "" {
+		args = append(args, "-override-file", c.overrideFile)
+	}
+	return args
+}
+
+type vendorJob struct {
+	artifact generate.Artifact
+	entry    *artifact.Entry
+	fetched  bool
+	includes []string // Includes of a Taskfile (URLs).
+	err      error
+}
+
+// vendor fetches the artifacts (in parallel, see -jobs). The includes of
+// fetched Taskfiles are vendored as well (recursively).
+func (c *VendorCommand) vendor(artifacts []generate.Artifact) (*artifact.Index, []string) {
+	index := &artifact.Index{Entries: []artifact.Entry{}}
+	issues := []string{}
+	seen := map[string]bool{}
+	for len(artifacts) > 0 {
+		jobs := []*vendorJob{}
+		for _, a := range artifacts {
+			if !seen[a.Url] {
+				seen[a.Url] = true
+				jobs = append(jobs, &vendorJob{artifact: a})
+			}
+		}
+		c.fetchArtifacts(jobs)
+		artifacts = []generate.Artifact{}
+		for _, job := range jobs {
+			if job.err != nil {
+				issues = append(issues, fmt.Sprintf("%s: %v", artifact.RedactUrl(job.artifact.Url), job.err))
+				continue
+			}
+			status := "fetched"
+			if !job.fetched {
+				status = "unchanged"
+			}
+			fmt.Fprintf(flag.CommandLine.Output(), "Vendored: %s -> %s (%s)\n", artifact.RedactUrl(job.artifact.Url), job.entry.File, status)
+			index.Entries = append(index.Entries, *job.entry)
+			for _, include := range job.includes {
+				artifacts = append(artifacts, generate.Artifact{Url: include, Kind: artifact.KindTaskfile})
+			}
+		}
+	}
+	return index, issues
+}
+
+// fetchArtifacts vendors the artifacts of the jobs with a bounded pool of
+// workers (see -jobs).
+func (c *VendorCommand) fetchArtifacts(jobs []*vendorJob) {
+	queue := make(chan *vendorJob)
+	var wg sync.WaitGroup
+	for range max(1, c.jobs) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range queue {
+				job.entry, job.fetched, job.err = c.vendorArtifact(job.artifact)
+				if job.err == nil && job.artifact.Kind == artifact.KindTaskfile {
+					job.includes, job.err = taskfileIncludes(job.artifact.Url, filepath.Join(c.outputPath, job.entry.File))
+				}
+			}
+		}()
+	}
+	for _, job := range jobs {
+		queue <- job
+	}
+	close(queue)
+	wg.Wait()
+}
+
+// vendorArtifact fetches an artifact into the vendor dir, unless the file of
+// the previous index is unchanged. A fetched file which does not match the
+// SHA-256 pin of the artifact is removed.
+func (c *VendorCommand) vendorArtifact(a generate.Artifact) (*artifact.Entry, bool, error) {
+	fetchUrl := artifact.ExpandEnv(a.Url)
+	file, err := artifact.Path(fetchUrl)
+	if err != nil {
+		return nil, false, err
+	}
+	filePath := filepath.Join(c.outputPath, file)
+	if c.index != nil {
+		if e := c.index.Lookup(a.Url); e != nil && e.File == file {
+			if sha, size, err := artifact.FileSha256(filePath); err == nil && sha == e.Sha256 && size == e.Size && artifact.Verify(a.Sha256, sha) == nil {
+				entry := *e
+				return &entry, false, nil
+			}
+		}
+	}
+
+	var req *http.Request
+	if a.Task == "download-file-github-asset" {
+		req, err = c.githubAssetRequest(a.Vars)
+	} else {
+		req, err = http.NewRequest(http.MethodGet, fetchUrl, nil)
+		if err == nil && a.Vars != nil {
+			if user, token := artifact.ExpandEnv(a.Vars["USER"]), artifact.ExpandEnv(a.Vars["TOKEN"]); user != "" && token != "" {
+				req.SetBasicAuth(user, token)
+			}
+		}
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	sha, size, err := c.download(req, filePath)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := artifact.Verify(a.Sha256, sha); err != nil {
+		os.Remove(filePath)
+		return nil, false, err
+	}
+	return &artifact.Entry{
+		Url:    a.Url,
+		Kind:   a.Kind,
+		File:   file,
+		Size:   size,
+		Sha256: sha,
+	}, true, nil
+}
+
+// githubAssetRequest returns the request for a GitHub release asset (the
+// asset URL is located with the releases API, see download-file-github-asset).
+func (c *VendorCommand) githubAssetRequest(vars map[string]string) (*http.Request, error) {
+	return artifact.GitHubAssetRequest(c.client, artifact.ExpandEnv(vars["API_URL"]), artifact.ExpandEnv(vars["TAG"]), artifact.ExpandEnv(vars["ASSET_NAME"]), artifact.ExpandEnv(vars["TOKEN"]))
+}
+
+// download writes the response of the request to the file, and returns the
+// SHA-256 and size of the file. Network errors and 5xx responses are retried
+// (see -retries).
+func (c *VendorCommand) download(req *http.Request, file string) (sha string, size int64, err error) {
+	artifact.Retry(c.retries, "Download failed, retrying", req.URL.String(), func() bool {
+		var retry bool
+		sha, size, retry, err = c.downloadOnce(req, file)
+		return err != nil && retry
+	})
+	return sha, size, err
+}
+
+func (c *VendorCommand) downloadOnce(req *http.Request, file string) (sha string, size int64, retry bool, err error) {
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", 0, true, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", 0, resp.StatusCode >= http.StatusInternalServerError, fmt.Errorf("download failed: %s", resp.Status)
+	}
+	if err := os.MkdirAll(filepath.Dir(file), os.ModePerm); err != nil {
+		return "", 0, false, err
+	}
+	// Download to a temporary file, the vendored file is replaced when the
+	// download is complete.
+	tmp, err := os.CreateTemp(filepath.Dir(file), ".download-*")
+	if err != nil {
+		return "", 0, false, err
+	}
+	defer os.Remove(tmp.Name())
+	hash := sha256.New()
+	size, err = io.Copy(io.MultiWriter(tmp, hash), resp.Body)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", 0, true, err
+	}
+	if err := os.Rename(tmp.Name(), file); err != nil {
+		return "", 0, false, err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), size, false, nil
+}
+
+// taskfileIncludes returns the includes of a vendored Taskfile as URLs
+// (relative includes are relative to the Taskfile URL), remote includes are
+// vendored as well. Templated includes cannot be located and are reported.
+func taskfileIncludes(taskfileUrl string, file string) ([]string, error) {
+	includes := []string{}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var taskfile generate.Taskfile
+	if err := yaml.Unmarshal(data, &taskfile); err != nil {
+		return nil, fmt.Errorf("failed to parse Taskfile: %v", err)
+	}
+	if taskfile.Includes == nil {
+		return includes, nil
+	}
+	base, err := provider.ParseUrl(taskfileUrl)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range slices.Sorted(maps.Keys(*taskfile.Includes)) {
+		location := (*taskfile.Includes)[name].Taskfile
+		switch {
+		case strings.Contains(location, "{{"):
+			return nil, fmt.Errorf("templated include %s (%s) cannot be vendored", name, location)
+		case strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://"):
+			includes = append(includes, location)
+			continue
+		case filepath.IsAbs(location):
+			continue
+		}
+		if ext := path.Ext(location); ext != ".yml" && ext != ".yaml" {
+			location = path.Join(location, "Taskfile.yml")
+		}
+		ref, err := url.Parse(location)
+		if err != nil {
+			return nil, fmt.Errorf("include %s: %v", name, err)
+		}
+		u := base.ResolveReference(ref).String()
+		includes = append(includes, strings.NewReplacer("%7B", "{", "%7D", "}").Replace(u))
+	}
+	return includes, nil
+}
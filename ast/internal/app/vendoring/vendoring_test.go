@@ -0,0 +1,261 @@
+// Copyright 2025 Robert Bosch GmbH
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package vendoring
+
+import (
+	"maps"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/boschglobal/dse.sdp/ast/internal/app/generate"
+	"github.com/boschglobal/dse.sdp/ast/internal/pkg/artifact"
+	"github.com/boschglobal/dse.sdp/ast/internal/pkg/testutil"
+)
+
+const vendorTestAst = `---
+kind: Simulation
+spec:
+  arch: linux-amd64
+  channels:
+    - name: physical
+  stacks:
+    - name: default
+      models:
+        - name: input
+          model: dse.modelc.csv
+          uses: dse.modelc
+          channels:
+            - alias: scalar_vector
+              name: physical
+          files:
+            - name: input.csv
+              reference: uses
+              value: input
+          metadata:
+            package:
+              download: '{{.REPO}}/releases/download/v{{.TAG}}/ModelC-{{.TAG}}-{{.PLATFORM_ARCH}}.zip'
+            models:
+              dse.modelc.csv:
+                path: examples/csv
+  uses:
+    - name: dse.modelc
+      url: SERVER/boschglobal/dse.modelc
+      version: v2.1.15
+    - name: input
+      url: SERVER/fileshare/input.csv
+`
+
+const vendorTestTaskfile = `---
+version: '3'
+includes:
+  common: ./common.yml
+tasks:
+  build:
+    cmds:
+      - echo build
+`
+
+var vendorTestFiles = map[string]string{
+	"/sdp/dse.modelc/v2.1.15/Taskfile.yml":                                            vendorTestTaskfile,
+	"/sdp/dse.modelc/v2.1.15/common.yml":                                              "version: '3'\n",
+	"/boschglobal/dse.modelc/releases/download/v2.1.15/ModelC-2.1.15-linux-amd64.zip": "zip content",
+	"/boschglobal/dse.modelc/releases/download/v2.1.15/Simer-2.1.15-windows.zip":      "simer zip content",
+	"/fileshare/input.csv":                                                            "a,b\n1,2\n",
+}
+
+// setupVendorTest stages the AST (uses entries hosted on a TLS server) and a
+// provider config (see testutil.FileServer.Stage).
+func setupVendorTest(t *testing.T, files map[string]string) (*testutil.FileServer, *atomic.Int32) {
+	server := testutil.NewFileServer(t, files, true)
+	server.Stage(t, vendorTestAst)
+	return server, &server.Fetches
+}
+
+func runVendor(t *testing.T, args ...string) error {
+	cmd := NewVendorCommand("vendor")
+	require.NoError(t, cmd.Parse(append([]string{"-input", "ast.yaml", "-providers", "providers.yaml", "-log", "1"}, args...)))
+	return cmd.Run()
+}
+
+func TestVendor(t *testing.T) {
+	server, fetches := setupVendorTest(t, vendorTestFiles)
+	u, _ := url.Parse(server.URL)
+
+	require.NoError(t, runVendor(t))
+	assert.Equal(t, int32(5), fetches.Load())
+
+	index, err := artifact.Load("out/vendor")
+	require.NoError(t, err)
+	require.Len(t, index.Entries, 5)
+	for path, content := range vendorTestFiles {
+		entry := index.Lookup(server.URL + path)
+		require.NotNil(t, entry, path)
+		assert.Equal(t, filepath.Join(u.Host, path), entry.File)
+		assert.Equal(t, testutil.Sha256(content), entry.Sha256)
+		assert.Equal(t, int64(len(content)), entry.Size)
+		data, err := os.ReadFile(filepath.Join("out/vendor", entry.File))
+		require.NoError(t, err)
+		assert.Equal(t, content, string(data))
+	}
+	assert.Equal(t, artifact.KindTaskfile, index.Lookup(server.URL+"/sdp/dse.modelc/v2.1.15/Taskfile.yml").Kind)
+	assert.Equal(t, artifact.KindTaskfile, index.Lookup(server.URL+"/sdp/dse.modelc/v2.1.15/common.yml").Kind)
+	assert.Equal(t, artifact.KindFile, index.Lookup(server.URL+"/fileshare/input.csv").Kind)
+
+	// Unchanged files are not fetched again.
+	require.NoError(t, runVendor(t))
+	assert.Equal(t, int32(5), fetches.Load())
+}
+
+func TestVendor_failed(t *testing.T) {
+	files := maps.Clone(vendorTestFiles)
+	delete(files, "/fileshare/input.csv")
+	server, _ := setupVendorTest(t, files)
+
+	err := runVendor(t, "-retries", "0")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "1 issue(s) found")
+
+	// The artifacts which were vendored are indexed.
+	index, err := artifact.Load("out/vendor")
+	require.NoError(t, err)
+	assert.Len(t, index.Entries, 4)
+	assert.Nil(t, index.Lookup(server.URL+"/fileshare/input.csv"))
+}
+
+func TestVendor_remoteInclude(t *testing.T) {
+	files := maps.Clone(vendorTestFiles)
+	server, _ := setupVendorTest(t, files)
+	files["/sdp/dse.modelc/v2.1.15/common.yml"] = "version: '3'\nincludes:\n  lib: " + server.URL + "/lib/Taskfile.yml\n"
+	files["/lib/Taskfile.yml"] = "version: '3'\n"
+
+	require.NoError(t, runVendor(t))
+	index, err := artifact.Load("out/vendor")
+	require.NoError(t, err)
+	assert.Len(t, index.Entries, 6)
+	entry := index.Lookup(server.URL + "/lib/Taskfile.yml")
+	require.NotNil(t, entry)
+	assert.Equal(t, artifact.KindTaskfile, entry.Kind)
+}
+
+func TestVendor_templatedInclude(t *testing.T) {
+	files := maps.Clone(vendorTestFiles)
+	files["/sdp/dse.modelc/v2.1.15/common.yml"] = "version: '3'\nincludes:\n  lib: '{{.LIBDIR}}/Taskfile.yml'\n"
+	server, _ := setupVendorTest(t, files)
+
+	err := runVendor(t)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "1 issue(s) found")
+	index, err := artifact.Load("out/vendor")
+	require.NoError(t, err)
+	assert.Nil(t, index.Lookup(server.URL+"/sdp/dse.modelc/v2.1.15/common.yml"))
+}
+
+func TestGenerate_vendor(t *testing.T) {
+	server, fetches := setupVendorTest(t, vendorTestFiles)
+	require.NoError(t, runVendor(t))
+	server.Close()
+	require.NoError(t, os.MkdirAll("out/sim", 0755))
+
+	gen := generate.NewGenerateCommand("generate")
+	require.NoError(t, gen.Parse([]string{"-taskfile", "-input", "ast.yaml", "-output", "sim", "-providers", "providers.yaml", "-vendor"}))
+	require.NoError(t, gen.Run())
+	assert.Equal(t, int32(5), fetches.Load())
+
+	data, err := os.ReadFile("out/sim/Taskfile.yml")
+	require.NoError(t, err)
+	var taskfile generate.Taskfile
+	require.NoError(t, yaml.Unmarshal(data, &taskfile))
+	vendorDir, _ := filepath.Abs("out/vendor")
+	u, _ := url.Parse(server.URL)
+
+	include := (*taskfile.Includes)["dse.modelc-v2.1.15"]
+	assert.Equal(t, filepath.Join(vendorDir, u.Host, "sdp/dse.modelc/v2.1.15/Taskfile.yml"), include.Taskfile)
+
+	deps := *(*taskfile.Tasks)["model-input"].Deps
+	require.Len(t, deps, 2)
+	for i, want := range []string{
+		"boschglobal/dse.modelc/releases/download/v2.1.15/ModelC-2.1.15-linux-amd64.zip",
+		"fileshare/input.csv",
+	} {
+		assert.Equal(t, "copy-file", deps[i].Task)
+		url, _ := deps[i].Vars.Get("URL")
+		assert.Equal(t, filepath.Join(vendorDir, u.Host, want), url)
+	}
+	file, _ := deps[0].Vars.Get("FILE")
+	assert.Equal(t, "downloads/{{base .PACKAGE_URL}}", file)
+	assert.NotContains(t, string(data), "task: download-file")
+}
+
+func TestGenerate_vendorMissing(t *testing.T) {
+	setupVendorTest(t, vendorTestFiles)
+	require.NoError(t, os.MkdirAll("out/vendor", 0755))
+	require.NoError(t, os.WriteFile("out/vendor/index.yaml", []byte("entries: []\n"), 0644))
+	require.NoError(t, os.MkdirAll("out/sim", 0755))
+
+	gen := generate.NewGenerateCommand("generate")
+	require.NoError(t, gen.Parse([]string{"-taskfile", "-input", "ast.yaml", "-output", "sim", "-providers", "providers.yaml", "-vendor"}))
+	err := gen.Run()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "3 issue(s) found")
+}
+
+// pinUses adds a sha256 pin to a uses entry of the staged AST.
+func pinUses(t *testing.T, url string, sha string) {
+	data, err := os.ReadFile("out/ast.yaml")
+	require.NoError(t, err)
+	ast := strings.Replace(string(data), "url: "+url+"\n", "url: "+url+"\n      metadata:\n        sha256: "+sha+"\n", 1)
+	require.NoError(t, os.WriteFile("out/ast.yaml", []byte(ast), 0644))
+}
+
+func TestVendor_sha256(t *testing.T) {
+	server, _ := setupVendorTest(t, vendorTestFiles)
+	pinUses(t, server.URL+"/boschglobal/dse.modelc", testutil.Sha256("zip content"))
+	pinUses(t, server.URL+"/fileshare/input.csv", testutil.Sha256("a,b\n1,2\n"))
+	require.NoError(t, runVendor(t))
+
+	// The vendored files are verified by generate.
+	require.NoError(t, os.MkdirAll("out/sim", 0755))
+	gen := generate.NewGenerateCommand("generate")
+	require.NoError(t, gen.Parse([]string{"-taskfile", "-input", "ast.yaml", "-output", "sim", "-providers", "providers.yaml", "-vendor"}))
+	require.NoError(t, gen.Run())
+
+	index, err := artifact.Load("out/vendor")
+	require.NoError(t, err)
+	file := filepath.Join("out/vendor", index.Lookup(server.URL+"/fileshare/input.csv").File)
+	require.NoError(t, os.WriteFile(file, []byte("a,b\n3,4\n"), 0644))
+	gen = generate.NewGenerateCommand("generate")
+	require.NoError(t, gen.Parse([]string{"-taskfile", "-input", "ast.yaml", "-output", "sim", "-providers", "providers.yaml", "-vendor"}))
+	err = gen.Run()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "1 issue(s) found")
+}
+
+func TestVendor_sha256Mismatch(t *testing.T) {
+	server, _ := setupVendorTest(t, vendorTestFiles)
+	pinUses(t, server.URL+"/boschglobal/dse.modelc", testutil.Sha256("other"))
+	pinUses(t, server.URL+"/fileshare/input.csv", testutil.Sha256("other"))
+
+	err := runVendor(t)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "2 issue(s) found")
+
+	// Files which do not match the pin are neither vendored nor indexed, the
+	// pin of a repo is the pin of its package (not of its Taskfile).
+	index, err := artifact.Load("out/vendor")
+	require.NoError(t, err)
+	assert.Nil(t, index.Lookup(server.URL+"/fileshare/input.csv"))
+	assert.Nil(t, index.Lookup(server.URL+"/boschglobal/dse.modelc/releases/download/v2.1.15/ModelC-2.1.15-linux-amd64.zip"))
+	assert.NotNil(t, index.Lookup(server.URL+"/sdp/dse.modelc/v2.1.15/Taskfile.yml"))
+	u, _ := url.Parse(server.URL)
+	assert.NoFileExists(t, filepath.Join("out/vendor", u.Host, "fileshare/input.csv"))
+}
@@ -0,0 +1,155 @@
+// Copyright 2025 Robert Bosch GmbH
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package artifact
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/boschglobal/dse.schemas/code/go/dse/ast"
+)
+
+const IndexFile = "index.yaml"
+
+// Kinds of vendored artifacts.
+const (
+	KindTaskfile = "taskfile" // Remote Taskfile (include).
+	KindFile     = "file"     // File downloaded by a task (package, FMU, etc.).
+)
+
+// MetadataSha256 is the metadata key of the SHA-256 pin of a uses entry (DSL
+// attribute sha256=). The pin covers the content referenced by the uses entry:
+// the metadata Taskfile of a repository (uses with a version), otherwise the
+// remote file.
+const MetadataSha256 = "sha256"
+
+var sha256Pattern = regexp.MustCompile(`^[0-9a-fA-F]{64}$`)
+
+// Entry describes a vendored file. The URL is the URL referenced by the
+// generated Taskfile (it may contain template or shell variable references),
+// the file is relative to the vendor dir (see Path).
+type Entry struct {
+	Url    string `yaml:"url"`
+	Kind   string `yaml:"kind"`
+	File   string `yaml:"file"`
+	Size   int64  `yaml:"size"`
+	Sha256 string `yaml:"sha256"`
+}
+
+type Index struct {
+	Entries []Entry `yaml:"entries"`
+}
+
+// Path returns the vendor file (relative to the vendor dir) for a URL, the
+// host and path of the URL (i.e. github.com/org/repo/releases/download/v1.0.0/
+// file.zip). User info is not part of the path. The query is represented by a
+// hash in the file name (i.e. Taskfile-<hash>.yml for ?ref=v1.0.0), so URLs
+// which differ in the query map to different files, and relative includes of
+// a vendored Taskfile are still vendored next to the Taskfile.
+func Path(u string) (string, error) {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return "", err
+	}
+	if parsed.Host == "" || strings.ContainsAny(parsed.Host+parsed.Path, "{}$") {
+		return "", fmt.Errorf("unsupported url: %s", u)
+	}
+	p := path.Clean("/" + parsed.Path)
+	if p == "/" {
+		return "", fmt.Errorf("url without path: %s", u)
+	}
+	if parsed.RawQuery != "" {
+		hash := sha256.Sum256([]byte(parsed.RawQuery))
+		ext := path.Ext(p)
+		p = fmt.Sprintf("%s-%s%s", strings.TrimSuffix(p, ext), hex.EncodeToString(hash[:6]), ext)
+	}
+	return filepath.FromSlash(parsed.Host + p), nil
+}
+
+// Load loads the index of the vendor dir.
+func Load(dir string) (*Index, error) {
+	data, err := os.ReadFile(filepath.Join(dir, IndexFile))
+	if err != nil {
+		return nil, fmt.Errorf("Error reading vendor index: %v", err)
+	}
+	index := Index{Entries: []Entry{}}
+	if err := yaml.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("Error parsing vendor index: %v", err)
+	}
+	return &index, nil
+}
+
+// Save writes the index (sorted by URL) to the vendor dir.
+func (i *Index) Save(dir string) error {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return fmt.Errorf("Unable to create vendor dir: %v", err)
+	}
+	slices.SortFunc(i.Entries, func(a, b Entry) int {
+		return strings.Compare(a.Url, b.Url)
+	})
+	data, err := yaml.Marshal(i)
+	if err != nil {
+		return fmt.Errorf("failed to marshal vendor index: %v", err)
+	}
+	return os.WriteFile(filepath.Join(dir, IndexFile), data, 0644)
+}
+
+// Lookup returns the index entry for a URL, or nil.
+func (i *Index) Lookup(url string) *Entry {
+	for j := range i.Entries {
+		if i.Entries[j].Url == url {
+			return &i.Entries[j]
+		}
+	}
+	return nil
+}
+
+// FileSha256 returns the SHA-256 (hex) and size of a file.
+func FileSha256(file string) (string, int64, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+	hash := sha256.New()
+	size, err := io.Copy(hash, f)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), size, nil
+}
+
+// UsesSha256 returns the SHA-256 pin of a uses entry, or "" if not pinned.
+func UsesSha256(uses ast.Uses) string {
+	if uses.Metadata == nil {
+		return ""
+	}
+	sha, _ := (*uses.Metadata)[MetadataSha256].(string)
+	return sha
+}
+
+// ValidSha256 reports whether s is a SHA-256 checksum (hex encoded).
+func ValidSha256(s string) bool {
+	return sha256Pattern.MatchString(s)
+}
+
+// Verify checks a SHA-256 against a pin, an empty pin is not checked.
+func Verify(pin string, sha string) error {
+	if pin != "" && !strings.EqualFold(pin, sha) {
+		return fmt.Errorf("sha256 mismatch (pinned %s, got %s)", pin, sha)
+	}
+	return nil
+}
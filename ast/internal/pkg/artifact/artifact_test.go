@@ -0,0 +1,83 @@
+// Copyright 2025 Robert Bosch GmbH
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package artifact
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/boschglobal/dse.schemas/code/go/dse/ast"
+)
+
+func TestPath(t *testing.T) {
+	tests := []struct {
+		url  string
+		path string
+		err  bool
+	}{
+		{url: "https://github.com/org/repo/releases/download/v1.0.0/file.zip", path: "github.com/org/repo/releases/download/v1.0.0/file.zip"},
+		{url: "https://token@raw.github.com/org/repo/v1.0.0/Taskfile.yml", path: "raw.github.com/org/repo/v1.0.0/Taskfile.yml"},
+		{url: "https://example.com/repo/Taskfile.yml?ref=main", path: "example.com/repo/Taskfile-6e7d7d440665.yml"},
+		{url: "https://example.com/repo/Taskfile.yml?ref=v1.0.0", path: "example.com/repo/Taskfile-35564f8ed2db.yml"},
+		{url: "https://example.com/download?file=a", path: "example.com/download-aa01fd1fd1b3"},
+		{url: "https://example.com/a/../../b/file.csv", path: "example.com/b/file.csv"},
+		{url: "https://example.com/", err: true},
+		{url: "https://example.com/file-${PLATFORM_ARCH}.zip", err: true},
+		{url: "/volume/file.csv", err: true},
+	}
+	for _, tc := range tests {
+		path, err := Path(tc.url)
+		if tc.err {
+			assert.Error(t, err, tc.url)
+			continue
+		}
+		require.NoError(t, err, tc.url)
+		assert.Equal(t, filepath.FromSlash(tc.path), path)
+	}
+}
+
+func TestIndex(t *testing.T) {
+	dir := t.TempDir()
+	_, err := Load(dir)
+	assert.Error(t, err)
+
+	index := &Index{Entries: []Entry{
+		{Url: "https://example.com/b.zip", Kind: KindFile, File: "example.com/b.zip"},
+		{Url: "https://example.com/a/Taskfile.yml", Kind: KindTaskfile, File: "example.com/a/Taskfile.yml"},
+	}}
+	require.NoError(t, index.Save(dir))
+	index, err = Load(dir)
+	require.NoError(t, err)
+	require.Len(t, index.Entries, 2)
+	assert.Equal(t, "https://example.com/a/Taskfile.yml", index.Entries[0].Url)
+	assert.Equal(t, KindFile, index.Lookup("https://example.com/b.zip").Kind)
+	assert.Nil(t, index.Lookup("https://example.com/c.zip"))
+
+	file := filepath.Join(dir, "file.txt")
+	require.NoError(t, os.WriteFile(file, []byte("hello"), 0644))
+	sha, size, err := FileSha256(file)
+	require.NoError(t, err)
+	assert.Equal(t, "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824", sha)
+	assert.Equal(t, int64(5), size)
+}
+
+func TestUsesSha256(t *testing.T) {
+	pin := "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+	assert.Equal(t, "", UsesSha256(ast.Uses{Name: "input"}))
+	assert.Equal(t, pin, UsesSha256(ast.Uses{Name: "input", Metadata: &map[string]interface{}{MetadataSha256: pin}}))
+
+	assert.True(t, ValidSha256(pin))
+	assert.False(t, ValidSha256(pin[1:]))
+	assert.False(t, ValidSha256("sha256="+pin))
+
+	assert.NoError(t, Verify("", pin))
+	assert.NoError(t, Verify(strings.ToUpper(pin), pin))
+	assert.ErrorContains(t, Verify(pin, "0000"), "sha256 mismatch")
+}
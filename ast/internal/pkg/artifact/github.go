@@ -0,0 +1,53 @@
+// Copyright 2025 Robert Bosch GmbH
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package artifact
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// GitHubAssetRequest returns the request for a GitHub release asset, the asset
+// URL is located with the releases API (see download-file-github-asset).
+func GitHubAssetRequest(client *http.Client, apiUrl string, tag string, assetName string, token string) (*http.Request, error) {
+	releaseUrl := fmt.Sprintf("%s/releases/tags/%s", apiUrl, tag)
+	req, err := http.NewRequest(http.MethodGet, releaseUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("release not found: %s (%s)", releaseUrl, resp.Status)
+	}
+	var release struct {
+		Assets []struct {
+			Name string `json:"name"`
+			Url  string `json:"url"`
+		} `json:"assets"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return nil, fmt.Errorf("invalid release: %s (%v)", releaseUrl, err)
+	}
+	for _, asset := range release.Assets {
+		if strings.Contains(asset.Name, assetName) {
+			req, err := http.NewRequest(http.MethodGet, asset.Url, nil)
+			if err != nil {
+				return nil, err
+			}
+			req.Header.Set("Accept", "application/octet-stream")
+			req.Header.Set("Authorization", "Bearer "+token)
+			return req, nil
+		}
+	}
+	return nil, fmt.Errorf("asset %s not found: %s", assetName, releaseUrl)
+}
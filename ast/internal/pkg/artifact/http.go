@@ -0,0 +1,58 @@
+// Copyright 2025 Robert Bosch GmbH
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package artifact
+
+import (
+	"log/slog"
+	"net/url"
+	"os"
+	"regexp"
+	"time"
+)
+
+// RetryBackoff is the delay before the first retry of a failed request, the
+// delay doubles with each subsequent retry.
+var RetryBackoff = 500 * time.Millisecond
+
+// Retry calls fetch until it succeeds (returns false), or until the retries
+// are exhausted. Each retry is logged with the message and the (redacted) URL.
+func Retry(retries int, msg string, u string, fetch func() (retry bool)) {
+	for attempt := 0; ; attempt++ {
+		if !fetch() || attempt >= retries {
+			return
+		}
+		delay := RetryBackoff << attempt
+		slog.Warn(msg, "url", RedactUrl(u), "attempt", attempt+1, "delay", delay)
+		time.Sleep(delay)
+	}
+}
+
+// RedactUrl removes the user info (i.e. a token) of a URL.
+func RedactUrl(s string) string {
+	u, err := url.Parse(s)
+	if err != nil || u.User == nil {
+		return s
+	}
+	u.User = nil
+	return u.String()
+}
+
+var envRefRegex = regexp.MustCompile(`\$\{(\w+)(?::-([^}]*))?\}|\$(\w+)|\{\{\s*\.(\w+)\s*\}\}`)
+
+// ExpandEnv expands the references of a URL or credential with the
+// environment: shell variables ($VAR, ${VAR} and ${VAR:-default}, e.g. the
+// user=$USER of a uses entry or the rendered vars of a download task) and
+// templates (i.e. the {{.GHE_TOKEN}} of provider URLs). Unset (or empty)
+// variables expand to the default, or "".
+func ExpandEnv(s string) string {
+	return envRefRegex.ReplaceAllStringFunc(s, func(match string) string {
+		m := envRefRegex.FindStringSubmatch(match)
+		name := m[1] + m[3] + m[4]
+		if value, ok := os.LookupEnv(name); ok && value != "" {
+			return value
+		}
+		return m[2]
+	})
+}
@@ -0,0 +1,275 @@
+// Copyright 2025 Robert Bosch GmbH
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const IndexFile = "index.yaml"
+
+// Entry describes a cached file. The file name is the SHA-256 of the source
+// URL (see Key).
+type Entry struct {
+	File    string    `yaml:"file"`
+	Url     string    `yaml:"url"`
+	Fetched time.Time `yaml:"fetched"`
+	ETag    string    `yaml:"etag,omitempty"`
+	Size    int64     `yaml:"size"`
+	Sha256  string    `yaml:"sha256"`
+}
+
+type Index struct {
+	Entries []Entry `yaml:"entries"`
+}
+
+// Cache of fetched (metadata) files, with an index. Safe for concurrent use.
+type Cache struct {
+	Dir   string
+	index Index
+	mu    sync.Mutex
+}
+
+// Key returns the cache file name for a URL.
+func Key(url string) string {
+	hash := sha256.Sum256([]byte(url))
+	return hex.EncodeToString(hash[:])
+}
+
+func contentSha256(data []byte) string {
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
+
+// Open opens the cache in dir, the index is loaded if present.
+func Open(dir string) (*Cache, error) {
+	c := &Cache{Dir: dir, index: Index{Entries: []Entry{}}}
+	data, err := os.ReadFile(filepath.Join(dir, IndexFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return c, nil
+		}
+		return nil, fmt.Errorf("Error reading cache index: %v", err)
+	}
+	if err := yaml.Unmarshal(data, &c.index); err != nil {
+		return nil, fmt.Errorf("Error parsing cache index: %v", err)
+	}
+	return c, nil
+}
+
+func (c *Cache) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.save()
+}
+
+func (c *Cache) save() error {
+	if err := os.MkdirAll(c.Dir, os.ModePerm); err != nil {
+		return fmt.Errorf("Unable to create cache dir: %v", err)
+	}
+	slices.SortFunc(c.index.Entries, func(a, b Entry) int {
+		return strings.Compare(a.Url, b.Url)
+	})
+	data, err := yaml.Marshal(c.index)
+	if err != nil {
+		return fmt.Errorf("failed to marshal cache index: %v", err)
+	}
+	return os.WriteFile(filepath.Join(c.Dir, IndexFile), data, 0644)
+}
+
+// Path returns the path of the cache file for a URL.
+func (c *Cache) Path(url string) string {
+	return filepath.Join(c.Dir, Key(url))
+}
+
+// Entries returns the index entries (sorted by URL).
+func (c *Cache) Entries() []Entry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entries := slices.Clone(c.index.Entries)
+	slices.SortFunc(entries, func(a, b Entry) int {
+		return strings.Compare(a.Url, b.Url)
+	})
+	return entries
+}
+
+// Lookup returns (a copy of) the index entry for a URL, or nil.
+func (c *Cache) Lookup(url string) *Entry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e := c.lookup(url); e != nil {
+		entry := *e
+		return &entry
+	}
+	return nil
+}
+
+func (c *Cache) lookup(url string) *Entry {
+	for i := range c.index.Entries {
+		if c.index.Entries[i].Url == url {
+			return &c.index.Entries[i]
+		}
+	}
+	return nil
+}
+
+// Find returns the index entries matching the reference, which may be a URL,
+// a cache file name or a cache file name prefix.
+func (c *Cache) Find(ref string) []Entry {
+	entries := []Entry{}
+	for _, e := range c.Entries() {
+		if e.Url == ref || strings.HasPrefix(e.File, ref) {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// Get reads the cached file for a URL. The returned entry is nil if the file
+// is not indexed (i.e. cached by an older version).
+func (c *Cache) Get(url string) ([]byte, *Entry, bool) {
+	data, err := os.ReadFile(c.Path(url))
+	if err != nil {
+		return nil, nil, false
+	}
+	return data, c.Lookup(url), true
+}
+
+// Put writes a file to the cache and updates (and saves) the index.
+func (c *Cache) Put(url string, data []byte, etag string) error {
+	if err := os.MkdirAll(c.Dir, os.ModePerm); err != nil {
+		return fmt.Errorf("Unable to create cache dir: %v", err)
+	}
+	if err := os.WriteFile(c.Path(url), data, 0644); err != nil {
+		return fmt.Errorf("failed to write cache file: %v", err)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry := Entry{
+		File:    Key(url),
+		Url:     url,
+		Fetched: time.Now().UTC().Truncate(time.Second),
+		ETag:    etag,
+		Size:    int64(len(data)),
+		Sha256:  contentSha256(data),
+	}
+	if e := c.lookup(url); e != nil {
+		*e = entry
+	} else {
+		c.index.Entries = append(c.index.Entries, entry)
+	}
+	return c.save()
+}
+
+// Touch marks the cached file for a URL as fetched now (e.g. after a
+// conditional request reported the file was not modified).
+func (c *Cache) Touch(url string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e := c.lookup(url); e != nil {
+		e.Fetched = time.Now().UTC().Truncate(time.Second)
+		return c.save()
+	}
+	return nil
+}
+
+// Stale returns true if the entry is older than the ttl (a ttl of 0 never
+// expires). Files which are not indexed are always stale (when a ttl is set).
+func Stale(e *Entry, ttl time.Duration) bool {
+	if ttl <= 0 {
+		return false
+	}
+	if e == nil {
+		return true
+	}
+	return time.Since(e.Fetched) > ttl
+}
+
+// Remove removes the file and index entry of a URL.
+func (c *Cache) Remove(url string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	os.Remove(c.Path(url))
+	c.index.Entries = slices.DeleteFunc(c.index.Entries, func(e Entry) bool {
+		return e.Url == url
+	})
+}
+
+// Orphans returns the files in the cache dir which are not indexed.
+func (c *Cache) Orphans() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	orphans := []string{}
+	files, err := os.ReadDir(c.Dir)
+	if err != nil {
+		return orphans
+	}
+	for _, f := range files {
+		if f.IsDir() || f.Name() == IndexFile {
+			continue
+		}
+		if !slices.ContainsFunc(c.index.Entries, func(e Entry) bool { return e.File == f.Name() }) {
+			orphans = append(orphans, f.Name())
+		}
+	}
+	return orphans
+}
+
+// Prune removes entries older than the ttl (if set) or all entries (if all),
+// as well as files which are not indexed. Returns the removed files.
+func (c *Cache) Prune(ttl time.Duration, all bool) ([]string, error) {
+	removed := []string{}
+	for _, f := range c.Orphans() {
+		os.Remove(filepath.Join(c.Dir, f))
+		removed = append(removed, f)
+	}
+	for _, e := range c.Entries() {
+		if all || Stale(&e, ttl) || !fileExists(filepath.Join(c.Dir, e.File)) {
+			c.Remove(e.Url)
+			removed = append(removed, e.File)
+		}
+	}
+	return removed, c.Save()
+}
+
+// Verify checks the index against the cached files and returns the issues
+// found (missing files, size or content mismatch, files not indexed).
+func (c *Cache) Verify() []string {
+	issues := []string{}
+	for _, e := range c.Entries() {
+		data, err := os.ReadFile(filepath.Join(c.Dir, e.File))
+		if err != nil {
+			issues = append(issues, fmt.Sprintf("%s: file missing (%s)", e.File, e.Url))
+			continue
+		}
+		if e.File != Key(e.Url) {
+			issues = append(issues, fmt.Sprintf("%s: file name does not match url (%s)", e.File, e.Url))
+		}
+		if int64(len(data)) != e.Size {
+			issues = append(issues, fmt.Sprintf("%s: size mismatch (index %d, file %d)", e.File, e.Size, len(data)))
+		} else if sha := contentSha256(data); e.Sha256 != "" && sha != e.Sha256 {
+			issues = append(issues, fmt.Sprintf("%s: sha256 mismatch (index %s, file %s)", e.File, e.Sha256, sha))
+		}
+	}
+	for _, f := range c.Orphans() {
+		issues = append(issues, fmt.Sprintf("%s: file not indexed", f))
+	}
+	return issues
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
@@ -0,0 +1,73 @@
+// Copyright 2025 Robert Bosch GmbH
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache(t *testing.T) {
+	dir := t.TempDir()
+	c, err := Open(dir)
+	require.NoError(t, err)
+	require.NoError(t, c.Put("https://example.com/a/Taskfile.yml", []byte("version: '3'\n"), `"abc"`))
+	require.NoError(t, c.Put("https://example.com/b/Taskfile.yml", []byte("version: '3'\n"), ""))
+
+	// Reopen, index is persisted.
+	c, err = Open(dir)
+	require.NoError(t, err)
+	entries := c.Entries()
+	require.Len(t, entries, 2)
+	assert.Equal(t, Key("https://example.com/a/Taskfile.yml"), entries[0].File)
+	assert.Equal(t, `"abc"`, entries[0].ETag)
+	assert.Equal(t, int64(13), entries[0].Size)
+
+	data, entry, ok := c.Get("https://example.com/a/Taskfile.yml")
+	assert.True(t, ok)
+	assert.Equal(t, "version: '3'\n", string(data))
+	assert.False(t, Stale(entry, 0))
+	assert.False(t, Stale(entry, time.Hour))
+	entry.Fetched = time.Now().Add(-2 * time.Hour)
+	assert.True(t, Stale(entry, time.Hour))
+	assert.True(t, Stale(nil, time.Hour))
+
+	assert.Len(t, c.Find(entries[1].File[:8]), 1)
+	assert.Empty(t, c.Verify())
+}
+
+func TestCache_verifyPrune(t *testing.T) {
+	dir := t.TempDir()
+	c, err := Open(dir)
+	require.NoError(t, err)
+	require.NoError(t, c.Put("https://example.com/a/Taskfile.yml", []byte("version: '3'\n"), ""))
+	require.NoError(t, c.Put("https://example.com/b/Taskfile.yml", []byte("version: '3'\n"), ""))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "orphan"), []byte("foo"), 0644))
+	require.NoError(t, os.WriteFile(c.Path("https://example.com/a/Taskfile.yml"), []byte("version: '2'\n"), 0644))
+	require.NoError(t, os.Remove(c.Path("https://example.com/b/Taskfile.yml")))
+
+	assert.Equal(t, []string{
+		Key("https://example.com/a/Taskfile.yml") + ": sha256 mismatch (index " +
+			contentSha256([]byte("version: '3'\n")) + ", file " + contentSha256([]byte("version: '2'\n")) + ")",
+		Key("https://example.com/b/Taskfile.yml") + ": file missing (https://example.com/b/Taskfile.yml)",
+		"orphan: file not indexed",
+	}, c.Verify())
+
+	removed, err := c.Prune(0, false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"orphan", Key("https://example.com/b/Taskfile.yml")}, removed)
+	assert.Len(t, c.Entries(), 1)
+
+	removed, err = c.Prune(0, true)
+	require.NoError(t, err)
+	assert.Len(t, removed, 1)
+	assert.Empty(t, c.Entries())
+	assert.NoFileExists(t, c.Path("https://example.com/a/Taskfile.yml"))
+}
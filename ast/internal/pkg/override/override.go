@@ -0,0 +1,121 @@
+// Copyright 2025 Robert Bosch GmbH
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package override
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultFile is loaded (if present) when no override file is specified.
+const DefaultFile = "sdp.override.yaml"
+
+// LabelPrefix of the AST labels which mark overridden uses entries (e.g.
+// override/dse.fmi: file:///repo/dse.fmi).
+const LabelPrefix = "override/"
+
+// MetadataKey of the uses metadata which records the original (overridden)
+// URL of a uses entry.
+const MetadataKey = "original_url"
+
+// Overrides redirect uses entries (by name) to a local directory or file.
+type Overrides map[string]string
+
+// Flag collects repeated -override name=url options.
+type Flag []string
+
+func (f *Flag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *Flag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+// Config is the format of the override file.
+//
+//	overrides:
+//	  dse.fmi: file:///home/user/git/dse.fmi
+//	  dse.modelc: ../dse.modelc
+type Config struct {
+	Overrides map[string]string `yaml:"overrides"`
+}
+
+// Load loads the overrides of the file (a missing default file is ignored) and
+// then the overrides specified with -override (which take precedence).
+func Load(file string, flags []string) (Overrides, error) {
+	o := Overrides{}
+	if file == "" {
+		file = DefaultFile
+	}
+	data, err := os.ReadFile(file)
+	if err == nil {
+		var config Config
+		if err := yaml.Unmarshal(data, &config); err != nil {
+			return nil, fmt.Errorf("Error parsing override file: %v", err)
+		}
+		for name, location := range config.Overrides {
+			if err := o.Set(name, location); err != nil {
+				return nil, err
+			}
+		}
+	} else if !os.IsNotExist(err) || file != DefaultFile {
+		return nil, fmt.Errorf("Error reading override file: %v", err)
+	}
+	for _, f := range flags {
+		name, location, ok := strings.Cut(f, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid override '%s' (expected name=file:///path)", f)
+		}
+		if err := o.Set(name, location); err != nil {
+			return nil, err
+		}
+	}
+	return o, nil
+}
+
+// Set adds an override, the location is a file:// URL or a (relative) path.
+func (o Overrides) Set(name string, location string) error {
+	name = strings.TrimSpace(name)
+	location = strings.TrimSpace(location)
+	if name == "" || location == "" {
+		return fmt.Errorf("invalid override '%s=%s'", name, location)
+	}
+	if !strings.HasPrefix(location, "file://") {
+		if strings.Contains(location, "://") {
+			return fmt.Errorf("override '%s': only file:// locations are supported (%s)", name, location)
+		}
+		abs, err := filepath.Abs(location)
+		if err != nil {
+			return fmt.Errorf("override '%s': %v", name, err)
+		}
+		location = "file://" + filepath.ToSlash(abs)
+	}
+	o[name] = location
+	return nil
+}
+
+// Names returns the overridden uses names (sorted).
+func (o Overrides) Names() []string {
+	return slices.Sorted(maps.Keys(o))
+}
+
+// FromLabels returns the overrides marked in the AST labels.
+func FromLabels(labels map[string]string) Overrides {
+	o := Overrides{}
+	for k, v := range labels {
+		if name, ok := strings.CutPrefix(k, LabelPrefix); ok {
+			o[name] = v
+		}
+	}
+	return o
+}
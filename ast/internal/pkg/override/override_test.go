@@ -0,0 +1,50 @@
+// Copyright 2025 Robert Bosch GmbH
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package override
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	// No default file.
+	o, err := Load("", nil)
+	require.NoError(t, err)
+	assert.Empty(t, o)
+	_, err = Load("missing.yaml", nil)
+	assert.ErrorContains(t, err, "Error reading override file")
+
+	require.NoError(t, os.WriteFile(DefaultFile, []byte(`---
+overrides:
+  dse.fmi: file:///repo/dse.fmi
+  dse.modelc: ../dse.modelc
+`), 0644))
+	o, err = Load("", []string{"dse.fmi=file:///checkout/dse.fmi", "dse.ncodec=file:///checkout/dse.ncodec"})
+	require.NoError(t, err)
+	assert.Equal(t, Overrides{
+		"dse.fmi":    "file:///checkout/dse.fmi",
+		"dse.modelc": "file://" + filepath.Join(filepath.Dir(dir), "dse.modelc"),
+		"dse.ncodec": "file:///checkout/dse.ncodec",
+	}, o)
+	assert.Equal(t, []string{"dse.fmi", "dse.modelc", "dse.ncodec"}, o.Names())
+
+	_, err = Load("", []string{"dse.fmi"})
+	assert.ErrorContains(t, err, "invalid override 'dse.fmi'")
+	_, err = Load("", []string{"dse.fmi=https://github.com/boschglobal/dse.fmi"})
+	assert.ErrorContains(t, err, "only file:// locations are supported")
+}
+
+func TestFromLabels(t *testing.T) {
+	labels := map[string]string{"generator": "ast convert", "override/dse.fmi": "file:///repo/dse.fmi"}
+	assert.Equal(t, Overrides{"dse.fmi": "file:///repo/dse.fmi"}, FromLabels(labels))
+}
@@ -0,0 +1,289 @@
+// Copyright 2025 Robert Bosch GmbH
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package provider
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// TaskfileVariants lists the Taskfile names searched for in a repo, in order
+// of preference.
+var TaskfileVariants = []string{
+	"Taskfile.sdp.yml",
+	"Taskfile.sdp.yaml",
+	"Taskfile.yml",
+	"Taskfile.yaml",
+}
+
+// Provider calculates the location of repo metadata (i.e. Taskfiles) for a
+// hosting service.
+type Provider interface {
+	// TaskfileURL returns the URL of the named Taskfile in the repo at the
+	// given version, or nil if the repo URL is not supported.
+	TaskfileURL(repo *url.URL, version string, name string) *url.URL
+}
+
+// repoPath splits the path of a repo URL into owner and repo, returns false
+// if the path is not a repo path (e.g. an asset link).
+func repoPath(repo *url.URL) (string, string, bool) {
+	parts := strings.Split(strings.Trim(repo.Path, "/"), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+	return parts[0], strings.TrimSuffix(parts[1], ".git"), true
+}
+
+// hostUrl returns a copy of the repo URL with only scheme, user and host.
+func hostUrl(repo *url.URL) *url.URL {
+	return &url.URL{Scheme: repo.Scheme, User: repo.User, Host: repo.Host, Path: "/"}
+}
+
+// GitHub provider, for github.com (raw.githubusercontent.com) and GitHub
+// Enterprise (raw.<host>) hosts.
+type GitHub struct {
+	RawHost string
+	TagRefs bool
+	ApiUrl  string
+}
+
+func (p GitHub) TaskfileURL(repo *url.URL, version string, name string) *url.URL {
+	owner, repoName, ok := repoPath(repo)
+	if !ok {
+		return nil
+	}
+	u := hostUrl(repo)
+	u.Host = p.RawHost
+	if p.TagRefs {
+		return u.JoinPath(owner, repoName, "refs/tags", version, name)
+	}
+	return u.JoinPath(owner, repoName, version, name)
+}
+
+// GitLab provider, supports nested groups.
+type GitLab struct {
+	ApiUrl string
+}
+
+func (p GitLab) TaskfileURL(repo *url.URL, version string, name string) *url.URL {
+	repoName := strings.TrimSuffix(strings.Trim(repo.Path, "/"), ".git")
+	if repoName == "" || strings.Contains(repoName, "/-/") {
+		return nil
+	}
+	u := hostUrl(repo)
+	return u.JoinPath(repoName, "-/raw", version, name)
+}
+
+// Gitea provider (also Forgejo).
+type Gitea struct {
+	ApiUrl string
+}
+
+func (p Gitea) TaskfileURL(repo *url.URL, version string, name string) *url.URL {
+	owner, repoName, ok := repoPath(repo)
+	if !ok {
+		return nil
+	}
+	u := hostUrl(repo)
+	return u.JoinPath(owner, repoName, "raw/tag", version, name)
+}
+
+// HTTP provider, the Taskfile URL is calculated from a base URL and a path
+// template. The template may reference: Host, Path (repo path), Owner, Repo,
+// Version and Taskfile. The optional tags template locates the tag list of
+// the repo (see TagURL).
+type HTTP struct {
+	BaseUrl string
+	Path    *template.Template
+	Tags    *template.Template
+}
+
+func NewHTTP(baseUrl string, path string, tags string) (*HTTP, error) {
+	if path == "" {
+		path = "{{.Path}}/{{.Version}}/{{.Taskfile}}"
+	}
+	tmpl, err := template.New("path").Option("missingkey=error").Parse(path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid path template (%s): %v", path, err)
+	}
+	p := &HTTP{BaseUrl: baseUrl, Path: tmpl}
+	if tags != "" {
+		p.Tags, err = template.New("tags").Option("missingkey=error").Parse(tags)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tags template (%s): %v", tags, err)
+		}
+	}
+	return p, nil
+}
+
+func (p HTTP) TaskfileURL(repo *url.URL, version string, name string) *url.URL {
+	repoName := strings.Trim(repo.Path, "/")
+	owner, _, _ := strings.Cut(repoName, "/")
+	var b bytes.Buffer
+	err := p.Path.Execute(&b, map[string]string{
+		"Host":     repo.Host,
+		"Path":     repoName,
+		"Owner":    owner,
+		"Repo":     repoName[strings.LastIndex(repoName, "/")+1:],
+		"Version":  version,
+		"Taskfile": name,
+	})
+	if err != nil {
+		slog.Error("Path template failed", "err", err)
+		return nil
+	}
+	base := p.BaseUrl
+	if base == "" {
+		base = fmt.Sprintf("%s://%s", repo.Scheme, repo.Host)
+	}
+	u, err := url.Parse(strings.TrimSuffix(base, "/") + "/" + strings.TrimPrefix(b.String(), "/"))
+	if err != nil {
+		return nil
+	}
+	return u
+}
+
+// Config selects the provider for each host.
+//
+//	providers:
+//	  - host: gitlab.example.com
+//	    type: gitlab
+//	  - host: artifacts.example.com
+//	    type: http
+//	    base_url: https://artifacts.example.com/sdp
+//	    path: "{{.Repo}}/{{.Version}}/{{.Taskfile}}"
+//	    tags: "{{.Repo}}/tags.json"
+type Config struct {
+	Providers []ProviderConfig `yaml:"providers"`
+}
+
+type ProviderConfig struct {
+	Host    string `yaml:"host"`
+	Type    string `yaml:"type"`
+	RawHost string `yaml:"raw_host,omitempty"`
+	BaseUrl string `yaml:"base_url,omitempty"`
+	Path    string `yaml:"path,omitempty"`
+	Tags    string `yaml:"tags,omitempty"`
+	ApiUrl  string `yaml:"api_url,omitempty"`
+}
+
+// Registry holds the provider for each (configured) host.
+type Registry struct {
+	providers map[string]Provider
+}
+
+// NewRegistry returns a registry with the default providers.
+func NewRegistry() *Registry {
+	return &Registry{
+		providers: map[string]Provider{
+			"github.com":               GitHub{RawHost: "raw.githubusercontent.com", TagRefs: true},
+			"github.boschdevcloud.com": GitHub{RawHost: "raw.github.boschdevcloud.com"},
+		},
+	}
+}
+
+// LoadRegistry returns a registry with the default providers and the providers
+// configured in the file (if any).
+func LoadRegistry(file string) (*Registry, error) {
+	r := NewRegistry()
+	if file == "" {
+		return r, nil
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading provider config: %v", err)
+	}
+	var config Config
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("Error parsing provider config: %v", err)
+	}
+	for _, pc := range config.Providers {
+		p, err := newProvider(pc)
+		if err != nil {
+			return nil, err
+		}
+		r.Register(pc.Host, p)
+	}
+	return r, nil
+}
+
+func newProvider(pc ProviderConfig) (Provider, error) {
+	if pc.Host == "" {
+		return nil, fmt.Errorf("provider config: host not specified")
+	}
+	switch pc.Type {
+	case "github":
+		rawHost := pc.RawHost
+		if rawHost == "" {
+			rawHost = "raw." + pc.Host
+		}
+		return GitHub{RawHost: rawHost, TagRefs: pc.Host == "github.com", ApiUrl: pc.ApiUrl}, nil
+	case "gitlab":
+		return GitLab{ApiUrl: pc.ApiUrl}, nil
+	case "gitea":
+		return Gitea{ApiUrl: pc.ApiUrl}, nil
+	case "http":
+		return NewHTTP(pc.BaseUrl, pc.Path, pc.Tags)
+	default:
+		return nil, fmt.Errorf("provider config: unsupported type '%s' (host %s)", pc.Type, pc.Host)
+	}
+}
+
+func (r *Registry) Register(host string, p Provider) {
+	r.providers[host] = p
+}
+
+// Lookup returns the provider for the host, or nil if the host has no provider.
+func (r *Registry) Lookup(host string) Provider {
+	if r == nil {
+		r = NewRegistry()
+	}
+	return r.providers[host]
+}
+
+// ParseUrl parses a repo URL which may contain template references (e.g.
+// https://{{.GHE_TOKEN}}@github.com/org/repo).
+func ParseUrl(u string) (*url.URL, error) {
+	u = strings.ReplaceAll(u, `{`, `%7B`)
+	u = strings.ReplaceAll(u, `}`, `%7D`)
+	return url.Parse(u)
+}
+
+func unescapeUrl(u *url.URL) string {
+	s := u.String()
+	s = strings.ReplaceAll(s, `%7B`, `{`)
+	s = strings.ReplaceAll(s, `%7D`, `}`)
+	return s
+}
+
+// TaskfileURLs returns the candidate Taskfile URLs for the repo (one for each
+// of the TaskfileVariants), or nil if the repo is not supported by a provider.
+func (r *Registry) TaskfileURLs(repoUrl string, version string) []string {
+	u, err := ParseUrl(repoUrl)
+	if err != nil {
+		return nil
+	}
+	p := r.Lookup(u.Host)
+	if p == nil {
+		slog.Debug("Unsupported metadata url (no provider)", "url", repoUrl)
+		return nil
+	}
+	urls := []string{}
+	for _, name := range TaskfileVariants {
+		taskfileUrl := p.TaskfileURL(u, version, name)
+		if taskfileUrl == nil {
+			return nil
+		}
+		urls = append(urls, unescapeUrl(taskfileUrl))
+	}
+	return urls
+}
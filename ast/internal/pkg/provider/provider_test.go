@@ -0,0 +1,217 @@
+// Copyright 2025 Robert Bosch GmbH
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package provider
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProvider_taskfileURL(t *testing.T) {
+	httpProvider, err := NewHTTP("https://artifacts.example.com/sdp/", "{{.Repo}}/{{.Version}}/{{.Taskfile}}", "")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		provider Provider
+		repo     string
+		want     string
+	}{
+		{
+			name:     "github",
+			provider: GitHub{RawHost: "raw.githubusercontent.com", TagRefs: true},
+			repo:     "https://github.com/boschglobal/dse.modelc",
+			want:     "https://raw.githubusercontent.com/boschglobal/dse.modelc/refs/tags/v1.0.0/Taskfile.yml",
+		},
+		{
+			name:     "github enterprise",
+			provider: GitHub{RawHost: "raw.github.example.com"},
+			repo:     "https://{{.GHE_TOKEN}}@github.example.com/org/repo",
+			want:     "https://{{.GHE_TOKEN}}@raw.github.example.com/org/repo/v1.0.0/Taskfile.yml",
+		},
+		{
+			name:     "github asset link",
+			provider: GitHub{RawHost: "raw.githubusercontent.com", TagRefs: true},
+			repo:     "https://github.com/boschglobal/dse.modelc/releases/download/v1.0.0/file.zip",
+		},
+		{
+			name:     "gitlab",
+			provider: GitLab{},
+			repo:     "https://gitlab.example.com/group/subgroup/repo.git",
+			want:     "https://gitlab.example.com/group/subgroup/repo/-/raw/v1.0.0/Taskfile.yml",
+		},
+		{
+			name:     "gitea",
+			provider: Gitea{},
+			repo:     "https://gitea.example.com/org/repo",
+			want:     "https://gitea.example.com/org/repo/raw/tag/v1.0.0/Taskfile.yml",
+		},
+		{
+			name:     "http",
+			provider: httpProvider,
+			repo:     "https://models.example.com/org/repo",
+			want:     "https://artifacts.example.com/sdp/repo/v1.0.0/Taskfile.yml",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := ParseUrl(tt.repo)
+			require.NoError(t, err)
+			got := tt.provider.TaskfileURL(u, "v1.0.0", "Taskfile.yml")
+			if tt.want == "" {
+				assert.Nil(t, got)
+				return
+			}
+			require.NotNil(t, got)
+			assert.Equal(t, tt.want, unescapeUrl(got))
+		})
+	}
+}
+
+func TestRegistry_config(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/org/repo/raw/tag/v1.0.0/Taskfile.yml" {
+			w.Write([]byte("version: '3'\n"))
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	u, err := ParseUrl(server.URL)
+	require.NoError(t, err)
+	config := filepath.Join(t.TempDir(), "providers.yaml")
+	require.NoError(t, os.WriteFile(config, []byte(`---
+providers:
+  - host: `+u.Host+`
+    type: gitea
+  - host: gitlab.example.com
+    type: gitlab
+`), 0644))
+
+	r, err := LoadRegistry(config)
+	require.NoError(t, err)
+	assert.IsType(t, GitHub{}, r.Lookup("github.com"))
+	assert.IsType(t, GitLab{}, r.Lookup("gitlab.example.com"))
+	assert.Nil(t, r.Lookup("unknown.example.com"))
+
+	urls := r.TaskfileURLs(server.URL+"/org/repo", "v1.0.0")
+	require.Len(t, urls, len(TaskfileVariants))
+	found := []string{}
+	for _, taskfileUrl := range urls {
+		resp, err := http.Get(taskfileUrl)
+		require.NoError(t, err)
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusOK {
+			found = append(found, taskfileUrl)
+		}
+	}
+	assert.Equal(t, []string{server.URL + "/org/repo/raw/tag/v1.0.0/Taskfile.yml"}, found)
+}
+
+func TestRegistry_configInvalid(t *testing.T) {
+	config := filepath.Join(t.TempDir(), "providers.yaml")
+	require.NoError(t, os.WriteFile(config, []byte("providers:\n  - host: example.com\n    type: svn\n"), 0644))
+	_, err := LoadRegistry(config)
+	assert.ErrorContains(t, err, "unsupported type 'svn'")
+}
+
+func TestProvider_tagURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider TagLister
+		repo     string
+		want     string
+	}{
+		{
+			name:     "github",
+			provider: GitHub{RawHost: "raw.githubusercontent.com", TagRefs: true},
+			repo:     "https://github.com/boschglobal/dse.modelc",
+			want:     "https://api.github.com/repos/boschglobal/dse.modelc/tags?per_page=100&page=2",
+		},
+		{
+			name:     "github enterprise",
+			provider: GitHub{RawHost: "raw.github.example.com"},
+			repo:     "https://github.example.com/org/repo",
+			want:     "https://github.example.com/api/v3/repos/org/repo/tags?per_page=100&page=2",
+		},
+		{
+			name:     "gitlab",
+			provider: GitLab{},
+			repo:     "https://gitlab.example.com/group/subgroup/repo.git",
+			want:     "https://gitlab.example.com/api/v4/projects/group%2Fsubgroup%2Frepo/repository/tags?per_page=100&page=2",
+		},
+		{
+			name:     "gitea",
+			provider: Gitea{ApiUrl: "https://api.example.com/v1/"},
+			repo:     "https://gitea.example.com/org/repo",
+			want:     "https://api.example.com/v1/repos/org/repo/tags?limit=50&page=2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := ParseUrl(tt.repo)
+			require.NoError(t, err)
+			got := tt.provider.TagURL(u, 2)
+			require.NotNil(t, got)
+			assert.Equal(t, tt.want, got.String())
+		})
+	}
+}
+
+func TestRegistry_tags(t *testing.T) {
+	t.Setenv("TAG_TOKEN", "secret")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "token secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path + "?" + r.URL.RawQuery {
+		case "/api/repos/org/repo/tags?per_page=100&page=1":
+			w.Write([]byte(`[{"name": "v1.2.0"}, {"name": "v1.1.0"}]`))
+		case "/api/repos/org/repo/tags?per_page=100&page=2":
+			w.Write([]byte(`[{"name": "v1.0.0"}]`))
+		case "/tags/repo.json?":
+			w.Write([]byte(`["v2.0.0", "v2.1.0"]`))
+		default:
+			w.Write([]byte(`[]`))
+		}
+	}))
+	defer server.Close()
+
+	u, err := ParseUrl(server.URL)
+	require.NoError(t, err)
+	config := filepath.Join(t.TempDir(), "providers.yaml")
+	require.NoError(t, os.WriteFile(config, []byte(`---
+providers:
+  - host: `+u.Host+`
+    type: github
+    api_url: `+server.URL+`/api
+  - host: models.example.com
+    type: http
+    base_url: `+server.URL+`
+    tags: "tags/{{.Repo}}.json"
+`), 0644))
+	r, err := LoadRegistry(config)
+	require.NoError(t, err)
+
+	repoUrl := "http://{{.TAG_TOKEN}}@" + u.Host + "/org/repo"
+	tags, err := r.Tags(nil, repoUrl)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"v1.2.0", "v1.1.0", "v1.0.0"}, tags)
+
+	tags, err = r.Tags(nil, "https://{{.TAG_TOKEN}}@models.example.com/org/repo")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"v2.0.0", "v2.1.0"}, tags)
+
+	_, err = r.Tags(nil, "https://unknown.example.com/org/repo")
+	assert.ErrorContains(t, err, "tag listing not supported")
+}
@@ -0,0 +1,217 @@
+// Copyright 2025 Robert Bosch GmbH
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package provider
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// maxTagPages limits the number of pages requested when listing tags.
+const maxTagPages = 10
+
+// TagLister is implemented by providers which can list the (version) tags of
+// a repo.
+type TagLister interface {
+	// TagURL returns the URL of each page of the tag list, the page numbers
+	// start at 1. Returns nil if the repo URL is not supported.
+	TagURL(repo *url.URL, page int) *url.URL
+}
+
+// apiUrl returns the API base URL, if configured, otherwise the default.
+func apiUrl(configured string, def string) *url.URL {
+	if configured == "" {
+		configured = def
+	}
+	u, err := url.Parse(strings.TrimSuffix(configured, "/"))
+	if err != nil {
+		return nil
+	}
+	return u
+}
+
+func (p GitHub) TagURL(repo *url.URL, page int) *url.URL {
+	owner, repoName, ok := repoPath(repo)
+	if !ok {
+		return nil
+	}
+	def := fmt.Sprintf("%s://%s/api/v3", repo.Scheme, repo.Host)
+	if repo.Host == "github.com" {
+		def = "https://api.github.com"
+	}
+	u := apiUrl(p.ApiUrl, def)
+	if u == nil {
+		return nil
+	}
+	u = u.JoinPath("repos", owner, repoName, "tags")
+	u.RawQuery = fmt.Sprintf("per_page=100&page=%d", page)
+	return u
+}
+
+func (p GitLab) TagURL(repo *url.URL, page int) *url.URL {
+	repoName := strings.TrimSuffix(strings.Trim(repo.Path, "/"), ".git")
+	if repoName == "" || strings.Contains(repoName, "/-/") {
+		return nil
+	}
+	u := apiUrl(p.ApiUrl, fmt.Sprintf("%s://%s/api/v4", repo.Scheme, repo.Host))
+	if u == nil {
+		return nil
+	}
+	// The project path is URL encoded (i.e. group%2Fsubgroup%2Frepo).
+	u, err := url.Parse(u.String() + "/projects/" + url.PathEscape(repoName) + "/repository/tags")
+	if err != nil {
+		return nil
+	}
+	u.RawQuery = fmt.Sprintf("per_page=100&page=%d", page)
+	return u
+}
+
+func (p Gitea) TagURL(repo *url.URL, page int) *url.URL {
+	owner, repoName, ok := repoPath(repo)
+	if !ok {
+		return nil
+	}
+	u := apiUrl(p.ApiUrl, fmt.Sprintf("%s://%s/api/v1", repo.Scheme, repo.Host))
+	if u == nil {
+		return nil
+	}
+	u = u.JoinPath("repos", owner, repoName, "tags")
+	u.RawQuery = fmt.Sprintf("limit=50&page=%d", page)
+	return u
+}
+
+// TagURL of the HTTP provider is calculated from the tags path template, which
+// may reference: Host, Path, Owner, Repo and Page. The tag list is not paged
+// unless the template references Page.
+func (p HTTP) TagURL(repo *url.URL, page int) *url.URL {
+	if p.Tags == nil {
+		return nil
+	}
+	if page > 1 && !strings.Contains(p.Tags.Root.String(), ".Page") {
+		return nil
+	}
+	repoName := strings.Trim(repo.Path, "/")
+	owner, _, _ := strings.Cut(repoName, "/")
+	var b bytes.Buffer
+	err := p.Tags.Execute(&b, map[string]any{
+		"Host":  repo.Host,
+		"Path":  repoName,
+		"Owner": owner,
+		"Repo":  repoName[strings.LastIndex(repoName, "/")+1:],
+		"Page":  page,
+	})
+	if err != nil {
+		return nil
+	}
+	base := p.BaseUrl
+	if base == "" {
+		base = fmt.Sprintf("%s://%s", repo.Scheme, repo.Host)
+	}
+	u, err := url.Parse(strings.TrimSuffix(base, "/") + "/" + strings.TrimPrefix(b.String(), "/"))
+	if err != nil {
+		return nil
+	}
+	return u
+}
+
+var tokenVarRegex = regexp.MustCompile(`\{\{\s*\.(\w+)\s*\}\}`)
+
+// repoToken returns the access token embedded in the repo URL (e.g.
+// https://{{.GHE_TOKEN}}@github.com/org/repo), template references are
+// expanded from the environment.
+func repoToken(repo *url.URL) string {
+	if repo.User == nil {
+		return ""
+	}
+	token, _ := url.PathUnescape(repo.User.Username())
+	return tokenVarRegex.ReplaceAllStringFunc(token, func(match string) string {
+		return os.Getenv(tokenVarRegex.FindStringSubmatch(match)[1])
+	})
+}
+
+// Tags lists the tags of a repo using the API of the repo host provider.
+func (r *Registry) Tags(client *http.Client, repoUrl string) ([]string, error) {
+	u, err := ParseUrl(repoUrl)
+	if err != nil {
+		return nil, fmt.Errorf("invalid repo url (%s): %v", repoUrl, err)
+	}
+	lister, ok := r.Lookup(u.Host).(TagLister)
+	if !ok {
+		return nil, fmt.Errorf("tag listing not supported for host %s", u.Host)
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	token := repoToken(u)
+	tags := []string{}
+	for page := 1; page <= maxTagPages; page++ {
+		tagUrl := lister.TagURL(u, page)
+		if tagUrl == nil {
+			if page == 1 {
+				return nil, fmt.Errorf("tag listing not supported for repo %s", repoUrl)
+			}
+			break
+		}
+		pageTags, err := fetchTags(client, tagUrl.String(), token)
+		if err != nil {
+			return nil, err
+		}
+		if len(pageTags) == 0 {
+			break
+		}
+		tags = append(tags, pageTags...)
+	}
+	return tags, nil
+}
+
+// fetchTags fetches a page of tags, the response is a JSON list of either
+// strings or objects with a name field.
+func fetchTags(client *http.Client, tagUrl string, token string) ([]string, error) {
+	req, err := http.NewRequest(http.MethodGet, tagUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "token "+token)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("tag list request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("tag list request failed: %s (%s)", resp.Status, tagUrl)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("tag list request failed: %v", err)
+	}
+	var items []json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, fmt.Errorf("invalid tag list (%s): %v", tagUrl, err)
+	}
+	tags := []string{}
+	for _, item := range items {
+		var name string
+		if err := json.Unmarshal(item, &name); err == nil {
+			tags = append(tags, name)
+			continue
+		}
+		var tag struct {
+			Name string `json:"name"`
+		}
+		if err := json.Unmarshal(item, &tag); err == nil && tag.Name != "" {
+			tags = append(tags, tag.Name)
+		}
+	}
+	return tags, nil
+}
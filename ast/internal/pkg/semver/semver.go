@@ -0,0 +1,192 @@
+// Copyright 2025 Robert Bosch GmbH
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package semver
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Version is a semantic version parsed from a tag (e.g. v2.3.7 or 2.3.7).
+type Version struct {
+	Major, Minor, Patch int
+	Pre                 string
+	Tag                 string
+}
+
+var versionRegex = regexp.MustCompile(`^v?(\d+)(?:\.(\d+))?(?:\.(\d+))?(?:-([0-9A-Za-z.-]+))?(?:\+[0-9A-Za-z.-]+)?$`)
+
+// Parse parses a version tag, missing minor/patch parts are set to 0.
+func Parse(tag string) (Version, bool) {
+	m := versionRegex.FindStringSubmatch(tag)
+	if m == nil {
+		return Version{}, false
+	}
+	v := Version{Pre: m[4], Tag: tag}
+	v.Major, _ = strconv.Atoi(m[1])
+	v.Minor, _ = strconv.Atoi(m[2])
+	v.Patch, _ = strconv.Atoi(m[3])
+	return v, true
+}
+
+func (v Version) String() string {
+	if v.Tag != "" {
+		return v.Tag
+	}
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// Compare returns -1, 0 or +1 (pre-release versions sort before the release).
+func Compare(a, b Version) int {
+	for _, d := range []int{a.Major - b.Major, a.Minor - b.Minor, a.Patch - b.Patch} {
+		if d < 0 {
+			return -1
+		} else if d > 0 {
+			return 1
+		}
+	}
+	switch {
+	case a.Pre == b.Pre:
+		return 0
+	case a.Pre == "":
+		return 1
+	case b.Pre == "":
+		return -1
+	}
+	return strings.Compare(a.Pre, b.Pre)
+}
+
+type comparator struct {
+	op      string
+	version Version
+}
+
+func (c comparator) check(v Version) bool {
+	r := Compare(v, c.version)
+	switch c.op {
+	case ">":
+		return r > 0
+	case ">=":
+		return r >= 0
+	case "<":
+		return r < 0
+	case "<=":
+		return r <= 0
+	default:
+		return r == 0
+	}
+}
+
+// Constraint is a version range, all comparators must match.
+type Constraint struct {
+	text        string
+	comparators []comparator
+}
+
+func (c Constraint) String() string {
+	return c.text
+}
+
+var constraintOpRegex = regexp.MustCompile(`^(>=|<=|>|<|=|~|\^)?(.+)$`)
+
+// IsConstraint returns true if the version is a range (or latest) rather than
+// an exact version tag.
+func IsConstraint(version string) bool {
+	version = strings.TrimSpace(version)
+	if version == "latest" {
+		return true
+	}
+	return strings.ContainsAny(version, "~^<>= ")
+}
+
+// ParseConstraint parses a version range:
+//
+//	latest       the highest release version
+//	~2.3         >=2.3.0 <2.4.0  (~2 is >=2.0.0 <3.0.0)
+//	^1.2         >=1.2.0 <2.0.0  (^0.2 is >=0.2.0 <0.3.0)
+//	>=1.1 <2     comparators (>, >=, <, <=, =) separated by spaces
+func ParseConstraint(text string) (*Constraint, error) {
+	c := &Constraint{text: strings.TrimSpace(text)}
+	if c.text == "latest" {
+		return c, nil
+	}
+	fields := strings.Fields(c.text)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("empty version constraint")
+	}
+	for _, field := range fields {
+		m := constraintOpRegex.FindStringSubmatch(field)
+		parts := strings.Count(strings.SplitN(strings.TrimPrefix(m[2], "v"), "-", 2)[0], ".") + 1
+		v, ok := Parse(m[2])
+		if !ok {
+			return nil, fmt.Errorf("invalid version constraint: %s", text)
+		}
+		switch m[1] {
+		case "~":
+			upper := Version{Major: v.Major + 1}
+			if parts > 1 {
+				upper = Version{Major: v.Major, Minor: v.Minor + 1}
+			}
+			c.comparators = append(c.comparators, comparator{">=", v}, comparator{"<", upper})
+		case "^":
+			var upper Version
+			switch {
+			case v.Major > 0 || parts == 1:
+				upper = Version{Major: v.Major + 1}
+			case v.Minor > 0 || parts == 2:
+				upper = Version{Minor: v.Minor + 1}
+			default:
+				upper = Version{Patch: v.Patch + 1}
+			}
+			c.comparators = append(c.comparators, comparator{">=", v}, comparator{"<", upper})
+		case "":
+			c.comparators = append(c.comparators, comparator{"=", v})
+		default:
+			c.comparators = append(c.comparators, comparator{m[1], v})
+		}
+	}
+	return c, nil
+}
+
+// Check returns true if the version satisfies the constraint. Pre-release
+// versions never satisfy a constraint.
+func (c Constraint) Check(v Version) bool {
+	if v.Pre != "" {
+		return false
+	}
+	for _, comp := range c.comparators {
+		if !comp.check(v) {
+			return false
+		}
+	}
+	return true
+}
+
+// Versions parses a list of tags, tags which are not versions are ignored.
+// The returned list is sorted (ascending).
+func Versions(tags []string) []Version {
+	versions := []Version{}
+	for _, tag := range tags {
+		if v, ok := Parse(tag); ok {
+			versions = append(versions, v)
+		}
+	}
+	slices.SortStableFunc(versions, Compare)
+	return versions
+}
+
+// Best returns the tag of the highest version satisfying the constraint.
+func (c Constraint) Best(tags []string) (string, bool) {
+	versions := Versions(tags)
+	for i := len(versions) - 1; i >= 0; i-- {
+		if c.Check(versions[i]) {
+			return versions[i].Tag, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,72 @@
+// Copyright 2025 Robert Bosch GmbH
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package semver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var tags = []string{
+	"v0.1.0", "v0.2.3", "v0.2.9", "v1.0.0", "v1.1.0", "v1.2.0", "v1.2.5",
+	"v1.3.0-rc1", "v1.10.2", "v2.0.0", "v2.3.0", "v2.3.4", "v2.4.0",
+	"3.0.0-beta", "nightly",
+}
+
+func TestIsConstraint(t *testing.T) {
+	for _, v := range []string{"latest", "~2.3", "^1.2", ">=1.1 <2", "=v1.0.0"} {
+		assert.True(t, IsConstraint(v), v)
+	}
+	for _, v := range []string{"v1.0.0", "1.2.3", "main", ""} {
+		assert.False(t, IsConstraint(v), v)
+	}
+}
+
+func TestConstraint_best(t *testing.T) {
+	tests := []struct {
+		constraint string
+		want       string
+	}{
+		{"latest", "v2.4.0"},
+		{"~2.3", "v2.3.4"},
+		{"~2", "v2.4.0"},
+		{"~1.2.0", "v1.2.5"},
+		{"^1.2", "v1.10.2"},
+		{"^0.2", "v0.2.9"},
+		{"^0.2.3", "v0.2.9"},
+		{"^0.1", "v0.1.0"},
+		{">=1.1 <2", "v1.10.2"},
+		{">1.0 <=v1.2.0", "v1.2.0"},
+		{"=1.1.0", "v1.1.0"},
+		{"~5", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.constraint, func(t *testing.T) {
+			c, err := ParseConstraint(tt.constraint)
+			require.NoError(t, err)
+			got, ok := c.Best(tags)
+			assert.Equal(t, tt.want != "", ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestConstraint_invalid(t *testing.T) {
+	for _, v := range []string{"", "~x", ">=1.1 <two"} {
+		_, err := ParseConstraint(v)
+		assert.Error(t, err, v)
+	}
+}
+
+func TestVersions(t *testing.T) {
+	versions := Versions([]string{"v1.10.0", "v1.2.0", "v1.2.0-rc1", "main", "v1.9.0"})
+	got := []string{}
+	for _, v := range versions {
+		got = append(got, v.Tag)
+	}
+	assert.Equal(t, []string{"v1.2.0-rc1", "v1.2.0", "v1.9.0", "v1.10.0"}, got)
+}
@@ -0,0 +1,67 @@
+// Copyright 2025 Robert Bosch GmbH
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package source
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/boschglobal/dse.schemas/code/go/dse/ast"
+)
+
+// Annotation with the location of an AST object (stack or model) in the DSE
+// script, formatted as file:line:column (i.e. openloop.dse:12:1). The location
+// of an item of the object (channel, file or workflow var) is annotated with
+// a key which extends the annotation with the path of the item (see Key).
+const Annotation = "sdp/source"
+
+// Key returns the annotation key for the location of an item of an object,
+// i.e. Key("file", "signal.yaml") returns sdp/source/file/signal.yaml.
+func Key(path ...string) string {
+	return strings.Join(append([]string{Annotation}, path...), "/")
+}
+
+// Location formats a location (line and column are one based).
+func Location(file string, line int, column int) string {
+	return fmt.Sprintf("%s:%d:%d", file, line, column)
+}
+
+// Of returns the location of an object, or of an item of the object (path),
+// from the annotations of the object. When the item has no location, the
+// location of the object is returned ("" if neither is annotated).
+func Of(annotations *ast.Annotations, path ...string) string {
+	if annotations == nil {
+		return ""
+	}
+	if len(path) > 0 {
+		if location, ok := (*annotations)[Key(path...)].(string); ok {
+			return location
+		}
+	}
+	location, _ := (*annotations)[Annotation].(string)
+	return location
+}
+
+// IsAnnotation reports if an annotation key is a source annotation (of the
+// object or of an item).
+func IsAnnotation(key string) bool {
+	return key == Annotation || strings.HasPrefix(key, Annotation+"/")
+}
+
+// IsItemAnnotation reports if an annotation key is the source annotation of
+// an item of an object.
+func IsItemAnnotation(key string) bool {
+	return strings.HasPrefix(key, Annotation+"/")
+}
+
+// Prefix returns the location of an object (or of an item, see Of) as a
+// message prefix (i.e. "openloop.dse:12:1: "), "" when the location is not
+// known.
+func Prefix(annotations *ast.Annotations, path ...string) string {
+	if location := Of(annotations, path...); location != "" {
+		return location + ": "
+	}
+	return ""
+}
@@ -0,0 +1,40 @@
+// Copyright 2025 Robert Bosch GmbH
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package source
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/boschglobal/dse.schemas/code/go/dse/ast"
+)
+
+func TestOf(t *testing.T) {
+	annotations := &ast.Annotations{
+		Annotation:                 Location("openloop.dse", 9, 1),
+		Key("channel", "physical"): "openloop.dse:10:3",
+		Key("workflow", "generate", "var", "NODE"): "openloop.dse:13:5",
+		"replicas": 4,
+	}
+	assert.Equal(t, "openloop.dse:9:1", Of(annotations))
+	assert.Equal(t, "openloop.dse:10:3", Of(annotations, "channel", "physical"))
+	assert.Equal(t, "openloop.dse:13:5", Of(annotations, "workflow", "generate", "var", "NODE"))
+	assert.Equal(t, "openloop.dse:9:1", Of(annotations, "file", "input.csv"))
+	assert.Equal(t, "", Of(nil))
+	assert.Equal(t, "", Of(&ast.Annotations{"replicas": 4}, "channel", "physical"))
+
+	assert.Equal(t, "openloop.dse:10:3: ", Prefix(annotations, "channel", "physical"))
+	assert.Equal(t, "", Prefix(nil))
+}
+
+func TestIsAnnotation(t *testing.T) {
+	assert.True(t, IsAnnotation("sdp/source"))
+	assert.True(t, IsAnnotation("sdp/source/file/input.csv"))
+	assert.False(t, IsAnnotation("sdp/sourcefile"))
+	assert.False(t, IsAnnotation("replicas"))
+	assert.False(t, IsItemAnnotation("sdp/source"))
+	assert.True(t, IsItemAnnotation("sdp/source/channel/physical"))
+}
@@ -0,0 +1,84 @@
+// Copyright 2025 Robert Bosch GmbH
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package testutil provides the fixtures shared by the tests of the commands
+// which fetch uses entries (a file server and a metadata provider config).
+package testutil
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Sha256 returns the SHA-256 (hex) of the content.
+func Sha256(content string) string {
+	hash := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(hash[:])
+}
+
+// FileServer serves files (path: content) and counts the GET requests (and
+// all requests).
+type FileServer struct {
+	*httptest.Server
+	Fetches  atomic.Int32
+	Requests atomic.Int32
+}
+
+// NewFileServer starts a file server, which is closed when the test ends. A
+// TLS server is trusted by the default transport (model packages are
+// downloaded with https) for the duration of the test.
+func NewFileServer(t *testing.T, files map[string]string, tls bool) *FileServer {
+	s := &FileServer{}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.Requests.Add(1)
+		content, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Method == http.MethodGet {
+			s.Fetches.Add(1)
+		}
+		w.Write([]byte(content))
+	})
+	if tls {
+		s.Server = httptest.NewTLSServer(handler)
+		transport := http.DefaultTransport
+		http.DefaultTransport = s.Client().Transport
+		t.Cleanup(func() { http.DefaultTransport = transport })
+	} else {
+		s.Server = httptest.NewServer(handler)
+	}
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Stage changes to a temporary dir and stages the AST (out/ast.yaml, SERVER is
+// replaced with the server URL) and a provider config (providers.yaml, http
+// provider, path: /sdp/<repo>/<version>/<taskfile>).
+func (s *FileServer) Stage(t *testing.T, ast string) {
+	u, err := url.Parse(s.URL)
+	require.NoError(t, err)
+
+	t.Chdir(t.TempDir())
+	require.NoError(t, os.MkdirAll("out", 0755))
+	ast = strings.ReplaceAll(ast, "SERVER", s.URL)
+	require.NoError(t, os.WriteFile("out/ast.yaml", []byte(ast), 0644))
+	require.NoError(t, os.WriteFile("providers.yaml", []byte(`---
+providers:
+  - host: `+u.Host+`
+    type: http
+    base_url: `+s.URL+`/sdp
+    path: "{{.Repo}}/{{.Version}}/{{.Taskfile}}"
+`), 0644))
+}
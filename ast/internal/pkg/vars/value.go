@@ -0,0 +1,123 @@
+// Copyright 2025 Robert Bosch GmbH
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package vars
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var intRegex = regexp.MustCompile(`^-?(0|[1-9][0-9]*)$`)
+var floatRegex = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][-+]?[0-9]+)?$`)
+
+// ParseValue returns the typed value of a DSL value (e.g. an annotation):
+//
+//	10              int
+//	0.5, 1e-3       float64
+//	true, false     bool
+//	{"a": 1}, [1,2] map[string]interface{} or []interface{} (JSON or YAML flow)
+//	"10", '10'      string (quoted, the quotes are removed)
+//
+// Any other value (including template references) is a string.
+func ParseValue(s string) interface{} {
+	s = strings.TrimSpace(s)
+	if unquoted, ok := Unquote(s); ok {
+		return unquoted
+	}
+	switch {
+	case s == "true":
+		return true
+	case s == "false":
+		return false
+	case intRegex.MatchString(s):
+		if i, err := strconv.Atoi(s); err == nil {
+			return i
+		}
+	case floatRegex.MatchString(s):
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f
+		}
+	}
+	if v, ok := structured(s); ok {
+		return v
+	}
+	return s
+}
+
+// StructuredValue returns a structured (JSON or YAML flow) value as JSON,
+// values which are already valid JSON are returned unchanged.
+func StructuredValue(s string) (string, bool) {
+	s = strings.TrimSpace(s)
+	v, ok := structured(s)
+	if !ok {
+		return "", false
+	}
+	if json.Valid([]byte(s)) {
+		return s, true
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", false
+	}
+	return string(b), true
+}
+
+// FormatValue formats a typed value as a DSL value (the inverse of
+// ParseValue). Strings which would be parsed as another type are quoted.
+func FormatValue(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		if s, ok := ParseValue(v).(string); ok && s == v {
+			return v
+		}
+		return `"` + v + `"`
+	case float64:
+		s := strconv.FormatFloat(v, 'g', -1, 64)
+		if !strings.ContainsAny(s, ".eE") {
+			s += ".0"
+		}
+		return s
+	case map[string]interface{}, []interface{}:
+		if b, err := json.Marshal(v); err == nil {
+			return string(b)
+		}
+	}
+	return fmt.Sprint(v)
+}
+
+// Unquote removes the quotes of a quoted ("..." or '...') value.
+func Unquote(s string) (string, bool) {
+	if len(s) < 2 {
+		return "", false
+	}
+	if (s[0] == '"' && s[len(s)-1] == '"') || (s[0] == '\'' && s[len(s)-1] == '\'') {
+		return s[1 : len(s)-1], true
+	}
+	return "", false
+}
+
+func structured(s string) (interface{}, bool) {
+	if !strings.HasPrefix(s, "{") && !strings.HasPrefix(s, "[") {
+		return nil, false
+	}
+	if strings.Contains(s, "{{") {
+		// Template reference, not a value.
+		return nil, false
+	}
+	var v interface{}
+	if err := yaml.Unmarshal([]byte(s), &v); err != nil {
+		return nil, false
+	}
+	switch v.(type) {
+	case map[string]interface{}, []interface{}:
+		return v, true
+	}
+	return nil, false
+}
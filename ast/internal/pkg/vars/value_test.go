@@ -0,0 +1,63 @@
+// Copyright 2025 Robert Bosch GmbH
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package vars
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseValue(t *testing.T) {
+	tests := []struct {
+		value string
+		want  interface{}
+	}{
+		{value: "10", want: 10},
+		{value: "-3", want: -3},
+		{value: "007", want: "007"},
+		{value: "0.5", want: 0.5},
+		{value: "1e-3", want: 0.001},
+		{value: "true", want: true},
+		{value: "False", want: "False"},
+		{value: `{"frame_in":10}`, want: map[string]interface{}{"frame_in": 10}},
+		{value: "{frame_in: 10, names: [a, b]}", want: map[string]interface{}{"frame_in": 10, "names": []interface{}{"a", "b"}}},
+		{value: "[1, 2.5]", want: []interface{}{1, 2.5}},
+		{value: `"10"`, want: "10"},
+		{value: "'true'", want: "true"},
+		{value: `'{"a":1}'`, want: `{"a":1}`},
+		{value: "{{.BUS_ID}}", want: "{{.BUS_ID}}"},
+		{value: "{not: valid", want: "{not: valid"},
+		{value: "redis://localhost:6379", want: "redis://localhost:6379"},
+	}
+	for _, tc := range tests {
+		assert.Equal(t, tc.want, ParseValue(tc.value), "value: %s", tc.value)
+	}
+}
+
+func TestStructuredValue(t *testing.T) {
+	v, ok := StructuredValue(`{"frame_in": 10, "frame_out": 20}`)
+	assert.True(t, ok)
+	assert.Equal(t, `{"frame_in": 10, "frame_out": 20}`, v)
+	v, ok = StructuredValue("{frame_in: 10, names: [a, b]}")
+	assert.True(t, ok)
+	assert.Equal(t, `{"frame_in":10,"names":["a","b"]}`, v)
+	_, ok = StructuredValue("(a|b)")
+	assert.False(t, ok)
+	_, ok = StructuredValue("'{frame_in: 10}'")
+	assert.False(t, ok)
+}
+
+func TestFormatValue(t *testing.T) {
+	for _, value := range []string{
+		"10", "0.5", "1.0", "true", "plain", `"10"`, `"true"`, `"a b"`, `{"frame_in":10}`, "[1,2]",
+	} {
+		formatted := FormatValue(ParseValue(value))
+		assert.Equal(t, ParseValue(value), ParseValue(formatted), "value: %s", value)
+	}
+	assert.Equal(t, `"10"`, FormatValue("10"))
+	assert.Equal(t, "1.0", FormatValue(1.0))
+	assert.Equal(t, `{"frame_in":10}`, FormatValue(map[string]interface{}{"frame_in": 10}))
+}
@@ -0,0 +1,323 @@
+// Copyright 2025 Robert Bosch GmbH
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package vars
+
+import (
+	"flag"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/boschglobal/dse.schemas/code/go/dse/ast"
+
+	"github.com/boschglobal/dse.sdp/ast/internal/pkg/source"
+)
+
+// Runtime variables are provided by the generated Taskfile (or by Task
+// itself) and are always considered to be resolved.
+var Runtime = []string{
+	"PATH",
+	"SIMDIR",
+	"OUTDIR",
+	"OUT",
+	"PROJDIR",
+	"PWD",
+	"MODEL",
+	"REPO",
+	"TAG",
+	"PLATFORM_ARCH",
+	"ENTRYWORKDIR",
+	"CONTAINER_WORKDIR",
+	"CONTAINER_SIMDIR",
+}
+
+// ReplicaRuntime variables are provided by the expansion of model replicas,
+// and are resolved only for models with the replicas annotation.
+var ReplicaRuntime = []string{
+	"INDEX",
+}
+
+// annotationReplicas of a model (see generate.AnnotationReplicas).
+const annotationReplicas = "replicas"
+
+var templateRefRegex = regexp.MustCompile(`\{\{\s*\.(\w+)\s*\}\}`)
+var envRefRegex = regexp.MustCompile(`\$(?:\{([A-Za-z_][A-Za-z0-9_]*)\}|([A-Za-z_][A-Za-z0-9_]*))`)
+var bareEnvRefRegex = regexp.MustCompile(`^\$([A-Za-z_][A-Za-z0-9_]*)$`)
+
+// LookupFunc looks up a variable of the environment (e.g. os.LookupEnv).
+type LookupFunc func(name string) (string, bool)
+
+// Issue is an unresolved (or cyclic) variable reference. Source is the
+// location of the var (or file) in the DSE script, if known.
+type Issue struct {
+	Source  string
+	Context string
+	Message string
+}
+
+func (i Issue) String() string {
+	if i.Source != "" {
+		return fmt.Sprintf("%s: %s: %s", i.Source, i.Context, i.Message)
+	}
+	return fmt.Sprintf("%s: %s", i.Context, i.Message)
+}
+
+// level is one scope of vars (workflow, model or global).
+type level struct {
+	id   string
+	vars []ast.Var
+}
+
+type node struct {
+	level int
+	name  string
+}
+
+type resolver struct {
+	levels  []level
+	env     LookupFunc
+	local   []string // Runtime variables of the model (see ReplicaRuntime).
+	cycles  map[string]bool
+	source  string
+	context string
+	issues  []Issue
+}
+
+// Check resolves the vars of the simulation through their scopes (workflow,
+// then model, then global) and returns an issue for each reference which
+// does not resolve to a var, a runtime variable or a variable of the
+// environment, and for each reference cycle.
+//
+// Checked are the global vars, model vars, model files, workflow vars
+// (model and stack workflows) and the url/path of uses entries (which
+// are expanded from the environment only).
+func Check(simSpec ast.SimulationSpec, env LookupFunc) []Issue {
+	issues := []Issue{}
+	cycles := map[string]bool{}
+	global := level{id: "", vars: deref(simSpec.Vars)}
+
+	// The source location of a var (or file) is taken from the annotations
+	// of the enclosing stack or model, path locates the vars of a workflow.
+	check := func(context string, annotations *ast.Annotations, path []string, levels []level, files []ast.File, local []string) {
+		r := resolver{levels: levels, env: env, cycles: cycles, local: local}
+		for _, v := range levels[0].vars {
+			r.source = source.Of(annotations, append(slices.Clone(path), "var", v.Name)...)
+			r.context = fmt.Sprintf("%svar '%s'", context, v.Name)
+			r.checkVar(node{level: 0, name: v.Name}, []node{})
+		}
+		for _, f := range files {
+			r.source = source.Of(annotations, "file", f.Name)
+			r.context = fmt.Sprintf("%sfile '%s'", context, f.Name)
+			for _, ref := range templateRefs(f.Name + " " + f.Value) {
+				if _, ok := r.lookup(ref, 0, node{level: -1}); !ok && !r.runtime(ref) {
+					r.undefined(ref)
+				}
+			}
+		}
+		issues = append(issues, r.issues...)
+	}
+
+	check("simulation: ", nil, nil, []level{global}, nil, nil)
+	for _, stack := range simSpec.Stacks {
+		for _, model := range stack.Models {
+			modelContext := fmt.Sprintf("model '%s' (stack '%s'): ", model.Name, stack.Name)
+			modelLevel := level{id: model.Name, vars: deref(model.Vars)}
+			var local []string
+			if model.Annotations != nil {
+				if _, ok := (*model.Annotations)[annotationReplicas]; ok {
+					local = ReplicaRuntime
+				}
+			}
+			check(modelContext, model.Annotations, nil, []level{modelLevel, global}, deref(model.Files), local)
+			for _, workflow := range deref(model.Workflows) {
+				check(fmt.Sprintf("%sworkflow '%s': ", modelContext, workflow.Name),
+					model.Annotations, []string{"workflow", workflow.Name},
+					[]level{{id: model.Name + "#" + workflow.Name, vars: deref(workflow.Vars)}, modelLevel, global}, nil, local)
+			}
+		}
+		for _, workflow := range deref(stack.Workflows) {
+			check(fmt.Sprintf("stack '%s': workflow '%s': ", stack.Name, workflow.Name),
+				stack.Annotations, []string{"workflow", workflow.Name},
+				[]level{{id: stack.Name + "#" + workflow.Name, vars: deref(workflow.Vars)}, global}, nil, nil)
+		}
+	}
+
+	r := resolver{env: env}
+	for _, uses := range deref(simSpec.Uses) {
+		r.context = fmt.Sprintf("uses '%s'", uses.Name)
+		values := []string{uses.Url}
+		if uses.Path != nil {
+			values = append(values, *uses.Path)
+		}
+		for _, value := range values {
+			for _, ref := range envRefs(value) {
+				if !r.runtime(ref) {
+					r.issues = append(r.issues, Issue{Context: r.context, Message: fmt.Sprintf("undefined environment variable $%s", ref)})
+				}
+			}
+			for _, ref := range templateRefs(value) {
+				if !r.runtime(ref) {
+					r.undefined(ref)
+				}
+			}
+		}
+	}
+	return append(issues, r.issues...)
+}
+
+// Report prints the issues (see Check) and returns an error if there are any.
+func Report(issues []Issue) error {
+	for _, issue := range issues {
+		fmt.Fprintf(flag.CommandLine.Output(), "%s\n", issue)
+	}
+	if len(issues) > 0 {
+		return fmt.Errorf("strict variable resolution failed: %d issue(s) found", len(issues))
+	}
+	return nil
+}
+
+// checkVar follows the references of a var (depth first), path is the chain
+// of vars which lead to this var.
+func (r *resolver) checkVar(n node, path []node) {
+	if i := slices.Index(path, n); i >= 0 {
+		r.cycle(append(slices.Clone(path[i:]), n))
+		return
+	}
+	v, _ := r.find(n)
+	path = append(path, n)
+	for _, ref := range r.refs(v, n.level) {
+		if found, ok := r.lookup(ref, n.level, n); ok {
+			r.checkVar(found, path)
+			continue
+		}
+		if r.runtime(ref) {
+			continue
+		}
+		if len(path) > 1 {
+			// Reported when checking the referencing var itself.
+			continue
+		}
+		if ref == n.name && n.level == len(r.levels)-1 {
+			// No enclosing scope, the var references itself.
+			r.cycle([]node{n, n})
+		} else {
+			r.undefined(ref)
+		}
+	}
+}
+
+// refs returns the names which a var references.
+func (r *resolver) refs(v ast.Var, l int) []string {
+	if v.Reference != nil {
+		switch *v.Reference {
+		case "uses":
+			return nil
+		case "var":
+			return []string{v.Value}
+		}
+	}
+	if r.levels[l].isWorkflow() {
+		// Generate passes a bare $VAR workflow var as {{.VAR}}.
+		if m := bareEnvRefRegex.FindStringSubmatch(v.Value); m != nil {
+			return []string{m[1]}
+		}
+	}
+	return templateRefs(v.Value)
+}
+
+// lookup searches the scopes, starting at level from, for a var. A var
+// which references its own name refers to the var of an enclosing scope.
+func (r *resolver) lookup(name string, from int, self node) (node, bool) {
+	for l := from; l < len(r.levels); l++ {
+		if l == self.level && name == self.name {
+			continue
+		}
+		for _, v := range r.levels[l].vars {
+			if v.Name == name {
+				return node{level: l, name: name}, true
+			}
+		}
+	}
+	return node{}, false
+}
+
+func (r *resolver) find(n node) (ast.Var, bool) {
+	for _, v := range r.levels[n.level].vars {
+		if v.Name == n.name {
+			return v, true
+		}
+	}
+	return ast.Var{}, false
+}
+
+func (r *resolver) runtime(name string) bool {
+	if slices.Contains(Runtime, name) || slices.Contains(r.local, name) {
+		return true
+	}
+	if r.env != nil {
+		if _, ok := r.env(name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *resolver) undefined(name string) {
+	r.issues = append(r.issues, Issue{Source: r.source, Context: r.context, Message: fmt.Sprintf("undefined reference {{.%s}}", name)})
+}
+
+func (r *resolver) cycle(path []node) {
+	names := []string{}
+	members := []string{}
+	for i, n := range path {
+		names = append(names, n.name)
+		if i > 0 {
+			members = append(members, r.levels[n.level].id+"/"+n.name)
+		}
+	}
+	slices.Sort(members)
+	key := strings.Join(members, ",")
+	if r.cycles[key] {
+		return
+	}
+	r.cycles[key] = true
+	r.issues = append(r.issues, Issue{Source: r.source, Context: r.context, Message: fmt.Sprintf("cyclic reference %s", strings.Join(names, " -> "))})
+}
+
+func (l level) isWorkflow() bool {
+	return strings.Contains(l.id, "#")
+}
+
+func templateRefs(s string) []string {
+	refs := []string{}
+	for _, m := range templateRefRegex.FindAllStringSubmatch(s, -1) {
+		if !slices.Contains(refs, m[1]) {
+			refs = append(refs, m[1])
+		}
+	}
+	return refs
+}
+
+func envRefs(s string) []string {
+	refs := []string{}
+	for _, m := range envRefRegex.FindAllStringSubmatch(s, -1) {
+		name := m[1]
+		if name == "" {
+			name = m[2]
+		}
+		if !slices.Contains(refs, name) {
+			refs = append(refs, name)
+		}
+	}
+	return refs
+}
+
+func deref[T any](p *[]T) []T {
+	if p == nil {
+		return nil
+	}
+	return *p
+}
@@ -0,0 +1,232 @@
+// Copyright 2025 Robert Bosch GmbH
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package vars
+
+import (
+	"bytes"
+	"flag"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/boschglobal/dse.schemas/code/go/dse/ast"
+)
+
+func env(vars map[string]string) LookupFunc {
+	return func(name string) (string, bool) {
+		v, ok := vars[name]
+		return v, ok
+	}
+}
+
+func checkAst(t *testing.T, s string, lookup LookupFunc) []string {
+	var spec ast.SimulationSpec
+	require.NoError(t, yaml.Unmarshal([]byte(s), &spec))
+	issues := []string{}
+	for _, issue := range Check(spec, lookup) {
+		issues = append(issues, issue.String())
+	}
+	return issues
+}
+
+func TestCheck(t *testing.T) {
+	issues := checkAst(t, `---
+uses:
+  - name: dse.fmi
+    url: $REPO_BASE/dse.fmi
+    path: ${FMI_PATH}/fmi.zip
+vars:
+  - name: BUS_ID
+    value: "1"
+  - name: NODE
+    value: node{{.BUS_ID}}
+stacks:
+  - name: default
+    models:
+      - name: linear
+        model: dse.fmi.mcl
+        vars:
+          - name: BUS_ID
+            value: "{{.BUS_ID}}"
+          - name: DIR
+            value: "{{.PATH}}/{{.SIMDIR}}/{{.OUTDIR}}/{{.HOME}}"
+          - name: FMU
+            value: "{{.FMU_DIR}}/{{.NODE}}"
+        files:
+          - name: "{{.DIR}}/signal.yaml"
+            value: "{{.SIGNALS}}"
+        workflows:
+          - name: generate
+            vars:
+              - name: BUS_ID
+                value: "{{.BUS_ID}}"
+              - name: CAN
+                value: BUS_ID
+                reference: var
+              - name: TOKEN
+                value: $TOKEN
+              - name: NETWORK
+                value: NET
+                reference: var
+    workflows:
+      - name: generate
+        vars:
+          - name: NODE
+            value: "{{.NODE}}"
+          - name: FMU
+            value: "{{.FMU}}"
+`, env(map[string]string{"HOME": "/root", "REPO_BASE": "file:///repo"}))
+	assert.Equal(t, []string{
+		"model 'linear' (stack 'default'): var 'FMU': undefined reference {{.FMU_DIR}}",
+		"model 'linear' (stack 'default'): file '{{.DIR}}/signal.yaml': undefined reference {{.SIGNALS}}",
+		"model 'linear' (stack 'default'): workflow 'generate': var 'TOKEN': undefined reference {{.TOKEN}}",
+		"model 'linear' (stack 'default'): workflow 'generate': var 'NETWORK': undefined reference {{.NET}}",
+		"stack 'default': workflow 'generate': var 'FMU': undefined reference {{.FMU}}",
+		"uses 'dse.fmi': undefined environment variable $FMI_PATH",
+	}, issues)
+}
+
+func TestCheck_cycles(t *testing.T) {
+	issues := checkAst(t, `---
+vars:
+  - name: A
+    value: "{{.B}}"
+  - name: B
+    value: "{{.C}}/{{.A}}"
+  - name: C
+    value: "{{.PATH}}"
+  - name: D
+    value: "{{.D}}"
+stacks:
+  - name: default
+    models:
+      - name: linear
+        model: dse.fmi.mcl
+        vars:
+          - name: X
+            value: "{{.A}}"
+          - name: Y
+            value: "{{.Z}}"
+          - name: Z
+            value: "{{.Y}}"
+`, nil)
+	assert.Equal(t, []string{
+		"simulation: var 'A': cyclic reference A -> B -> A",
+		"simulation: var 'D': cyclic reference D -> D",
+		"model 'linear' (stack 'default'): var 'Y': cyclic reference Y -> Z -> Y",
+	}, issues)
+}
+
+func TestCheck_resolved(t *testing.T) {
+	issues := checkAst(t, `---
+vars:
+  - name: BUS_ID
+    value: "1"
+stacks:
+  - name: default
+    models:
+      - name: net
+        model: dse.network
+        vars:
+          - name: MIMETYPE
+            value: application/x-automotive-bus;bus=can;bus_id=1
+            reference: network
+            networktype: mimetype
+          - name: SIGNALGROUP
+            value: signalgroup
+            reference: uses
+        workflows:
+          - name: generate-network
+            vars:
+              - name: BUS
+                value: BUS_ID
+                reference: var
+`, nil)
+	assert.Empty(t, issues)
+}
+
+func TestCheck_replicas(t *testing.T) {
+	issues := checkAst(t, `---
+stacks:
+  - name: default
+    models:
+      - name: node
+        model: dse.modelc.csv
+        annotations:
+          replicas: 3
+        vars:
+          - name: NODE_ID
+            value: "{{.INDEX}}"
+        workflows:
+          - name: generate
+            vars:
+              - name: NODE
+                value: "{{.INDEX}}"
+      - name: gateway
+        model: Gateway
+        vars:
+          - name: NODE_ID
+            value: "{{.INDEX}}"
+`, nil)
+	// INDEX is only resolved for models with replicas.
+	assert.Equal(t, []string{
+		"model 'gateway' (stack 'default'): var 'NODE_ID': undefined reference {{.INDEX}}",
+	}, issues)
+}
+
+func TestCheck_source(t *testing.T) {
+	issues := checkAst(t, `---
+stacks:
+  - name: default
+    annotations:
+      sdp/source: openloop.dse:8:1
+    models:
+      - name: linear
+        model: dse.fmi.mcl
+        annotations:
+          sdp/source: openloop.dse:9:1
+          sdp/source/file/signal.yaml: openloop.dse:11:3
+          sdp/source/workflow/generate/var/NETWORK: openloop.dse:14:5
+        vars:
+          - name: FMU
+            value: "{{.FMU_DIR}}"
+        files:
+          - name: signal.yaml
+            value: "{{.SIGNALS}}"
+        workflows:
+          - name: generate
+            vars:
+              - name: NETWORK
+                value: "{{.NET}}"
+    workflows:
+      - name: generate
+        vars:
+          - name: NODE
+            value: "{{.NODE}}"
+`, nil)
+	assert.Equal(t, []string{
+		"openloop.dse:9:1: model 'linear' (stack 'default'): var 'FMU': undefined reference {{.FMU_DIR}}",
+		"openloop.dse:11:3: model 'linear' (stack 'default'): file 'signal.yaml': undefined reference {{.SIGNALS}}",
+		"openloop.dse:14:5: model 'linear' (stack 'default'): workflow 'generate': var 'NETWORK': undefined reference {{.NET}}",
+		"openloop.dse:8:1: stack 'default': workflow 'generate': var 'NODE': undefined reference {{.NODE}}",
+	}, issues)
+}
+
+func TestReport(t *testing.T) {
+	var buf bytes.Buffer
+	flag.CommandLine.SetOutput(&buf)
+	t.Cleanup(func() { flag.CommandLine.SetOutput(nil) })
+
+	assert.NoError(t, Report([]Issue{}))
+	err := Report([]Issue{
+		{Context: "simulation: var 'A'", Message: "undefined reference {{.B}}"},
+		{Source: "sim.dse:3:1", Context: "simulation: var 'C'", Message: "undefined reference {{.D}}"},
+	})
+	require.Error(t, err)
+	assert.Equal(t, "strict variable resolution failed: 2 issue(s) found", err.Error())
+	assert.Equal(t, "simulation: var 'A': undefined reference {{.B}}\nsim.dse:3:1: simulation: var 'C': undefined reference {{.D}}\n", buf.String())
+}
@@ -176,7 +176,7 @@ export const Uses = createToken({
 
 function matchUseItem(text: string) {
   const useItemPattern =
-    /^[ \t]*(\S+)([ ]+(?:(?:https\:\/\/\S+)|(?:\S+\.\S+)|(?:\S+\/\S+)))([ ]+v\d+(?:\.\d+)*)?(?:[ ]+(path\=\S+))?(?:[ ]+(user\=\S+))?(?:[ ]+(token\=\S+))?\s*(?:\#.*)?$/;
+    /^[ \t]*(\S+)([ ]+(?:(?:https\:\/\/\S+)|(?:\S+\.\S+)|(?:\S+\/\S+)))([ ]+(?:v\d+(?:\.\d+)*|latest|[~^]v?\d+(?:\.\d+)*|(?:[<>]=?|=)v?\d+(?:\.\d+)*|"[^"]*"))?(?:[ ]+(path\=\S+))?(?:[ ]+(user\=\S+))?(?:[ ]+(token\=\S+))?(?:[ ]+(sha256\=\S+))?(?:[ ]+(token\=\S+))?\s*(?:\#.*)?$/;
   const execResult = useItemPattern.exec(text) as CustomRegExpExecArray;
   if (execResult !== null) {
     const useItem = execResult[1];
@@ -196,6 +196,13 @@ function matchUseItem(text: string) {
     let token = "";
     if (execResult[6] !== undefined) {
       token = execResult[6];
+    } else if (execResult[8] !== undefined) {
+      // The token may also follow the sha256.
+      token = execResult[8];
+    }
+    let sha256 = "";
+    if (execResult[7] !== undefined) {
+      sha256 = execResult[7];
     }
 
     execResult.payload = {
@@ -208,7 +215,7 @@ function matchUseItem(text: string) {
         token_type: "link",
       },
       version: {
-        value: version.trim(),
+        value: version.trim().replace(/^"(.*)"$/, "$1"),
         token_type: "version",
       },
       path: {
@@ -223,6 +230,10 @@ function matchUseItem(text: string) {
         value: token.replace("token=", "").trim(),
         token_type: "token",
       },
+      sha256: {
+        value: sha256.replace("sha256=", "").trim(),
+        token_type: "sha256",
+      },
     };
   }
   return execResult;
@@ -2,6 +2,9 @@ package kind
 
 import (
 	"context"
+	"fmt"
+	"log/slog"
+	"strings"
 
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
 
@@ -11,6 +14,43 @@ import (
 
 type SimulationSpec ast.SimulationSpec
 
+// sourceAnnotation is set by the AST convert command with the location of an
+// object (stack or model) in the DSE script (i.e. openloop.dse:12:1), the key
+// is extended with the path of an item of the object (i.e. the annotation
+// sdp/source/channel/physical of a model).
+const sourceAnnotation = "sdp/source"
+
+// sourceLocation returns the location of an object, or of an item of the
+// object, falling back to the location of the object ("" if not annotated).
+func sourceLocation(annotations map[string]interface{}, path ...string) string {
+	if len(path) > 0 {
+		if location, ok := annotations[sourceAnnotation+"/"+strings.Join(path, "/")].(string); ok {
+			return location
+		}
+	}
+	location, _ := annotations[sourceAnnotation].(string)
+	return location
+}
+
+func astAnnotations(annotations *ast.Annotations) map[string]interface{} {
+	if annotations == nil {
+		return nil
+	}
+	return *annotations
+}
+
+// importError logs an import error citing the location of the object in the
+// DSE script.
+func importError(location string, object string, err error) {
+	if err == nil {
+		return
+	}
+	if location != "" {
+		object = location + ": " + object
+	}
+	slog.Error(fmt.Sprintf("%s: import failed: %v", object, err))
+}
+
 func newSimulationSpec() *SimulationSpec {
 	return new(SimulationSpec)
 }
@@ -22,7 +62,12 @@ func (s *SimulationSpec) MergeGraph(ctx context.Context, session neo4j.SessionWi
 	for _, channel := range s.Channels {
 		channelMatchProps := map[string]string{"channel_name": channel.Name}
 		channelNodeProps := map[string]any{}
-		channelID, _ := graph.NodeExt(ctx, session, []string{"Ast:SimulationChannel"}, channelMatchProps, channelNodeProps)
+		location := sourceLocation(kd.Metadata.Annotations, "channel", channel.Name)
+		if location != "" {
+			channelNodeProps["source"] = location
+		}
+		channelID, err := graph.NodeExt(ctx, session, []string{"Ast:SimulationChannel"}, channelMatchProps, channelNodeProps)
+		importError(location, fmt.Sprintf("channel '%s'", channel.Name), err)
 		graph.Relation(ctx, session, simulationID, channelID, []string{"Has"})
 
 		// CHANNEL -[HAS]-> NETWORKS
@@ -46,7 +91,12 @@ func (s *SimulationSpec) MergeGraph(ctx context.Context, session neo4j.SessionWi
 		if stack.Stacked != nil {
 			stackNodeProps["stacked"] = *stack.Stacked
 		}
-		stackID, _ := graph.NodeExt(ctx, session, []string{"Ast:Stack"}, stackMatchProps, stackNodeProps)
+		stackAnnotations := astAnnotations(stack.Annotations)
+		if location := sourceLocation(stackAnnotations); location != "" {
+			stackNodeProps["source"] = location
+		}
+		stackID, err := graph.NodeExt(ctx, session, []string{"Ast:Stack"}, stackMatchProps, stackNodeProps)
+		importError(sourceLocation(stackAnnotations), fmt.Sprintf("stack '%s'", stack.Name), err)
 		graph.Relation(ctx, session, simulationID, stackID, []string{"Has"})
 
 		// STACKS -[HAS]-> ENV
@@ -69,7 +119,12 @@ func (s *SimulationSpec) MergeGraph(ctx context.Context, session neo4j.SessionWi
 				"arch":  model.Arch,
 				"model": model.Model,
 			}
-			modelID, _ := graph.NodeExt(ctx, session, []string{"Ast:ModelInst"}, modelMatchProps, modelNodeProps)
+			modelAnnotations := astAnnotations(model.Annotations)
+			if location := sourceLocation(modelAnnotations); location != "" {
+				modelNodeProps["source"] = location
+			}
+			modelID, err := graph.NodeExt(ctx, session, []string{"Ast:ModelInst"}, modelMatchProps, modelNodeProps)
+			importError(sourceLocation(modelAnnotations), fmt.Sprintf("model '%s'", model.Name), err)
 			graph.Relation(ctx, session, stackID, modelID, []string{"Has"})
 
 			// MODEL -[HAS]-> CHANNELS
@@ -79,7 +134,12 @@ func (s *SimulationSpec) MergeGraph(ctx context.Context, session neo4j.SessionWi
 				if channel.Alias != "" {
 					channelNodeProps["alias"] = channel.Alias
 				}
-				channelID, _ := graph.NodeExt(ctx, session, []string{"Ast:ModelChannel"}, channelMatchProps, channelNodeProps)
+				location := sourceLocation(modelAnnotations, "channel", channel.Name)
+				if location != "" {
+					channelNodeProps["source"] = location
+				}
+				channelID, err := graph.NodeExt(ctx, session, []string{"Ast:ModelChannel"}, channelMatchProps, channelNodeProps)
+				importError(location, fmt.Sprintf("model '%s': channel '%s'", model.Name, channel.Name), err)
 				graph.Relation(ctx, session, modelID, channelID, []string{"Contains"})
 
 				connectQuery := `
@@ -126,7 +186,12 @@ func (s *SimulationSpec) MergeGraph(ctx context.Context, session neo4j.SessionWi
 								"var_value": vars.Value,
 							}
 							varNodeProps := map[string]any{}
-							varID, _ := graph.NodeExt(ctx, session, []string{"Ast:Var"}, varMatchProps, varNodeProps)
+							location := sourceLocation(modelAnnotations, "workflow", wf.Name, "var", vars.Name)
+							if location != "" {
+								varNodeProps["source"] = location
+							}
+							varID, err := graph.NodeExt(ctx, session, []string{"Ast:Var"}, varMatchProps, varNodeProps)
+							importError(location, fmt.Sprintf("model '%s': workflow '%s': var '%s'", model.Name, wf.Name, vars.Name), err)
 							graph.Relation(ctx, session, workflowID, varID, []string{"Has"})
 						}
 					}
@@ -2,6 +2,7 @@ package kind
 
 import (
 	"context"
+	"fmt"
 	"strconv"
 
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
@@ -56,7 +57,15 @@ func (s *StackSpec) MergeGraph(ctx context.Context, session neo4j.SessionWithCon
 					"annotations": mi.Annotations,
 					"model":       mi.Model.Name,
 				}
-				model_instance_id, _ := graph.NodeExt(ctx, session, []string{"Sim:ModelInst"}, match_props, node_props)
+				var location string
+				if mi.Annotations != nil {
+					location = sourceLocation(*mi.Annotations)
+				}
+				if location != "" {
+					node_props["source"] = location
+				}
+				model_instance_id, err := graph.NodeExt(ctx, session, []string{"Sim:ModelInst"}, match_props, node_props)
+				importError(location, fmt.Sprintf("model instance '%s'", mi.Name), err)
 				graph.Relation(ctx, session, stack_id, model_instance_id, []string{"Has"})
 
 				// Handle Channels and Selectors